@@ -0,0 +1,73 @@
+package httplog
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// abortWatch records when the request context was canceled during serving,
+// i.e. when the client disconnected.
+type abortWatch struct {
+	start time.Time
+	clock Clock
+	after atomic.Int64 // Elapsed nanoseconds at cancellation, or 0
+	stop  func() bool
+
+	// noticed is the elapsed nanoseconds when ClientGone first reported the
+	// client abort to the handler, or 0.
+	noticed atomic.Int64
+}
+
+// ClientGone reports whether the client of the request disconnected, i.e. the
+// request context was canceled, so that handlers can cheaply check mid-work
+// whether to carry on. It uses the same detection as ErrClientAborted, and the
+// time the handler first noticed the client abort is logged as
+// Schema.AbortNoticed.
+//
+// Outside of the request logger, it reports whether ctx was canceled.
+func ClientGone(ctx context.Context) bool {
+	rl := getRequestLog(ctx)
+	if rl == nil || rl.abort == nil {
+		return errors.Is(ctx.Err(), context.Canceled)
+	}
+	return rl.abort.gone()
+}
+
+// gone reports whether the client disconnected, and records when it was
+// first noticed.
+func (aw *abortWatch) gone() bool {
+	if aw.after.Load() == 0 {
+		return false
+	}
+	aw.noticed.CompareAndSwap(0, int64(max(aw.clock.Since(aw.start), 1)))
+	return true
+}
+
+func watchAbort(ctx context.Context, start time.Time, clock Clock) *abortWatch {
+	aw := &abortWatch{start: start, clock: clock}
+	aw.stop = context.AfterFunc(ctx, func() {
+		aw.after.Store(int64(max(aw.clock.Since(aw.start), 1)))
+	})
+	return aw
+}
+
+// kvs returns the progress of the response at the client abort: whether the
+// response headers were sent, the elapsed time at cancellation and the percentage
+// of the Content-Length written. The bytes written are logged as ResponseBytes.
+func (aw *abortWatch) kvs(s *Schema, status int, header http.Header, bytesWritten int) []any {
+	kvs := []any{s.AbortHeadersSent, status != 0}
+	if after := aw.after.Load(); after > 0 {
+		kvs = append(kvs, s.AbortElapsed, float64(time.Duration(after).Milliseconds()))
+	}
+	if noticed := aw.noticed.Load(); noticed > 0 {
+		kvs = append(kvs, s.AbortNoticed, float64(time.Duration(noticed).Milliseconds()))
+	}
+	if cl, err := strconv.ParseInt(header.Get("Content-Length"), 10, 64); err == nil && cl > 0 {
+		kvs = append(kvs, s.AbortProgress, min(float64(bytesWritten)*100/float64(cl), 100))
+	}
+	return kvs
+}
@@ -0,0 +1,18 @@
+package httplog_test
+
+import (
+	"testing"
+
+	"github.com/rickliujh/chi-httplogr/v3/httplogtest"
+)
+
+func TestAllocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("the race detector adds allocations")
+	}
+	httplogtest.CheckAllocs(t, httplogtest.Scenarios())
+}
+
+func BenchmarkRequestLogger(b *testing.B) {
+	httplogtest.RunBenchmarks(b, httplogtest.Scenarios())
+}
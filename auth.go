@@ -0,0 +1,124 @@
+package httplog
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+)
+
+// APIKeyFingerprint returns a short salted hash of the given API key (or any
+// other client credential), which is safe to be logged. The same key and salt
+// always produce the same fingerprint, so the abuse of a specific credential
+// can be traced in the logs without storing the secret itself.
+func APIKeyFingerprint(key, salt string) string {
+	mac := hmac.New(sha256.New, []byte(salt))
+	mac.Write([]byte(key))
+	return hex.EncodeToString(mac.Sum(nil)[:8])
+}
+
+// apiKeyFingerprint returns the fingerprint of the first API key found in the
+// given request headers, or an empty string if none was found.
+func apiKeyFingerprint(r *http.Request, o *Options) string {
+	for _, h := range o.LogAPIKeyHeaders {
+		if key := r.Header.Get(h); key != "" {
+			return APIKeyFingerprint(key, o.APIKeySalt)
+		}
+	}
+	return ""
+}
+
+// AuthOutcome is the outcome of the request authentication, see SetAuthResult.
+type AuthOutcome string
+
+const (
+	AuthSuccess   AuthOutcome = "success"
+	AuthFailure   AuthOutcome = "failure"
+	AuthAnonymous AuthOutcome = "anonymous"
+)
+
+// authResult holds the authentication result set by SetAuthResult.
+type authResult struct {
+	scheme  string
+	outcome AuthOutcome
+	reason  string
+}
+
+// SetAuthResult records the authentication result of the request, i.e. the
+// authentication scheme (e.g. "bearer", "basic", "mtls"), the outcome and the
+// reason of failures (e.g. "expired_token"), logged as Schema.AuthScheme,
+// Schema.AuthOutcome and Schema.AuthReason. Call it from the authentication
+// middleware, so that auth failures can be analyzed without parsing the
+// handler-specific messages. The last result set is logged.
+func SetAuthResult(ctx context.Context, scheme string, outcome AuthOutcome, reason string) {
+	if rl := getRequestLog(ctx); rl != nil {
+		rl.mu.Lock()
+		rl.auth = &authResult{scheme: scheme, outcome: outcome, reason: reason}
+		rl.mu.Unlock()
+	}
+}
+
+// authKVs returns the authentication result set by SetAuthResult, if any.
+func authKVs(ctx context.Context, s *Schema) []any {
+	rl := getRequestLog(ctx)
+	if rl == nil {
+		return nil
+	}
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	if rl.auth == nil {
+		return nil
+	}
+	kvs := []any{s.AuthScheme, rl.auth.scheme, s.AuthOutcome, string(rl.auth.outcome)}
+	if rl.auth.reason != "" {
+		kvs = append(kvs, s.AuthReason, rl.auth.reason)
+	}
+	return kvs
+}
+
+// authFailed reports whether the authentication or authorization of the request
+// failed, i.e. SetAuthResult recorded AuthFailure or the response status is
+// HTTP 401 or 403.
+func authFailed(ctx context.Context, statusCode int) bool {
+	if statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden {
+		return true
+	}
+	rl := getRequestLog(ctx)
+	if rl == nil {
+		return false
+	}
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	return rl.auth != nil && rl.auth.outcome == AuthFailure
+}
+
+// securityKVs returns the attributes of the security audit entry of the failed
+// request authentication, see Options.SecurityLogger: the client, the target
+// resource and the authentication result.
+func securityKVs(ctx context.Context, r *http.Request, statusCode int, o *Options, s *Schema) []any {
+	remoteAddr := r.RemoteAddr
+	if o.LogCDNHeaders {
+		if cdn := cdnHeaders(r); cdn.clientIP != "" {
+			remoteAddr = cdn.clientIP
+		}
+	}
+	remoteIP, _ := splitRemoteAddr(remoteAddr)
+
+	kvs := appendKVs(nil,
+		s.RequestRemoteIP, privacyHash(PrivacyRemoteIP, remoteIP, o),
+		s.RequestUserAgent, privacyHash(PrivacyUserAgent, r.UserAgent(), o),
+		s.RequestMethod, r.Method,
+		s.RequestURL, loggedURL(r, o),
+		s.RequestRoute, routePattern(r, o),
+		s.ResponseStatus, statusCode,
+	)
+	if id := requestID(ctx, r); id != "" {
+		kvs = appendKVs(kvs, s.RequestID, id)
+	}
+	if auth := authKVs(ctx, s); auth != nil {
+		return appendKVs(kvs, auth...)
+	}
+	return appendKVs(kvs, s.AuthOutcome, string(AuthFailure))
+}
@@ -0,0 +1,61 @@
+package httplog_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	httplog "github.com/rickliujh/chi-httplogr/v3"
+	"github.com/rickliujh/chi-httplogr/v3/httplogtest"
+)
+
+func TestSecurityLogger(t *testing.T) {
+	rec := httplogtest.NewRecorder()
+	security := httplogtest.NewRecorder()
+	handler := httplog.RequestLogger(rec.Logger(), &httplog.Options{
+		// The security audit entries aren't subject to Skip of the request logs.
+		Skip:           func(req *http.Request, respStatus int) bool { return true },
+		SecurityLogger: security.Logger(),
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/forbidden":
+			w.WriteHeader(http.StatusForbidden)
+		case "/expired":
+			httplog.SetAuthResult(r.Context(), "bearer", httplog.AuthFailure, "token expired")
+		case "/ok":
+			httplog.SetAuthResult(r.Context(), "bearer", httplog.AuthSuccess, "")
+		}
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/forbidden", nil)
+	req.RemoteAddr = "203.0.113.7:4321"
+	req.Header.Set("User-Agent", "curl/8.0")
+	rec.RoundTrip(handler, req)
+	entry := security.LastEntry()
+	for key, want := range map[string]any{
+		httplog.SchemaECS.RequestRemoteIP:  "203.0.113.7",
+		httplog.SchemaECS.RequestUserAgent: "curl/8.0",
+		httplog.SchemaECS.RequestMethod:    http.MethodGet,
+		httplog.SchemaECS.ResponseStatus:   http.StatusForbidden,
+		httplog.SchemaECS.AuthOutcome:      "failure",
+	} {
+		if !entry.HasKV(key, want) {
+			t.Errorf("security entry is missing %s=%v: %v", key, want, entry.KVs)
+		}
+	}
+
+	// A failure recorded by SetAuthResult is audited regardless of the status.
+	rec.RoundTrip(handler, httptest.NewRequest(http.MethodGet, "/expired", nil))
+	entry = security.LastEntry()
+	if !entry.HasKV(httplog.SchemaECS.ResponseStatus, http.StatusOK) || !entry.HasKV(httplog.SchemaECS.AuthReason, "token expired") {
+		t.Errorf("security entry is missing the auth failure: %v", entry.KVs)
+	}
+
+	rec.RoundTrip(handler, httptest.NewRequest(http.MethodGet, "/ok", nil))
+	if n := len(security.Entries()); n != 2 {
+		t.Errorf("got %d security entries, want 2 for the failures only", n)
+	}
+	if n := len(rec.Entries()); n != 0 {
+		t.Errorf("got %d request log entries, want all skipped", n)
+	}
+}
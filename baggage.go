@@ -0,0 +1,35 @@
+package httplog
+
+import (
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// baggageKVs returns the W3C Baggage entries of the request selected by
+// Options.LogBaggage as a nested object, or nil. The entry properties are
+// omitted, e.g. "synthetic=true;ttl=60" is logged as {"synthetic": "true"}.
+func baggageKVs(header http.Header, keys []string) map[string]any {
+	all := slices.Contains(keys, "*")
+
+	var baggage map[string]any
+	for _, v := range header.Values("baggage") {
+		for _, member := range strings.Split(v, ",") {
+			entry, _, _ := strings.Cut(member, ";")
+			key, value, ok := strings.Cut(entry, "=")
+			key = strings.TrimSpace(key)
+			if !ok || key == "" || (!all && !slices.Contains(keys, key)) {
+				continue
+			}
+			if unescaped, err := url.PathUnescape(strings.TrimSpace(value)); err == nil {
+				value = unescaped
+			}
+			if baggage == nil {
+				baggage = map[string]any{}
+			}
+			baggage[key] = value
+		}
+	}
+	return baggage
+}
@@ -0,0 +1,114 @@
+package httplog
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+// BandwidthStats holds the request and response bytes of the requests of one
+// status class (e.g. "2xx") and route, see Options.AccountBandwidth.
+type BandwidthStats struct {
+	StatusClass   string `json:"statusClass"`
+	Route         string `json:"route"`
+	Requests      uint64 `json:"requests"`
+	RequestBytes  uint64 `json:"requestBytes"`
+	ResponseBytes uint64 `json:"responseBytes"`
+}
+
+type bandwidthKey struct {
+	statusClass string
+	route       string
+}
+
+// bandwidth accounts the bytes of all request logger middlewares in the process.
+var bandwidth = struct {
+	mu    sync.Mutex
+	stats map[bandwidthKey]*BandwidthStats
+}{stats: map[bandwidthKey]*BandwidthStats{}}
+
+// byteCounter counts the bytes read from the request bodies not read through a
+// reqBodyReader, see Options.AccountBandwidth.
+type byteCounter struct {
+	io.ReadCloser
+	n int64
+}
+
+func (bc *byteCounter) Read(p []byte) (int, error) {
+	n, err := bc.ReadCloser.Read(p)
+	bc.n += int64(n)
+	return n, err
+}
+
+// accountBandwidth adds the request and response bytes to the bandwidth stats
+// of the request status class and bounded-cardinality route, see MetricsLabel.
+// The request bytes are the bytes read from the body, as the Content-Length is
+// unknown (-1) for chunked bodies.
+func accountBandwidth(r *http.Request, o *Options, status int, requestBytes int64, responseBytes int) {
+	key := bandwidthKey{statusClass: statusClass(status), route: MetricsLabel(r, o)}
+
+	bandwidth.mu.Lock()
+	defer bandwidth.mu.Unlock()
+	bs, ok := bandwidth.stats[key]
+	if !ok {
+		bs = &BandwidthStats{StatusClass: key.statusClass, Route: key.route}
+		bandwidth.stats[key] = bs
+	}
+	bs.Requests++
+	bs.RequestBytes += uint64(requestBytes)
+	bs.ResponseBytes += uint64(responseBytes)
+}
+
+// ReadBandwidth returns a snapshot of the bandwidth stats of all request logger
+// middlewares in the process, ordered by status class and route. It's empty
+// unless Options.AccountBandwidth is set.
+func ReadBandwidth() []BandwidthStats {
+	bandwidth.mu.Lock()
+	result := make([]BandwidthStats, 0, len(bandwidth.stats))
+	for _, bs := range bandwidth.stats {
+		result = append(result, *bs)
+	}
+	bandwidth.mu.Unlock()
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].StatusClass != result[j].StatusClass {
+			return result[i].StatusClass < result[j].StatusClass
+		}
+		return result[i].Route < result[j].Route
+	})
+	return result
+}
+
+// LogBandwidthSummary logs a summary line of the bandwidth stats accounted
+// since the process start per status class and route every interval, until
+// ctx is canceled. Run it in a goroutine, e.g.:
+//
+//	go httplog.LogBandwidthSummary(ctx, logger, time.Minute)
+//
+// The stats are accounted by the middlewares with Options.AccountBandwidth.
+func LogBandwidthSummary(ctx context.Context, logger logr.Logger, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		for _, bs := range ReadBandwidth() {
+			logger.Info("HTTP bandwidth summary",
+				"statusClass", bs.StatusClass,
+				"route", bs.Route,
+				"requests", bs.Requests,
+				"requestBytes", bs.RequestBytes,
+				"responseBytes", bs.ResponseBytes,
+			)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package httplog_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	httplog "github.com/rickliujh/chi-httplogr/v3"
+	"github.com/rickliujh/chi-httplogr/v3/httplogtest"
+)
+
+func TestAccountBandwidthChunked(t *testing.T) {
+	handler := httplog.RequestLogger(httplogtest.NewRecorder().Logger(), &httplog.Options{
+		AccountBandwidth: true,
+		MetricsLabelFunc: func(r *http.Request) string { return "/bandwidth-chunked" },
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		w.Write([]byte("ok"))
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("hello"))
+	req.ContentLength = -1 // chunked
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	for _, bs := range httplog.ReadBandwidth() {
+		if bs.Route == "/bandwidth-chunked" {
+			if bs.RequestBytes != 5 || bs.ResponseBytes != 2 {
+				t.Errorf("got %d request and %d response bytes, want 5 and 2", bs.RequestBytes, bs.ResponseBytes)
+			}
+			return
+		}
+	}
+	t.Error("no bandwidth stats of the route")
+}
@@ -0,0 +1,144 @@
+package httplog
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sync/atomic"
+
+	"github.com/go-logr/logr"
+)
+
+// bodyOverride is the per-request override of body logging, see LogBody and SkipBody.
+type bodyOverride int
+
+const (
+	bodyDefault bodyOverride = iota
+	bodyLog
+	bodySkip
+)
+
+// LogBody enables logging of the request and response bodies of the request,
+// overriding Options.LogRequestBody and Options.LogResponseBody, e.g. to preserve
+// the payload of a specific failed request.
+//
+// Only the parts of the bodies read or written after the call can be captured,
+// unless the body logging was enabled from the start, so call it early.
+func LogBody(ctx context.Context) {
+	setBodyOverride(ctx, bodyLog)
+}
+
+// SkipBody disables logging of the request and response bodies of the request,
+// overriding Options.LogRequestBody and Options.LogResponseBody, e.g. for handlers
+// with sensitive payloads. The request body isn't passed to Options.LogExtraAttrs
+// either.
+func SkipBody(ctx context.Context) {
+	setBodyOverride(ctx, bodySkip)
+}
+
+func setBodyOverride(ctx context.Context, override bodyOverride) {
+	if rl := getRequestLog(ctx); rl != nil {
+		rl.mu.Lock()
+		rl.body = override
+		rl.mu.Unlock()
+	}
+}
+
+func (rl *requestLog) bodyOverride() bodyOverride {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	return rl.body
+}
+
+// reqBodyReader tees the request body read by the handler, if the body is
+// captured from the start or body logging was enabled by LogBody.
+type reqBodyReader struct {
+	io.ReadCloser
+	buf     io.Writer
+	capture bool
+	rl      *requestLog
+	n       int64 // bytes read
+
+	// oversized marks the body once more than Options.OversizedRequestBytes were
+	// read, also without Content-Length; with Options.SkipOversizedBodies, the
+	// capture stops there.
+	oversizedAt   int64
+	oversized     bool
+	skipOversized bool
+}
+
+func (br *reqBodyReader) Read(p []byte) (int, error) {
+	n, err := br.ReadCloser.Read(p)
+	br.n += int64(n)
+	if br.oversizedAt > 0 && br.n > br.oversizedAt {
+		br.oversized = true
+	}
+	if n > 0 && (br.capture || br.rl.bodyOverride() == bodyLog) && !(br.oversized && br.skipOversized) {
+		br.buf.Write(p[:n])
+	}
+	return n, err
+}
+
+// activeCaptures counts the requests whose bodies are being captured, across all
+// request logger middlewares, see Options.MaxGlobalConcurrentCaptures.
+var activeCaptures atomic.Int64
+
+// cappedBuffer captures at most limit bytes of the body written to it, so that
+// large request bodies are never fully buffered, see Options.MaxCaptureBytes.
+type cappedBuffer struct {
+	buf       *bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (cb *cappedBuffer) Write(p []byte) (int, error) {
+	n := min(len(p), cb.limit-cb.buf.Len())
+	if n < len(p) {
+		cb.truncated = true
+	}
+	if n > 0 {
+		cb.buf.Write(p[:n])
+	}
+	return len(p), nil
+}
+
+// reqCaptureLimit returns the maximum number of request body bytes captured in
+// memory, or -1 if unlimited. The logged body needs Options.LogBodyMaxLen bytes
+// and one extra byte, so that logBody knows the body was trimmed; the hooks
+// reading the body, i.e. hooks, need up to Options.MaxCaptureBytes, except for
+// Options.LogExtraAttrs, which gets the full body.
+func reqCaptureLimit(hooks bool, o *Options) int {
+	if o.LogBodyMaxLen <= 0 || o.LogExtraAttrs != nil {
+		return -1
+	}
+	limit := o.LogBodyMaxLen + 1
+	if hooks {
+		limit = max(limit, maxCaptureBytes(o))
+	}
+	return limit
+}
+
+// maxCaptureBytes returns Options.MaxCaptureBytes or its default.
+func maxCaptureBytes(o *Options) int {
+	if o.MaxCaptureBytes == 0 {
+		return defaultMaxCaptureBytes
+	}
+	return o.MaxCaptureBytes
+}
+
+// logBodyEntries emits the request and response bodies as separate debug-level
+// log entries, linked to the request log by the request ID and sequence number.
+func logBodyEntries(logger logr.Logger, bodyKVs []any, linkKVs []any, s *Schema, o *Options) {
+	for i := 0; i+1 < len(bodyKVs); i += 2 {
+		msg := "HTTP request body"
+		if bodyKVs[i] == s.ResponseBody {
+			msg = "HTTP response body"
+		}
+
+		kvs := appendKVs(append([]any(nil), linkKVs...), bodyKVs[i], bodyKVs[i+1])
+		if s.grouped() {
+			kvs = groupKVs(kvs, s)
+		}
+		emit(logger.V(1), 1, nil, msg, kvs, o)
+	}
+}
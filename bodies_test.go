@@ -0,0 +1,235 @@
+package httplog_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	httplog "github.com/rickliujh/chi-httplogr/v3"
+	"github.com/rickliujh/chi-httplogr/v3/httplogtest"
+)
+
+func TestCapturedRequestBody(t *testing.T) {
+	rec := httplogtest.NewRecorder()
+	handler := httplog.RequestLogger(rec.Logger(), &httplog.Options{
+		LogRequestBody: func(r *http.Request) bool { return true },
+		LogBodyMaxLen:  16,
+		GraphQLPaths:   []string{"/graphql"},
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+
+	query := `{"operationName":"GetUser","query":"query GetUser { user { id } }` + strings.Repeat(" ", 5000) + `"}`
+	for _, path := range []string{"/", "/graphql"} {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(query))
+		req.Header.Set("Content-Type", "application/json")
+		_, entry := rec.RoundTrip(handler, req)
+		v, _ := entry.Value(httplog.SchemaECS.RequestBody)
+		if body, _ := v.(string); body != query[:16]+"... [trimmed]" {
+			t.Errorf("%s: got body %q, want it trimmed to 16 bytes", path, body)
+		}
+		if path == "/graphql" && !entry.HasKV(httplog.SchemaECS.GraphQLOperationName, "GetUser") {
+			t.Errorf("GraphQL operation of the trimmed body not logged: %v", entry.KVs)
+		}
+	}
+}
+
+func TestMaxCaptureBytes(t *testing.T) {
+	var inspected int
+	rec := httplogtest.NewRecorder()
+	handler := httplog.RequestLogger(rec.Logger(), &httplog.Options{
+		MaxCaptureBytes: 2000,
+		InspectRequestBody: func(r *http.Request, body []byte) []any {
+			inspected = len(body)
+			return nil
+		},
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", strings.NewReader(strings.Repeat("x", 5000))))
+	if inspected != 2000 {
+		t.Errorf("got %d bytes captured, want 2000", inspected)
+	}
+}
+
+func TestSkipOversizedChunkedBody(t *testing.T) {
+	var inspected int
+	rec := httplogtest.NewRecorder()
+	handler := httplog.RequestLogger(rec.Logger(), &httplog.Options{
+		Schema:                httplog.SchemaECS,
+		OversizedRequestBytes: 100,
+		SkipOversizedBodies:   true,
+		InspectRequestBody: func(r *http.Request, body []byte) []any {
+			inspected = len(body)
+			return nil
+		},
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(strings.Repeat("x", 1000)))
+	req.ContentLength = -1 // chunked
+	_, entry := rec.RoundTrip(handler, req)
+	if !entry.HasKV(httplog.SchemaECS.RequestOversized, true) {
+		t.Errorf("request not marked oversized: %v", entry.KVs)
+	}
+	if inspected != 0 {
+		t.Errorf("got %d bytes captured, want 0", inspected)
+	}
+}
+
+func TestMaxGlobalConcurrentCaptures(t *testing.T) {
+	rec := httplogtest.NewRecorder()
+	opts := &httplog.Options{
+		Schema:                      httplog.SchemaECS,
+		MaxGlobalConcurrentCaptures: 1,
+		InspectRequestBody:          func(r *http.Request, body []byte) []any { return nil },
+	}
+	started, release := make(chan struct{}), make(chan struct{})
+	blocking := httplog.RequestLogger(rec.Logger(), opts)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	}))
+	other := httplog.RequestLogger(rec.Logger(), opts)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		blocking.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", strings.NewReader("{}")))
+	}()
+	<-started
+	_, entry := rec.RoundTrip(other, httptest.NewRequest(http.MethodPost, "/", strings.NewReader("{}")))
+	close(release)
+	<-done
+
+	if !entry.HasKV(httplog.SchemaECS.BodyCaptureSkipped, true) {
+		t.Errorf("body capture not skipped: %v", entry.KVs)
+	}
+}
+
+func TestReplayStoreMaxCaptureBytes(t *testing.T) {
+	tests := []struct {
+		name      string
+		size      int
+		chunked   bool
+		wantStore bool
+	}{
+		{name: "Small", size: 100, wantStore: true},
+		{name: "Large", size: 5000},
+		{name: "LargeChunked", size: 5000, chunked: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stored bool
+			rec := httplogtest.NewRecorder()
+			handler := httplog.RequestLogger(rec.Logger(), &httplog.Options{
+				MaxCaptureBytes: 2000,
+				ReplayStore: httplog.ReplayStoreFunc(func(r *http.Request, body []byte) (string, error) {
+					stored = true
+					return "ref", nil
+				}),
+			})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				io.Copy(io.Discard, r.Body)
+				w.WriteHeader(http.StatusInternalServerError)
+			}))
+
+			req := httptest.NewRequest(http.MethodPut, "/", strings.NewReader(strings.Repeat("x", tt.size)))
+			if tt.chunked {
+				req.ContentLength = -1
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+			if stored != tt.wantStore {
+				t.Errorf("stored: %v, want %v", stored, tt.wantStore)
+			}
+		})
+	}
+}
+
+func TestInvalidSpillBodyKey(t *testing.T) {
+	dir := t.TempDir()
+	rec := httplogtest.NewRecorder()
+	handler := httplog.RequestLogger(rec.Logger(), &httplog.Options{
+		LogRequestBody:     func(req *http.Request) bool { return true },
+		SpillBodyThreshold: 16,
+		SpillBodyDir:       dir,
+		SpillBodyKey:       []byte("short"),
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+
+	if entry := rec.LastEntry(); !entry.IsError || !strings.Contains(entry.Message, "SpillBodyKey") {
+		t.Errorf("got entry %q, want the invalid SpillBodyKey error", entry.Message)
+	}
+	rec.RoundTrip(handler, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(strings.Repeat("x", 100))))
+	if files, _ := os.ReadDir(dir); len(files) > 0 {
+		t.Errorf("body spilled with an invalid key: %v", files)
+	}
+}
+
+func TestMaxCaptureBytesConsumers(t *testing.T) {
+	body := `{"query":"query Q { user { id } }","padding":"` + strings.Repeat("x", 5000) + `"}`
+	serve := func(o *httplog.Options, contentLength int64) httplogtest.Entry {
+		rec := httplogtest.NewRecorder()
+		o.Levels = &httplog.Levels{Warn: 1, Info: 1, Debug: 1}
+		o.MaxCaptureBytes = 100
+		handler := httplog.RequestLogger(rec.Logger(), o)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			io.Copy(io.Discard, r.Body)
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		req := httptest.NewRequest(http.MethodPut, "/graphql", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.ContentLength = contentLength
+		_, entry := rec.RoundTrip(handler, req)
+		return entry
+	}
+
+	t.Run("LogExtraAttrs", func(t *testing.T) {
+		var got string
+		serve(&httplog.Options{LogExtraAttrs: func(r *http.Request, reqBody string, status int) []any {
+			got = reqBody
+			return nil
+		}}, -1)
+		if got != body {
+			t.Errorf("got %d bytes, want the full body of %d bytes", len(got), len(body))
+		}
+	})
+	t.Run("ValidateRequestBody", func(t *testing.T) {
+		var called bool
+		entry := serve(&httplog.Options{ValidateRequestBody: func(r *http.Request, body []byte) error {
+			called = true
+			return nil
+		}}, -1)
+		if called || entry.HasKV(httplog.SchemaECS.RequestBodyValid, true) {
+			t.Errorf("truncated body validated: %v", entry.KVs)
+		}
+	})
+	t.Run("ReplayStore", func(t *testing.T) {
+		for _, contentLength := range []int64{int64(len(body)), -1} {
+			var stored bool
+			serve(&httplog.Options{ReplayStore: httplog.ReplayStoreFunc(func(r *http.Request, body []byte) (string, error) {
+				stored = true
+				return "ref", nil
+			})}, contentLength)
+			if stored {
+				t.Errorf("Content-Length %d: truncated body stored", contentLength)
+			}
+		}
+	})
+	t.Run("GraphQLPaths", func(t *testing.T) {
+		entry := serve(&httplog.Options{GraphQLPaths: []string{"/graphql"}}, -1)
+		if _, ok := entry.Value(httplog.SchemaECS.GraphQLOperationType); ok {
+			t.Errorf("GraphQL operation parsed from a truncated body: %v", entry.KVs)
+		}
+	})
+}
@@ -0,0 +1,59 @@
+package httplog
+
+import (
+	"encoding/hex"
+)
+
+// BodyWindow is a byte range of the request or response body to be logged, see
+// Options.LogBodyWindows.
+type BodyWindow struct {
+	Offset int // Offset of the first byte
+	Length int // Number of bytes
+}
+
+// windowCapture captures the body windows of the body written to it.
+type windowCapture struct {
+	windows []BodyWindow
+	bufs    [][]byte
+	offset  int // offset of the next byte written
+}
+
+func newWindowCapture(windows []BodyWindow) *windowCapture {
+	return &windowCapture{windows: windows, bufs: make([][]byte, len(windows))}
+}
+
+func (wc *windowCapture) Write(p []byte) (int, error) {
+	for i, w := range wc.windows {
+		start := max(w.Offset, wc.offset)
+		end := min(w.Offset+w.Length, wc.offset+len(p))
+		if start < end {
+			wc.bufs[i] = append(wc.bufs[i], p[start-wc.offset:end-wc.offset]...)
+		}
+	}
+	wc.offset += len(p)
+	return len(p), nil
+}
+
+func (wc *windowCapture) capturing() bool {
+	return true
+}
+
+// kvs returns the captured windows, with the bytes hex-encoded, or nil if none
+// was captured.
+func (wc *windowCapture) kvs() []map[string]any {
+	if wc == nil {
+		return nil
+	}
+	var windows []map[string]any
+	for i, buf := range wc.bufs {
+		if len(buf) == 0 {
+			continue
+		}
+		windows = append(windows, map[string]any{
+			"offset": wc.windows[i].Offset,
+			"length": len(buf),
+			"hex":    hex.EncodeToString(buf),
+		})
+	}
+	return windows
+}
@@ -0,0 +1,61 @@
+package httplog
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// burstDetector counts the HTTP 5xx responses per route in fixed windows, see
+// Options.ErrorBurstThreshold.
+type burstDetector struct {
+	threshold int
+	window    time.Duration
+
+	mu     sync.Mutex
+	routes map[string]*burstWindow
+}
+
+type burstWindow struct {
+	start time.Time
+	count int
+}
+
+// newBurstDetector returns the burst detector, or nil if the burst detection
+// is disabled.
+func newBurstDetector(o *Options) *burstDetector {
+	if o.ErrorBurstThreshold <= 0 {
+		return nil
+	}
+	window := o.ErrorBurstWindow
+	if window <= 0 {
+		window = time.Minute
+	}
+	return &burstDetector{threshold: o.ErrorBurstThreshold, window: window, routes: map[string]*burstWindow{}}
+}
+
+// add counts the HTTP 5xx response of the route and reports whether it makes
+// the route reach the threshold within the current window, i.e. once per window.
+func (bd *burstDetector) add(route string, now time.Time) (burst bool, count int) {
+	bd.mu.Lock()
+	defer bd.mu.Unlock()
+
+	w, ok := bd.routes[route]
+	if !ok || now.Sub(w.start) >= bd.window {
+		w = &burstWindow{start: now}
+		bd.routes[route] = w
+	}
+	w.count++
+	return w.count == bd.threshold, w.count
+}
+
+// burstKVs returns the attributes of the burst summary entry.
+func burstKVs(r *http.Request, route string, count int, window time.Duration, s *Schema) []any {
+	return appendKVs(nil,
+		s.ErrorBurst, true,
+		s.ErrorBurstCount, count,
+		s.ErrorBurstWindow, float64(window.Milliseconds()),
+		s.RequestMethod, r.Method,
+		s.RequestRoute, route,
+	)
+}
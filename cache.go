@@ -0,0 +1,98 @@
+package httplog
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Normalized cache statuses logged as Schema.CacheStatus.
+const (
+	CacheHit         = "hit"
+	CacheMiss        = "miss"
+	CacheStale       = "stale"
+	CacheExpired     = "expired"
+	CacheBypass      = "bypass"
+	CacheRevalidated = "revalidated"
+	CacheDynamic     = "dynamic"
+)
+
+// SetCacheStatus sets the cache status on the request log, e.g. httplog.CacheHit.
+// It takes precedence over the status derived from the response headers.
+func SetCacheStatus(ctx context.Context, status string) {
+	if rl := getRequestLog(ctx); rl != nil {
+		rl.mu.Lock()
+		rl.cacheStatus = status
+		rl.mu.Unlock()
+	}
+}
+
+// cacheStatus returns the normalized cache status of the response, as set by
+// SetCacheStatus or derived from the standard cache response headers.
+func cacheStatus(ctx context.Context, header http.Header) string {
+	if rl := getRequestLog(ctx); rl != nil {
+		rl.mu.Lock()
+		status := rl.cacheStatus
+		rl.mu.Unlock()
+		if status != "" {
+			return status
+		}
+	}
+
+	// RFC 9211, e.g. "ExampleCache; hit" or "ExampleCache; fwd=miss".
+	if v := header.Get("Cache-Status"); v != "" {
+		params := strings.ToLower(v)
+		switch {
+		case strings.Contains(params, "; hit"):
+			return CacheHit
+		case strings.Contains(params, "fwd=stale"):
+			return CacheExpired
+		case strings.Contains(params, "fwd=bypass"), strings.Contains(params, "fwd=uri-miss"):
+			return CacheBypass
+		case strings.Contains(params, "fwd="):
+			return CacheMiss
+		}
+	}
+
+	// Cloudflare, e.g. "HIT", "MISS", "EXPIRED" or "DYNAMIC".
+	if v := header.Get("CF-Cache-Status"); v != "" {
+		return normalizeCacheStatus(v)
+	}
+
+	// Varnish, Squid, Fastly etc., e.g. "HIT", "MISS, HIT" or "HIT from proxy".
+	if v := header.Get("X-Cache"); v != "" {
+		// The last value reflects the cache closest to the client.
+		if i := strings.LastIndexByte(v, ','); i >= 0 {
+			v = v[i+1:]
+		}
+		status, _, _ := strings.Cut(strings.TrimSpace(v), " ")
+		return normalizeCacheStatus(status)
+	}
+
+	if age, err := strconv.Atoi(header.Get("Age")); err == nil && age > 0 {
+		return CacheHit
+	}
+
+	return ""
+}
+
+func normalizeCacheStatus(status string) string {
+	switch status = strings.ToLower(strings.TrimSpace(status)); status {
+	case "hit", "tcp_hit", "tcp_mem_hit":
+		return CacheHit
+	case "miss", "tcp_miss":
+		return CacheMiss
+	case "stale", "updating":
+		return CacheStale
+	case "expired", "refresh_hit":
+		return CacheExpired
+	case "bypass", "pass":
+		return CacheBypass
+	case "revalidated", "tcp_refresh_hit":
+		return CacheRevalidated
+	case "dynamic":
+		return CacheDynamic
+	}
+	return status
+}
@@ -0,0 +1,137 @@
+package httplog
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+)
+
+// The optional operations of response writers, see Options.LogWriterCapabilities.
+const (
+	WriterFlush  = "flush"
+	WriterHijack = "hijack"
+	WriterPush   = "push"
+)
+
+// UnsupportedWriterError is the panic value of the response writer operations
+// not supported by the underlying writer, see Options.FailUnsupportedWriterOps.
+type UnsupportedWriterError struct {
+	// Op is the unsupported operation, e.g. WriterHijack.
+	Op string
+}
+
+// Error implements error.
+func (e *UnsupportedWriterError) Error() string {
+	return "httplog: response writer doesn't support " + e.Op
+}
+
+// Unwrap returns http.ErrNotSupported.
+func (e *UnsupportedWriterError) Unwrap() error {
+	return http.ErrNotSupported
+}
+
+// findWriter returns the first writer of the type in the chain of writers
+// unwrapped by Unwrap, as http.ResponseController does.
+func findWriter[T any](w http.ResponseWriter) (T, bool) {
+	for {
+		if t, ok := w.(T); ok {
+			return t, true
+		}
+		u, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			var zero T
+			return zero, false
+		}
+		w = u.Unwrap()
+	}
+}
+
+// writerCapabilities returns the optional operations supported by the writer or
+// the writers it wraps.
+func writerCapabilities(w http.ResponseWriter) []string {
+	capabilities := []string{}
+	_, fl := findWriter[http.Flusher](w)
+	_, fe := findWriter[interface{ FlushError() error }](w)
+	if fl || fe {
+		capabilities = append(capabilities, WriterFlush)
+	}
+	if _, ok := findWriter[http.Hijacker](w); ok {
+		capabilities = append(capabilities, WriterHijack)
+	}
+	if _, ok := findWriter[http.Pusher](w); ok {
+		capabilities = append(capabilities, WriterPush)
+	}
+	return capabilities
+}
+
+// strictWriter implements http.Flusher unconditionally, and http.Hijacker on
+// HTTP/1.x, and panics with an UnsupportedWriterError on the operations not
+// supported by the underlying writer, so that the handler fails fast instead of
+// silently not flushing or failing with an opaque error. http.Pusher is only
+// implemented if supported, since handlers detect server push by the interface,
+// as they detect hijacking on HTTP/2.
+type strictWriter struct {
+	http.ResponseWriter
+}
+
+// wrapStrict returns w wrapped by the strictWriter for the protocol version of
+// the request, see Options.FailUnsupportedWriterOps.
+func wrapStrict(w http.ResponseWriter, protoMajor int) http.ResponseWriter {
+	sw := strictWriter{ResponseWriter: w}
+	if protoMajor == 1 {
+		return &strictHTTP1Writer{sw}
+	}
+	if _, ok := findWriter[http.Pusher](w); ok {
+		return &strictHTTP2Writer{sw}
+	}
+	return &sw
+}
+
+type strictHTTP1Writer struct {
+	strictWriter
+}
+
+type strictHTTP2Writer struct {
+	strictWriter
+}
+
+func (w *strictWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *strictWriter) Flush() {
+	if err := w.FlushError(); err != nil {
+		panic(err)
+	}
+}
+
+func (w *strictWriter) FlushError() error {
+	if f, ok := findWriter[http.Flusher](w.ResponseWriter); ok {
+		f.Flush()
+		return nil
+	}
+	if f, ok := findWriter[interface{ FlushError() error }](w.ResponseWriter); ok {
+		return f.FlushError()
+	}
+	panic(&UnsupportedWriterError{Op: WriterFlush})
+}
+
+func (w *strictHTTP1Writer) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if hj, ok := findWriter[http.Hijacker](w.ResponseWriter); ok {
+		return hj.Hijack()
+	}
+	panic(&UnsupportedWriterError{Op: WriterHijack})
+}
+
+func (w *strictHTTP2Writer) Push(target string, opts *http.PushOptions) error {
+	p, _ := findWriter[http.Pusher](w.ResponseWriter)
+	return p.Push(target, opts)
+}
+
+func (w *strictWriter) ReadFrom(r io.Reader) (int64, error) {
+	if rf, ok := w.ResponseWriter.(io.ReaderFrom); ok {
+		return rf.ReadFrom(r)
+	}
+	return io.Copy(writerOnly{w.ResponseWriter}, r)
+}
@@ -0,0 +1,70 @@
+package httplog_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	httplog "github.com/rickliujh/chi-httplogr/v3"
+	"github.com/rickliujh/chi-httplogr/v3/httplogtest"
+)
+
+func TestFailUnsupportedWriterOps(t *testing.T) {
+	tests := []struct {
+		name       string
+		protoMajor int
+		wantHijack bool
+	}{
+		{name: "HTTP1", protoMajor: 1, wantHijack: true},
+		{name: "HTTP2", protoMajor: 2, wantHijack: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var hijacker, pusher, flusher bool
+			rec := httplogtest.NewRecorder()
+			handler := httplog.RequestLogger(rec.Logger(), &httplog.Options{
+				FailUnsupportedWriterOps: true,
+			})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, hijacker = w.(http.Hijacker)
+				_, pusher = w.(http.Pusher)
+				_, flusher = w.(http.Flusher)
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.ProtoMajor = tt.protoMajor
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			if hijacker != tt.wantHijack {
+				t.Errorf("http.Hijacker: %v, want %v", hijacker, tt.wantHijack)
+			}
+			if pusher {
+				t.Errorf("http.Pusher implemented, but not supported")
+			}
+			if !flusher {
+				t.Errorf("http.Flusher not implemented")
+			}
+		})
+	}
+}
+
+func TestLogResponseControllerInterfaces(t *testing.T) {
+	var hijacker, pusher, flusher bool
+	rec := httplogtest.NewRecorder()
+	handler := httplog.RequestLogger(rec.Logger(), &httplog.Options{
+		LogResponseController: true,
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, hijacker = w.(http.Hijacker)
+		_, pusher = w.(http.Pusher)
+		_, flusher = w.(http.Flusher)
+	}))
+
+	// The recorder only implements http.Flusher.
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	if hijacker || pusher {
+		t.Errorf("http.Hijacker: %v, http.Pusher: %v, want neither", hijacker, pusher)
+	}
+	if !flusher {
+		t.Errorf("http.Flusher not implemented")
+	}
+}
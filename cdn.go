@@ -0,0 +1,52 @@
+package httplog
+
+import (
+	"net/http"
+	"strings"
+)
+
+// cdnInfo holds the request attributes set by CDN edge servers.
+type cdnInfo struct {
+	clientIP string // Original client IP address
+	scheme   string // Original URL scheme
+	rayID    string // CDN request ID
+	edge     string // CDN edge location or cache node
+}
+
+// cdnHeaders returns the request attributes found in the common CDN headers of
+// Cloudflare, Fastly, Akamai and Amazon CloudFront.
+func cdnHeaders(r *http.Request) cdnInfo {
+	var cdn cdnInfo
+
+	for _, h := range []string{"CF-Connecting-IP", "True-Client-IP", "Fastly-Client-IP"} {
+		if ip := r.Header.Get(h); ip != "" {
+			cdn.clientIP = ip
+			break
+		}
+	}
+
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto == "http" || proto == "https" {
+		cdn.scheme = proto
+	}
+
+	switch {
+	case r.Header.Get("CF-Ray") != "":
+		// Cloudflare, e.g. "7d2b8a8f6b2c1234-FRA", suffixed by the edge location.
+		cdn.rayID = r.Header.Get("CF-Ray")
+		if i := strings.LastIndexByte(cdn.rayID, '-'); i >= 0 {
+			cdn.edge = cdn.rayID[i+1:]
+		}
+	case r.Header.Get("X-Amz-Cf-Id") != "":
+		cdn.rayID = r.Header.Get("X-Amz-Cf-Id")
+		cdn.edge = r.Header.Get("X-Amz-Cf-Pop")
+	case r.Header.Get("X-Akamai-Request-ID") != "":
+		cdn.rayID = r.Header.Get("X-Akamai-Request-ID")
+	}
+
+	// Fastly, e.g. "cache-fra19120-FRA, cache-ams21020-AMS".
+	if servedBy := r.Header.Get("X-Served-By"); servedBy != "" && cdn.edge == "" {
+		cdn.edge = servedBy
+	}
+
+	return cdn
+}
@@ -0,0 +1,132 @@
+package httplog
+
+import (
+	"net/http"
+	"strings"
+)
+
+// clientHintsKVs returns the User-Agent Client Hints of the request, i.e. the
+// brands (Sec-CH-UA), platform (Sec-CH-UA-Platform) and mobile flag (Sec-CH-UA-Mobile).
+func clientHintsKVs(header http.Header, s *Schema) []any {
+	var kvs []any
+	if brands := clientHintBrands(header.Get("Sec-CH-UA")); len(brands) > 0 {
+		kvs = append(kvs, s.ClientBrands, brands)
+	}
+	if platform := strings.Trim(header.Get("Sec-CH-UA-Platform"), `" `); platform != "" {
+		kvs = append(kvs, s.ClientPlatform, platform)
+	}
+	switch strings.TrimSpace(header.Get("Sec-CH-UA-Mobile")) {
+	case "?1":
+		kvs = append(kvs, s.ClientMobile, true)
+	case "?0":
+		kvs = append(kvs, s.ClientMobile, false)
+	}
+	return kvs
+}
+
+// clientHintBrands parses the Sec-CH-UA structured header list (RFC 8941), e.g.
+// `"Chromium";v="118", "Google Chrome";v="118", "Not=A?Brand";v="99"`, into
+// brands with major versions, e.g. ["Chromium 118", "Google Chrome 118"].
+// The fake GREASE brands are omitted.
+func clientHintBrands(v string) []string {
+	var brands []string
+	p := sfParser{s: v}
+	for {
+		p.skipSpace()
+		if p.done() {
+			return brands
+		}
+		name := p.bareItem()
+		var version string
+		for p.consume(';') {
+			p.skipSpace()
+			key := p.token()
+			var value string
+			if p.consume('=') {
+				value = p.bareItem()
+			}
+			if key == "v" {
+				version = value
+			}
+		}
+		if name != "" && !isGreaseBrand(name) {
+			if version != "" {
+				name += " " + version
+			}
+			brands = append(brands, name)
+		}
+		// Skip the rest of a malformed item up to the next member of the list.
+		for !p.done() && !p.consume(',') {
+			p.i++
+		}
+	}
+}
+
+// sfParser parses the items of a structured header list. It's lenient: the
+// malformed items are skipped by the caller.
+type sfParser struct {
+	s string
+	i int
+}
+
+func (p *sfParser) done() bool { return p.i >= len(p.s) }
+
+func (p *sfParser) skipSpace() {
+	for !p.done() && (p.s[p.i] == ' ' || p.s[p.i] == '\t') {
+		p.i++
+	}
+}
+
+func (p *sfParser) consume(c byte) bool {
+	if !p.done() && p.s[p.i] == c {
+		p.i++
+		return true
+	}
+	return false
+}
+
+// bareItem returns the string or token at the current position, or the raw
+// text up to the next delimiter for the other item types, e.g. numbers.
+func (p *sfParser) bareItem() string {
+	if !p.consume('"') {
+		return p.token()
+	}
+	var b strings.Builder
+	for !p.done() {
+		c := p.s[p.i]
+		p.i++
+		switch c {
+		case '\\':
+			if !p.done() {
+				b.WriteByte(p.s[p.i])
+				p.i++
+			}
+		case '"':
+			return b.String()
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+func (p *sfParser) token() string {
+	start := p.i
+	for !p.done() && !strings.ContainsRune(",;= \t\"", rune(p.s[p.i])) {
+		p.i++
+	}
+	return p.s[start:p.i]
+}
+
+// isGreaseBrand reports whether the brand is an intentionally fake GREASE brand,
+// e.g. "Not=A?Brand", "Not_A Brand" or "(Not(A:Brand"; they are "Not A Brand"
+// with arbitrary punctuation.
+func isGreaseBrand(brand string) bool {
+	letters := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' {
+			return r
+		}
+		return -1
+	}, brand)
+	return letters == "NotABrand"
+}
@@ -0,0 +1,39 @@
+package httplog_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	httplog "github.com/rickliujh/chi-httplogr/v3"
+	"github.com/rickliujh/chi-httplogr/v3/httplogtest"
+)
+
+func TestClientHintBrands(t *testing.T) {
+	tests := []struct {
+		header string
+		want   []string
+	}{
+		{`"Chromium";v="118", "Google Chrome";v="118", "Not=A?Brand";v="99"`, []string{"Chromium 118", "Google Chrome 118"}},
+		{`"(Not(A:Brand";v="8", "Chromium";v="120"`, []string{"Chromium 120"}},
+		{`"Not)A;Brand";v="24", "Brand; with, delimiters";v="1"`, []string{"Brand; with, delimiters 1"}},
+		{`"Escaped \"quote\"";v="2"`, []string{`Escaped "quote" 2`}},
+		{`"Chromium"`, []string{"Chromium"}},
+	}
+	for _, tt := range tests {
+		rec := httplogtest.NewRecorder()
+		handler := httplog.RequestLogger(rec.Logger(), &httplog.Options{
+			Schema:         httplog.SchemaECS,
+			Levels:         &httplog.Levels{Warn: 1, Info: 1, Debug: 1},
+			LogClientHints: true,
+		})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Sec-CH-UA", tt.header)
+		_, entry := rec.RoundTrip(handler, req)
+		if got, _ := entry.Value(httplog.SchemaECS.ClientBrands); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Sec-CH-UA %s: got brands %q, want %q", tt.header, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,16 @@
+package httplog
+
+import (
+	"time"
+)
+
+// Clock provides the current time to the request logger, see Options.Clock.
+type Clock interface {
+	Now() time.Time
+	Since(t time.Time) time.Duration
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time                  { return time.Now() }
+func (systemClock) Since(t time.Time) time.Duration { return time.Since(t) }
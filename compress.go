@@ -0,0 +1,54 @@
+package httplog
+
+import (
+	"context"
+	"math"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// SetUncompressedBytes sets the size of the response body before compression,
+// so that the request log can include the compression ratio of the response.
+//
+// Use it from custom compression middlewares, or mount CompressionMeter instead.
+func SetUncompressedBytes(ctx context.Context, n int) {
+	if rl := getRequestLog(ctx); rl != nil {
+		rl.mu.Lock()
+		rl.uncompressedBytes = n
+		rl.mu.Unlock()
+	}
+}
+
+// CompressionMeter is a middleware that measures the size of the response body
+// before compression. Mount it right after the compression middleware, e.g.:
+//
+//	r.Use(httplog.RequestLogger(logger, opts))
+//	r.Use(middleware.Compress(5))
+//	r.Use(httplog.CompressionMeter)
+func CompressionMeter(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		defer func() {
+			SetUncompressedBytes(r.Context(), ww.BytesWritten())
+		}()
+
+		next.ServeHTTP(ww, r)
+	})
+}
+
+// compressionRatio returns the ratio of the uncompressed to compressed response
+// body size, or zero if the response isn't compressed or the size is unknown.
+func compressionRatio(ctx context.Context, header http.Header, compressedBytes int) float64 {
+	rl := getRequestLog(ctx)
+	if rl == nil || compressedBytes == 0 || header.Get("Content-Encoding") == "" {
+		return 0
+	}
+	rl.mu.Lock()
+	uncompressedBytes := rl.uncompressedBytes
+	rl.mu.Unlock()
+	if uncompressedBytes == 0 {
+		return 0
+	}
+	return math.Round(float64(uncompressedBytes)/float64(compressedBytes)*100) / 100
+}
@@ -0,0 +1,47 @@
+package httplog
+
+import (
+	"net/http"
+)
+
+// conditionalKVs returns the attributes of conditional requests, so that cache
+// validation efficiency can be measured from the logs.
+func conditionalKVs(r *http.Request, header http.Header, statusCode int, s *Schema) []any {
+	ifNoneMatch := r.Header.Get("If-None-Match") != ""
+	ifModifiedSince := r.Header.Get("If-Modified-Since") != ""
+	if !ifNoneMatch && !ifModifiedSince {
+		return nil
+	}
+
+	kvs := []any{
+		s.RequestConditional, map[string]any{
+			"if_none_match":     ifNoneMatch,
+			"if_modified_since": ifModifiedSince,
+		},
+		s.ResponseNotModified, statusCode == http.StatusNotModified,
+	}
+	if statusCode == http.StatusNotModified {
+		if etag := header.Get("ETag"); etag != "" {
+			kvs = append(kvs, s.ResponseETag, etag)
+		}
+	}
+	return kvs
+}
+
+// rangeKVs returns the attributes of range requests, so that partial content
+// issues can be debugged from the logs.
+func rangeKVs(r *http.Request, header http.Header, statusCode int, s *Schema) []any {
+	rng := r.Header.Get("Range")
+	if rng == "" {
+		return nil
+	}
+
+	kvs := []any{
+		s.RequestRange, rng,
+		s.ResponseRangeSatisfiable, statusCode != http.StatusRequestedRangeNotSatisfiable,
+	}
+	if contentRange := header.Get("Content-Range"); contentRange != "" {
+		kvs = append(kvs, s.ResponseContentRange, contentRange)
+	}
+	return kvs
+}
@@ -2,38 +2,252 @@ package httplog
 
 import (
 	"context"
+	"net/http"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 const (
 	ErrorKey = "error"
 )
 
-type ctxKeyLogKVs struct{}
+type ctxKeyRequestLog struct{}
 
-func (c *ctxKeyLogKVs) String() string {
-	return "httplog kv context"
+func (c *ctxKeyRequestLog) String() string {
+	return "httplog request log context"
+}
+
+// requestLog holds the request log state set from within the handlers.
+// The fields are guarded by mu, since handlers may fan out goroutines.
+type requestLog struct {
+	mu sync.Mutex
+
+	kvs               []any
+	groups            []kvGroup
+	kvCount           int  // key-value pairs set by SetKVs and SetGroupKVs
+	kvBytes           int  // estimated size of the key-value pairs
+	kvsTruncated      bool // whether key-value pairs were dropped, see limitKVs
+	tenant            string
+	tenantResolved    bool // whether Options.TenantFunc was called, see resolveTenant
+	priority          string
+	handler           string
+	uncompressedBytes int
+	cacheStatus       string
+	upstream          upstream
+	timings           []timing
+	middlewareTimings []timing
+	counters          []counter
+	experiments       []experiment
+	parent            parentRequest
+	auth              *authResult
+	reject            *reject
+	sampling          *samplingDecision
+	body              bodyOverride
+	diffHeaders       bool
+	hookErrors        []string
+	err               error
+	recovered         *PanicError
+	abort             *abortWatch
+
+	// maxKVs and maxKVBytes limit the key-value pairs set by the handlers, see
+	// Options.MaxContextKVs and Options.MaxContextKVBytes.
+	maxKVs     int
+	maxKVBytes int
+
+	// partial writes an intermediate log entry, see EmitPartial.
+	partial func(ctx context.Context, msg string)
+
+	// start and clock measure the timings, see Mark and Span.
+	start time.Time
+	clock Clock
+
+	// closed is set once the request log was written. Any later changes, e.g.
+	// from goroutines using a detached context, are dropped.
+	closed atomic.Bool
+}
+
+func getRequestLog(ctx context.Context) *requestLog {
+	rl, _ := ctx.Value(ctxKeyRequestLog{}).(*requestLog)
+	if rl == nil || rl.closed.Load() {
+		return nil
+	}
+	return rl
+}
+
+// Detach returns a copy of the request context that is never canceled, so it
+// can be passed to goroutines that outlive the request. The copy preserves the
+// logger and the request log.
+//
+// Keys and values set on a detached context are logged only if they are set
+// before the request log is written, i.e. before RequestLogger's handler returns;
+// later calls to SetKVs and friends are no-ops.
+func Detach(ctx context.Context) context.Context {
+	return context.WithoutCancel(ctx)
 }
 
 // SetKVs sets the keys and values on the request log.
+//
+// The keys and values are flushed once, when the request log is written after
+// the underlying HTTP handler returns. Calls made after that are no-ops.
 func SetKVs(ctx context.Context, KeysAndValues ...any) {
-	if ptr, ok := ctx.Value(ctxKeyLogKVs{}).(*[]any); ok && ptr != nil {
-		*ptr = append(*ptr, KeysAndValues...)
+	if rl := getRequestLog(ctx); rl != nil {
+		rl.mu.Lock()
+		rl.kvs = append(rl.kvs, rl.limitKVs(KeysAndValues)...)
+		rl.mu.Unlock()
+	}
+}
+
+// limitKVs returns the keys and values within Options.MaxContextKVs and
+// Options.MaxContextKVBytes, and marks the request log as truncated if any were
+// dropped. rl.mu must be held.
+func (rl *requestLog) limitKVs(kvs []any) []any {
+	if rl.maxKVs <= 0 && rl.maxKVBytes <= 0 {
+		return kvs
+	}
+	for i := 0; i+1 < len(kvs); i += 2 {
+		size := kvSize(kvs[i]) + kvSize(kvs[i+1])
+		if (rl.maxKVs > 0 && rl.kvCount >= rl.maxKVs) || (rl.maxKVBytes > 0 && rl.kvBytes+size > rl.maxKVBytes) {
+			rl.kvsTruncated = true
+			return kvs[:i]
+		}
+		rl.kvCount++
+		rl.kvBytes += size
 	}
+	return kvs
+}
+
+// kvSize estimates the size of the key or value in the request log.
+func kvSize(v any) int {
+	switch v := v.(type) {
+	case string:
+		return len(v)
+	case []byte:
+		return len(v)
+	case error:
+		return len(v.Error())
+	}
+	return 8
+}
+
+// getKVsTruncated reports whether keys and values were dropped by limitKVs.
+func (rl *requestLog) getKVsTruncated() bool {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	return rl.kvsTruncated
 }
 
 func getKVs(ctx context.Context) []any {
-	if ptr, ok := ctx.Value(ctxKeyLogKVs{}).(*[]any); ok && ptr != nil {
-		return *ptr
+	if rl := getRequestLog(ctx); rl != nil {
+		rl.mu.Lock()
+		defer rl.mu.Unlock()
+		return slices.Clip(rl.kvs)
 	}
 
 	return nil
 }
 
-// SetError sets the error key and value on the request log.
+// kvGroup holds the keys and values set by SetGroupKVs.
+type kvGroup struct {
+	name string
+	kvs  []any
+}
+
+// SetGroupKVs sets the keys and values on the request log, nested under the
+// given group, e.g. "app.billing" for ECS or "app:billing" for GCP. Groups are
+// logged as objects, so that handler attributes don't pollute the top level.
+//
+// Nested groups are supported by formats with Schema.GroupDelimiter, e.g. GCP.
+func SetGroupKVs(ctx context.Context, group string, KeysAndValues ...any) {
+	if rl := getRequestLog(ctx); rl != nil {
+		rl.mu.Lock()
+		defer rl.mu.Unlock()
+		KeysAndValues = rl.limitKVs(KeysAndValues)
+		if len(KeysAndValues) == 0 {
+			return
+		}
+		for i := range rl.groups {
+			if rl.groups[i].name == group {
+				rl.groups[i].kvs = append(rl.groups[i].kvs, KeysAndValues...)
+				return
+			}
+		}
+		// Copy the caller's slice, which is appended to by later calls.
+		rl.groups = append(rl.groups, kvGroup{name: group, kvs: slices.Clone(KeysAndValues)})
+	}
+}
+
+func getGroupKVs(ctx context.Context) []any {
+	rl := getRequestLog(ctx)
+	if rl == nil {
+		return nil
+	}
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	kvs := make([]any, 0, len(rl.groups)*2)
+	for _, g := range rl.groups {
+		kvs = append(kvs, g.name, nestKVs(g.kvs))
+	}
+	return kvs
+}
+
+// SetError sets the error key and value on the request log. The last error set
+// is classified by Options.ClassifyError.
 func SetError(ctx context.Context, err error) error {
 	if err != nil {
 		SetKVs(ctx, ErrorKey, err)
+		if rl := getRequestLog(ctx); rl != nil {
+			rl.mu.Lock()
+			rl.err = err
+			rl.mu.Unlock()
+		}
 	}
 
 	return err
 }
+
+func (rl *requestLog) getError() error {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	return rl.err
+}
+
+// SetTenant sets the tenant ID on the request log. It takes precedence over
+// the tenant ID returned by Options.TenantFunc.
+func SetTenant(ctx context.Context, tenant string) {
+	if rl := getRequestLog(ctx); rl != nil {
+		rl.mu.Lock()
+		rl.tenant = tenant
+		rl.mu.Unlock()
+	}
+}
+
+// Tenant returns the tenant ID of the request log, as set by SetTenant or
+// resolved by Options.TenantFunc.
+//
+// It can be used in Options.Skip to filter requests of a noisy tenant.
+func Tenant(ctx context.Context) string {
+	if rl := getRequestLog(ctx); rl != nil {
+		rl.mu.Lock()
+		defer rl.mu.Unlock()
+		return rl.tenant
+	}
+	return ""
+}
+
+// resolveTenant returns the tenant ID of the request log, calling
+// Options.TenantFunc once, unless the tenant ID was set by SetTenant.
+func (rl *requestLog) resolveTenant(ctx context.Context, r *http.Request, o *Options) string {
+	rl.mu.Lock()
+	tenant, resolved := rl.tenant, rl.tenantResolved
+	rl.tenantResolved = true
+	rl.mu.Unlock()
+	if tenant != "" || resolved || o.TenantFunc == nil {
+		return tenant
+	}
+	rl.callHook("TenantFunc", func() { tenant = o.TenantFunc(r.WithContext(ctx)) })
+	SetTenant(ctx, tenant)
+	return tenant
+}
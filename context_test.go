@@ -0,0 +1,61 @@
+package httplog_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+
+	httplog "github.com/rickliujh/chi-httplogr/v3"
+	"github.com/rickliujh/chi-httplogr/v3/httplogtest"
+)
+
+// TestSetKVsConcurrent sets keys and values from goroutines fanned out by the
+// handler; run it with -race.
+func TestSetKVsConcurrent(t *testing.T) {
+	rec := httplogtest.NewRecorder()
+	const workers = 20
+
+	handler := httplog.RequestLogger(rec.Logger(), &httplog.Options{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var wg sync.WaitGroup
+		for i := 0; i < workers; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				httplog.SetKVs(r.Context(), fmt.Sprintf("worker%d", i), i)
+			}(i)
+		}
+		wg.Wait()
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+
+	_, entry := rec.RoundTrip(handler, httptest.NewRequest(http.MethodGet, "/", nil))
+	for i := 0; i < workers; i++ {
+		if !entry.HasKV(fmt.Sprintf("worker%d", i), i) {
+			t.Errorf("entry is missing worker%d: %v", i, entry.KVs)
+		}
+	}
+}
+
+func TestSetGroupKVsCopies(t *testing.T) {
+	rec := httplogtest.NewRecorder()
+	handler := httplog.RequestLogger(rec.Logger(), &httplog.Options{
+		Schema: httplog.SchemaECS,
+		Levels: &httplog.Levels{Warn: 1, Info: 1, Debug: 1},
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		kvs := make([]any, 2, 4)
+		kvs[0], kvs[1] = "a", 1
+		httplog.SetGroupKVs(r.Context(), "app", kvs...)
+		httplog.SetGroupKVs(r.Context(), "app", "b", 2)
+		kvs[1] = "mutated"
+		_ = append(kvs, "c", 3)
+	}))
+
+	_, entry := rec.RoundTrip(handler, httptest.NewRequest(http.MethodGet, "/", nil))
+	app, _ := entry.Value("app")
+	if want := map[string]any{"a": 1, "b": 2}; !reflect.DeepEqual(app, want) {
+		t.Errorf("got app %v, want %v", app, want)
+	}
+}
@@ -0,0 +1,137 @@
+package httplog
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// controllerWriter records the changes made by the handler through
+// http.ResponseController, i.e. the read and write deadlines and full duplex,
+// and passes them on to the underlying response writer. It's wrapped by
+// wrapController to preserve the optional interfaces of the underlying writer.
+type controllerWriter struct {
+	http.ResponseWriter
+
+	mu            sync.Mutex
+	readDeadline  *time.Time
+	writeDeadline *time.Time
+	fullDuplex    bool
+}
+
+func (w *controllerWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *controllerWriter) SetReadDeadline(deadline time.Time) error {
+	if err := http.NewResponseController(w.ResponseWriter).SetReadDeadline(deadline); err != nil {
+		return err
+	}
+	w.mu.Lock()
+	w.readDeadline = &deadline
+	w.mu.Unlock()
+	return nil
+}
+
+func (w *controllerWriter) SetWriteDeadline(deadline time.Time) error {
+	if err := http.NewResponseController(w.ResponseWriter).SetWriteDeadline(deadline); err != nil {
+		return err
+	}
+	w.mu.Lock()
+	w.writeDeadline = &deadline
+	w.mu.Unlock()
+	return nil
+}
+
+func (w *controllerWriter) EnableFullDuplex() error {
+	if err := http.NewResponseController(w.ResponseWriter).EnableFullDuplex(); err != nil {
+		return err
+	}
+	w.mu.Lock()
+	w.fullDuplex = true
+	w.mu.Unlock()
+	return nil
+}
+
+// wrapController returns w wrapped by the controller writer cw, preserving the
+// optional interfaces of w like wrapSnapshot, i.e. http.Flusher, http.Hijacker
+// and io.ReaderFrom of HTTP/1.x writers, and http.Flusher and http.Pusher of
+// HTTP/2 writers, so that the handler's feature detection isn't fooled.
+func wrapController(w http.ResponseWriter, cw *controllerWriter) http.ResponseWriter {
+	cw.ResponseWriter = w
+	_, fl := w.(http.Flusher)
+	_, hj := w.(http.Hijacker)
+	_, rf := w.(io.ReaderFrom)
+	_, ps := w.(http.Pusher)
+	switch {
+	case fl && hj && rf:
+		return &controllerHTTP1Writer{controllerFlushWriter{cw}}
+	case fl && ps:
+		return &controllerHTTP2Writer{controllerFlushWriter{cw}}
+	case fl:
+		return &controllerFlushWriter{cw}
+	default:
+		return cw
+	}
+}
+
+type controllerFlushWriter struct {
+	*controllerWriter
+}
+
+func (w *controllerFlushWriter) Flush() {
+	// The flush must go through the wrapped writer, which records the status.
+	w.ResponseWriter.(http.Flusher).Flush()
+}
+
+type controllerHTTP1Writer struct {
+	controllerFlushWriter
+}
+
+func (w *controllerHTTP1Writer) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return w.ResponseWriter.(http.Hijacker).Hijack()
+}
+
+func (w *controllerHTTP1Writer) ReadFrom(r io.Reader) (int64, error) {
+	return w.ResponseWriter.(io.ReaderFrom).ReadFrom(r)
+}
+
+type controllerHTTP2Writer struct {
+	controllerFlushWriter
+}
+
+func (w *controllerHTTP2Writer) Push(target string, opts *http.PushOptions) error {
+	return w.ResponseWriter.(http.Pusher).Push(target, opts)
+}
+
+// kvs returns the deadlines relative to the request start in milliseconds,
+// or 0 if cleared, and whether full duplex was enabled.
+func (w *controllerWriter) kvs(s *Schema, start time.Time) []any {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var kvs []any
+	for _, d := range []struct {
+		key      string
+		deadline *time.Time
+	}{
+		{s.RequestReadDeadline, w.readDeadline},
+		{s.ResponseWriteDeadline, w.writeDeadline},
+	} {
+		if d.deadline == nil {
+			continue
+		}
+		var ms float64
+		if !d.deadline.IsZero() {
+			ms = float64(d.deadline.Sub(start).Milliseconds())
+		}
+		kvs = append(kvs, d.key, ms)
+	}
+	if w.fullDuplex {
+		kvs = append(kvs, s.RequestFullDuplex, true)
+	}
+	return kvs
+}
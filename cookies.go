@@ -0,0 +1,32 @@
+package httplog
+
+import (
+	"net/http"
+)
+
+var defaultRedactCookies = []string{"session", "sessionid", "session_id", "sid", "token", "access_token", "refresh_token", "auth", "jwt"}
+
+// cookiesKVs returns the request cookies selected by Options.LogCookies as a
+// nested object, or nil. The values of the Options.RedactCookies are redacted.
+func cookiesKVs(r *http.Request, o *Options) map[string]any {
+	redact := o.RedactCookies
+	if redact == nil {
+		redact = defaultRedactCookies
+	}
+	var cookies map[string]any
+	for _, name := range o.LogCookies {
+		c, err := r.Cookie(name)
+		if err != nil {
+			continue
+		}
+		if cookies == nil {
+			cookies = map[string]any{}
+		}
+		if redactedName(redact, name) {
+			cookies[name] = redactValue(c.Value, o)
+			continue
+		}
+		cookies[name] = c.Value
+	}
+	return cookies
+}
@@ -0,0 +1,28 @@
+package httplog
+
+import (
+	"net/http"
+)
+
+// CORS request classes logged as Schema.CORSType.
+const (
+	CORSPreflight = "preflight"
+	CORSActual    = "actual"
+)
+
+// CORSType classifies the request as a CORS preflight request (CORSPreflight),
+// an actual cross-origin request (CORSActual), or a non-CORS request ("").
+func CORSType(r *http.Request) string {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return ""
+	}
+	if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+		return CORSPreflight
+	}
+	if origin == scheme(r)+"://"+r.Host {
+		// Same-origin request, e.g. a POST from a form.
+		return ""
+	}
+	return CORSActual
+}
@@ -0,0 +1,54 @@
+package httplog
+
+import (
+	"context"
+)
+
+// counter holds a named value aggregated by Count or Add.
+type counter struct {
+	name  string
+	value float64
+}
+
+// Count increments the named counter of the request by n, e.g.
+// httplog.Count(ctx, "db.queries", 1). The counters are logged as a nested
+// Schema.Counters object, so that e.g. the number of database queries run by
+// the request can be answered from the logs.
+func Count(ctx context.Context, name string, n int) {
+	Add(ctx, name, float64(n))
+}
+
+// Add adds v to the named counter of the request, e.g.
+// httplog.Add(ctx, "cache.hits", n). See Count.
+func Add(ctx context.Context, name string, v float64) {
+	if rl := getRequestLog(ctx); rl != nil {
+		rl.mu.Lock()
+		defer rl.mu.Unlock()
+		for i := range rl.counters {
+			if rl.counters[i].name == name {
+				rl.counters[i].value += v
+				return
+			}
+		}
+		rl.counters = append(rl.counters, counter{name: name, value: v})
+	}
+}
+
+// countersKVs returns the aggregated counters as a nested object, or nil.
+func countersKVs(ctx context.Context) map[string]any {
+	rl := getRequestLog(ctx)
+	if rl == nil {
+		return nil
+	}
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	if len(rl.counters) == 0 {
+		return nil
+	}
+	m := make(map[string]any, len(rl.counters))
+	for _, c := range rl.counters {
+		m[c.name] = c.value
+	}
+	return m
+}
@@ -0,0 +1,190 @@
+package httplog
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"time"
+)
+
+// Names of the request counters recorded by RecordQuery, see Count.
+const (
+	DBQueriesCounter  = "db.queries"
+	DBDurationCounter = "db.duration_ms"
+)
+
+// RecordQuery records a database (or other external) call on the request log,
+// i.e. increments the DBQueriesCounter and adds the duration to the
+// DBDurationCounter. Use it from query hooks of database libraries, or wrap
+// the database driver with InstrumentConnector.
+func RecordQuery(ctx context.Context, duration time.Duration) {
+	Count(ctx, DBQueriesCounter, 1)
+	Add(ctx, DBDurationCounter, float64(duration.Microseconds())/1000)
+}
+
+// InstrumentConnector wraps the database driver connector to record the count
+// and the cumulative duration of queries run with the request context, e.g.:
+//
+//	db := sql.OpenDB(httplog.InstrumentConnector(connector))
+//	rows, err := db.QueryContext(r.Context(), "SELECT ...")
+//
+// Queries run without the request context are not recorded.
+func InstrumentConnector(c driver.Connector) driver.Connector {
+	return &dbConnector{Connector: c}
+}
+
+type dbConnector struct {
+	driver.Connector
+}
+
+func (c *dbConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &dbConn{Conn: conn}, nil
+}
+
+func (c *dbConnector) Close() error {
+	if closer, ok := c.Connector.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+type dbConn struct {
+	driver.Conn
+}
+
+func (c *dbConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	var stmt driver.Stmt
+	var err error
+	if p, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		stmt, err = p.PrepareContext(ctx, query)
+	} else {
+		stmt, err = c.Conn.Prepare(query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &dbStmt{Stmt: stmt}, nil
+}
+
+func (c *dbConn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *dbConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if b, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return b.BeginTx(ctx, opts)
+	}
+	if opts.Isolation != 0 || opts.ReadOnly {
+		return nil, errors.New("httplog: driver does not support non-default transaction options")
+	}
+	return c.Conn.Begin()
+}
+
+func (c *dbConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	e, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	start := time.Now()
+	res, err := e.ExecContext(ctx, query, args)
+	if !errors.Is(err, driver.ErrSkip) {
+		RecordQuery(ctx, time.Since(start))
+	}
+	return res, err
+}
+
+func (c *dbConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	q, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	start := time.Now()
+	rows, err := q.QueryContext(ctx, query, args)
+	if !errors.Is(err, driver.ErrSkip) {
+		RecordQuery(ctx, time.Since(start))
+	}
+	return rows, err
+}
+
+func (c *dbConn) Ping(ctx context.Context) error {
+	if p, ok := c.Conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *dbConn) ResetSession(ctx context.Context) error {
+	if r, ok := c.Conn.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *dbConn) IsValid() bool {
+	if v, ok := c.Conn.(driver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
+
+func (c *dbConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if n, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return n.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+type dbStmt struct {
+	driver.Stmt
+}
+
+func (s *dbStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	start := time.Now()
+	defer func() { RecordQuery(ctx, time.Since(start)) }()
+
+	if e, ok := s.Stmt.(driver.StmtExecContext); ok {
+		return e.ExecContext(ctx, args)
+	}
+	values, err := namedValues(args)
+	if err != nil {
+		return nil, err
+	}
+	return s.Stmt.Exec(values)
+}
+
+func (s *dbStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	start := time.Now()
+	defer func() { RecordQuery(ctx, time.Since(start)) }()
+
+	if q, ok := s.Stmt.(driver.StmtQueryContext); ok {
+		return q.QueryContext(ctx, args)
+	}
+	values, err := namedValues(args)
+	if err != nil {
+		return nil, err
+	}
+	return s.Stmt.Query(values)
+}
+
+func (s *dbStmt) CheckNamedValue(nv *driver.NamedValue) error {
+	if n, ok := s.Stmt.(driver.NamedValueChecker); ok {
+		return n.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+func namedValues(args []driver.NamedValue) ([]driver.Value, error) {
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		if arg.Name != "" {
+			return nil, errors.New("httplog: driver does not support named parameters")
+		}
+		values[i] = arg.Value
+	}
+	return values, nil
+}
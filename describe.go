@@ -0,0 +1,230 @@
+package httplog
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// FieldDescription describes a field of the schema, see Schema.Describe.
+type FieldDescription struct {
+	Field       string `json:"field"`           // Semantic field, e.g. "RequestMethod", or the custom field of Schema.Extra
+	Name        string `json:"name"`            // Field name in the schema, e.g. "http.request.method"
+	Group       string `json:"group,omitempty"` // Nested object of the field, if grouped, see Schema.FieldDelimiter
+	Type        string `json:"type"`            // Inferred type, i.e. keyword, text, long, float, boolean, date or object
+	Description string `json:"description"`
+}
+
+// fieldDescriptions are the descriptions of the semantic fields of the schema.
+// Keep them in sync with the comments of the Schema fields.
+var fieldDescriptions = map[string]string{
+	"Timestamp":                   "Timestamp of the log entry",
+	"Level":                       "Log level (e.g. INFO, WARNING, ERROR)",
+	"Message":                     "Primary log message",
+	"ErrorMessage":                "Error message when an error occurs",
+	"ErrorType":                   "Low-cardinality error type (e.g. \"ClientAborted\", \"ValidationError\")",
+	"EventOutcome":                "Outcome of the request: success, failure or unknown",
+	"AbortHeadersSent":            "Whether the response headers were sent before the client aborted",
+	"AbortElapsed":                "Time from the request start to the client abort in milliseconds",
+	"AbortNoticed":                "Time from the request start to the client abort noticed by ClientGone in milliseconds",
+	"AbortProgress":               "Percentage of the response Content-Length written before the client abort",
+	"HookErrors":                  "Failures of the user-provided hooks, see HookError",
+	"ErrorTitle":                  "Short human-readable summary of the error, e.g. from RFC 7807 problem details",
+	"ErrorDetail":                 "Human-readable explanation of the error, e.g. from RFC 7807 problem details",
+	"ErrorStackTrace":             "Stack trace for panic or error",
+	"TraceID":                     "Trace ID of the distributed trace, see Options.TracePropagators",
+	"TransactionID":               "Transaction ID of the tracing agent (e.g. Elastic APM), see Options.TraceContext",
+	"SpanID":                      "Span ID of the caller within the distributed trace",
+	"Baggage":                     "Selected W3C Baggage entries propagated by the caller, see Options.LogBaggage",
+	"SourceFile":                  "Source file name where the log originated",
+	"SourceLine":                  "Line number in the source file",
+	"SourceFunction":              "Function name where the log originated",
+	"RequestURL":                  "Full request URL",
+	"RequestID":                   "Request ID set by chi's middleware.RequestID or X-Request-Id header",
+	"RequestParentID":             "Request ID of the parent request, see Options.ParentRequestIDHeader",
+	"RequestMethod":               "HTTP method (e.g. GET, POST)",
+	"RequestPath":                 "URL path component",
+	"RequestRoute":                "Matched route pattern (e.g. /users/{id}), see Options.RoutePattern",
+	"RequestRouteMount":           "Mount prefix of the chi sub-router (e.g. /api/v1), see Options.LogRouteMount",
+	"RequestRouteInner":           "Route pattern within the chi sub-router (e.g. /users/{id}), see Options.LogRouteMount",
+	"HandlerName":                 "Name of the Go handler serving the request, see Named",
+	"RequestFingerprint":          "Stable fingerprint of the request client, see Options.LogFingerprint",
+	"SLOViolated":                 "Whether the request violated the SLO of the route, see Options.SLOs",
+	"SLOReason":                   "Reason of the SLO violation, i.e. status or latency",
+	"RequestRemoteIP":             "Client IP address (without the port)",
+	"RequestRemotePort":           "Client port, if the remote address has one",
+	"RequestHost":                 "Host header value",
+	"RequestPort":                 "Port of the server (from the Host header or the scheme), see Options.LogURLParts",
+	"RequestQuery":                "Raw query string, see Options.LogURLParts",
+	"RequestQueryParams":          "Selected query parameters, see Options.LogQueryParams",
+	"RequestFragment":             "URL fragment, see Options.LogURLParts",
+	"RequestScheme":               "URL scheme (http, https)",
+	"RequestProto":                "HTTP protocol version (e.g. HTTP/1.1, HTTP/2)",
+	"RequestHeaders":              "Selected request headers",
+	"RequestTrailers":             "Request trailers, see Options.LogRequestTrailers",
+	"RequestCookies":              "Selected request cookies, see Options.LogCookies",
+	"RequestBody":                 "Request body content, if logged",
+	"RequestBodyRef":              "Path of the file with the full request body, see Options.SpillBodyThreshold",
+	"RequestBodyWindows":          "Byte ranges of the request body, see Options.LogBodyWindows",
+	"RequestOversized":            "Whether the request Content-Length exceeds Options.OversizedRequestBytes",
+	"RequestHeaderBytes":          "Estimated size of the request headers on the wire, see Options.LogHeaderSize",
+	"RequestHeaderCount":          "Number of request header lines, see Options.LogHeaderSize",
+	"RequestHeadersLarge":         "Whether the request headers exceed Options.LargeHeaderBytes",
+	"BodyCaptureSkipped":          "Whether the body capture was skipped, see Options.MaxGlobalConcurrentCaptures",
+	"RequestBytes":                "Size of request body in bytes",
+	"RequestBytesPerSec":          "Request body throughput in bytes per second, see Options.LogThroughput",
+	"RequestMessages":             "Number of gRPC messages or WebSocket frames received",
+	"RequestBytesUnread":          "Unread bytes in request body",
+	"RequestBodyValid":            "Whether the JSON request body is valid, see Options.ValidateRequestBody",
+	"RequestBodyError":            "Validation error of the JSON request body",
+	"RequestUserAgent":            "User-Agent header value",
+	"RequestUserAgentDetails":     "Parsed User-Agent details, see Options.UserAgentParser",
+	"ClientIsBot":                 "Whether the client is a bot, as flagged by Options.UserAgentParser",
+	"ClientLocale":                "Most preferred locale of the Accept-Language header",
+	"RequestCharset":              "Charset parameter of the Content-Type header, see Options.LogRequestEncoding",
+	"RequestContentLanguage":      "Content-Language header value",
+	"ClientBrands":                "Browser brands and major versions from Sec-CH-UA, see Options.LogClientHints",
+	"ClientPlatform":              "Platform (OS) from Sec-CH-UA-Platform",
+	"ClientMobile":                "Mobile device flag from Sec-CH-UA-Mobile",
+	"TrafficClass":                "Traffic class (e.g. user, probe, monitor, bot), see Options.TrafficClassFunc",
+	"RequestReferer":              "Referer header value",
+	"RequestSequence":             "Per-middleware sequence number of the logged request",
+	"RepeatCount":                 "Number of identical error logs collapsed after the first one, see Options.ErrorDedupWindow",
+	"ErrorBurst":                  "Whether the entry is a summary of an HTTP 5xx burst, see Options.ErrorBurstThreshold",
+	"ErrorBurstCount":             "Number of HTTP 5xx responses of the route within the burst window",
+	"ErrorBurstWindow":            "Burst window in milliseconds",
+	"SamplingRate":                "Sampling rate of the request log, see Options.Sampler",
+	"SamplingSampled":             "Whether the request log was sampled (failed requests are always logged)",
+	"CDNRayID":                    "CDN request ID (e.g. CF-Ray), see Options.LogCDNHeaders",
+	"CDNEdge":                     "CDN edge location or cache node that served the request",
+	"Labels":                      "Low-cardinality labels of the request, see Options.LogLabels",
+	"RequestIdempotencyKey":       "Idempotency key of the request, see Options.IdempotencyHeaders",
+	"RequestPriority":             "Priority class of the request (high, normal or low), see Options.LogPriority",
+	"RequestReplayRef":            "Reference of the request stored by Options.ReplayStore",
+	"RequestDuplicate":            "Whether the idempotency key was seen recently, see Options.DuplicateCacheSize",
+	"RequestConditional":          "Presence of conditional request headers (If-None-Match, If-Modified-Since)",
+	"RequestRange":                "Range header value of range requests",
+	"RequestDeadline":             "Timeout of the request context, i.e. its deadline relative to the start of the request",
+	"RequestDeadlineRemaining":    "Time remaining until the deadline of the request context at completion",
+	"RequestQueueTime":            "Time the request spent queued before reaching the app, see Options.LogQueueTime",
+	"RequestContinueSent":         "Whether the interim 100 Continue response was sent, see Options.LogExpectContinue",
+	"RequestContinueWait":         "Time from the interim 100 Continue response to the first byte of the request body in milliseconds",
+	"RequestReadDeadline":         "Read deadline set by the handler relative to the request start in milliseconds, see Options.LogResponseController",
+	"RequestFullDuplex":           "Whether the handler enabled full duplex",
+	"GraphQLOperationName":        "GraphQL operation name, see Options.GraphQLPaths",
+	"GraphQLOperationType":        "GraphQL operation type (query, mutation, subscription)",
+	"GraphQLDocumentHash":         "Truncated SHA-256 hash of the GraphQL query document",
+	"RPCMethod":                   "RPC method name, see Options.InspectRequestBody",
+	"RPCRequestID":                "RPC request ID, e.g. JSON-RPC id",
+	"RequestCORSType":             "CORS request class (preflight, actual)",
+	"RequestOrigin":               "Origin header value of CORS requests",
+	"UserID":                      "Unique identifier of the user, see Options.IdentityFunc",
+	"UserName":                    "Short name or login of the user",
+	"UserClaims":                  "Selected claims of the JWT bearer token, see Options.LogJWTClaims",
+	"UserIssuer":                  "Issuer of the JWT bearer token (\"iss\" claim), see Options.LogJWTClaims",
+	"UserAudience":                "Audience of the JWT bearer token (\"aud\" claim), see Options.LogJWTClaims",
+	"ClientScope":                 "Scopes granted to the client (\"scope\" claim), see Options.LogJWTClaims",
+	"APIKeyHash":                  "Salted hash fingerprint of the API key, see Options.LogAPIKeyHeaders",
+	"TenantID":                    "Tenant (organization) the request belongs to, see Options.TenantFunc",
+	"AuthScheme":                  "Authentication scheme (e.g. bearer, basic), see SetAuthResult",
+	"AuthOutcome":                 "Authentication outcome (success, failure, anonymous)",
+	"AuthReason":                  "Reason of the authentication failure",
+	"RejectReason":                "Reason of the rejection by a throttling or load-shedding middleware, see SetRejected",
+	"RejectQueueWait":             "Time the rejected request waited in the queue in milliseconds",
+	"ResponseHeaders":             "Selected response headers",
+	"ResponseBody":                "Response body content, if logged",
+	"ResponseBodyRef":             "Path of the file with the full response body, see Options.SpillBodyThreshold",
+	"ResponseBodyWindows":         "Byte ranges of the response body",
+	"ResponseStatus":              "HTTP status code",
+	"ResponseStatusClass":         "HTTP status code class, e.g. 2xx",
+	"ResponseDuration":            "Request processing duration",
+	"ResponseStalled":             "Time to the first byte of a stalled response in milliseconds, see Options.StallThreshold",
+	"Timings":                     "Named durations recorded by Mark and Span",
+	"MiddlewareTimings":           "Durations recorded by MiddlewareTiming, and the remaining time as handler",
+	"Counters":                    "Named counters aggregated by Count and Add",
+	"Experiments":                 "Experiment and feature flag variants set by SetExperiment",
+	"ResponseBytes":               "Size of response body in bytes",
+	"ResponseHeadContentLength":   "Content-Length of HEAD responses, as of the GET response",
+	"ResponseBytesPerSec":         "Response body throughput in bytes per second, see Options.LogThroughput",
+	"Partial":                     "Intermediate log entry of a long-lived request, see EmitPartial",
+	"ContextKVsTruncated":         "Whether key-value pairs set by SetKVs were dropped, see Options.MaxContextKVs",
+	"ResponseMessages":            "Number of gRPC messages or WebSocket frames sent",
+	"WebSocketCloseCode":          "Status code of the WebSocket close frame, or 1006 if none was sent",
+	"WebSocketCloseReason":        "Reason of the WebSocket close frame",
+	"WebSocketCloseInitiator":     "Side sending the first WebSocket close frame (client or server)",
+	"ResponseHeaderBytes":         "Estimated size of the response status line and headers, see Options.LogWireBytes",
+	"ResponseWireBytes":           "Estimated total size of the response on the wire (headers and body)",
+	"ResponseWriteDeadline":       "Write deadline set by the handler relative to the request start in milliseconds",
+	"ResponseWriterCapabilities":  "Flush, Hijack and Push support of the response writer",
+	"ResponseFilename":            "File name of file downloads (Content-Disposition: attachment)",
+	"ResponseAllowedMethods":      "Methods allowed by the Allow header of HTTP 405 responses",
+	"ResponseErrorMessage":        "Error message extracted from JSON error responses, see Options.ErrorMessageFields",
+	"ResponseContentTypeMismatch": "Detected Content-Type of the response body, if it doesn't match the declared one",
+	"ResponseCompressionRatio":    "Ratio of uncompressed to compressed response body size",
+	"CacheStatus":                 "Normalized cache status (e.g. hit, miss, stale, bypass)",
+	"ResponseNotModified":         "Whether a conditional request was answered with HTTP 304",
+	"ResponseETag":                "ETag header value of HTTP 304 responses",
+	"ResponseContentRange":        "Content-Range header value of range responses",
+	"ResponseRangeSatisfiable":    "Whether the requested range was satisfiable (not HTTP 416)",
+	"ResponseRedirectLocation":    "Location header value of HTTP 3xx responses",
+	"ResponseRateLimit":           "Rate limiting response headers of HTTP 429 responses",
+	"SecurityMissingHeaders":      "Security response headers missing in the response, see Options.AuditSecurityHeaders",
+	"UpstreamAddress":             "Address of the upstream target",
+	"UpstreamStatus":              "HTTP status code of the upstream response",
+	"UpstreamDuration":            "Duration of the last upstream round trip",
+	"UpstreamRetries":             "Number of retried upstream round trips",
+	"UpstreamHeaderDiff":          "Names of the request headers added, removed and modified before proxying",
+}
+
+// Describe returns the descriptions of the schema fields in the declaration
+// order, followed by the custom fields of Schema.Extra in alphabetical order,
+// e.g. to generate the parsing config of log shippers (Vector, Fluent Bit) from
+// the schema. Fields omitted from the schema (empty names) are not returned.
+func (s *Schema) Describe() []FieldDescription {
+	var fields []FieldDescription
+	describe := func(field, name, typ, description string) {
+		fd := FieldDescription{Field: field, Name: name, Type: typ, Description: description}
+		if delimiter := s.FieldDelimiter(name); delimiter != "" {
+			if i := strings.LastIndex(name, delimiter); i >= 0 {
+				fd.Group = name[:i]
+			}
+		}
+		fields = append(fields, fd)
+	}
+
+	v := reflect.ValueOf(s).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).Type.Kind() != reflect.String || t.Field(i).Name == "GroupDelimiter" {
+			continue
+		}
+		name := v.Field(i).String()
+		if name == "" {
+			continue
+		}
+		typ, ok := fieldTypes[t.Field(i).Name]
+		if !ok {
+			typ = "keyword"
+		}
+		describe(t.Field(i).Name, name, typ, fieldDescriptions[t.Field(i).Name])
+	}
+
+	extra := make([]string, 0, len(s.Extra))
+	for field, name := range s.Extra {
+		if name != "" {
+			extra = append(extra, field)
+		}
+	}
+	sort.Strings(extra)
+	for _, field := range extra {
+		describe(field, s.Extra[field], "keyword", "Custom field, see ExtendSchema")
+	}
+	return fields
+}
+
+// DescribeJSON returns the descriptions of the schema fields, see Describe, as
+// a JSON array.
+func (s *Schema) DescribeJSON() ([]byte, error) {
+	return json.MarshalIndent(s.Describe(), "", "  ")
+}
@@ -0,0 +1,84 @@
+package httplog_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	httplog "github.com/rickliujh/chi-httplogr/v3"
+)
+
+func TestDescribe(t *testing.T) {
+	for name, schema := range map[string]*httplog.Schema{
+		"ECS":       httplog.SchemaECS,
+		"OTEL":      httplog.SchemaOTEL,
+		"GCP":       httplog.SchemaGCP,
+		"CEF":       httplog.SchemaCEF,
+		"SplunkCIM": httplog.SchemaSplunkCIM,
+		"Concise":   httplog.SchemaECS.Concise(true),
+		"Extended":  httplog.ExtendSchema(httplog.SchemaECS, map[string]string{"order_id": "order.id"}),
+	} {
+		fields := schema.Describe()
+		if len(fields) == 0 {
+			t.Fatalf("%s: no fields described", name)
+		}
+		seen := map[string]bool{}
+		for _, f := range fields {
+			if f.Name == "" || f.Description == "" || f.Type == "" {
+				t.Errorf("%s: field %+v is missing the name, description or type", name, f)
+			}
+			if seen[f.Field] {
+				t.Errorf("%s: field %s described twice", name, f.Field)
+			}
+			seen[f.Field] = true
+		}
+	}
+}
+
+func TestDescribeFields(t *testing.T) {
+	schema := httplog.ExtendSchema(httplog.SchemaECS, map[string]string{"order_id": "order.id", "cart_id": "cart.id"})
+	fields := schema.Describe()
+
+	// Schema fields come in the declaration order, then the custom fields sorted.
+	if fields[0].Field != "Timestamp" || fields[0].Name != httplog.SchemaECS.Timestamp {
+		t.Errorf("got first field %+v, want Timestamp", fields[0])
+	}
+	if got := fields[len(fields)-2:]; got[0].Field != "cart_id" || got[1].Field != "order_id" || got[1].Name != "order.id" {
+		t.Errorf("got last fields %+v, want the custom fields sorted", got)
+	}
+	for _, f := range fields {
+		if f.Field == "ResponseDuration" && f.Type != "float" && f.Type != "long" {
+			t.Errorf("got type %q of ResponseDuration, want a number", f.Type)
+		}
+	}
+
+	// Fields omitted from the schema aren't described.
+	omitted := *httplog.SchemaECS
+	omitted.RequestMethod = ""
+	for _, f := range omitted.Describe() {
+		if f.Field == "RequestMethod" {
+			t.Errorf("omitted field described: %+v", f)
+		}
+	}
+
+	// Fields grouped into nested objects are described with their group.
+	for _, f := range httplog.SchemaGCP.Describe() {
+		if f.Field == "RequestMethod" && f.Group != "httpRequest" {
+			t.Errorf("got group %q of %s, want httpRequest", f.Group, f.Name)
+		}
+	}
+}
+
+func TestDescribeJSON(t *testing.T) {
+	data, err := httplog.SchemaECS.DescribeJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields []httplog.FieldDescription
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, data)
+	}
+	if !reflect.DeepEqual(fields, httplog.SchemaECS.Describe()) {
+		t.Errorf("DescribeJSON doesn't match Describe:\n%s", data)
+	}
+}
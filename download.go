@@ -0,0 +1,102 @@
+package httplog
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// attachment returns the file name and true, if the response is a file download,
+// i.e. it has the "Content-Disposition: attachment" header.
+func attachment(header http.Header) (filename string, ok bool) {
+	disposition, params, err := mime.ParseMediaType(header.Get("Content-Disposition"))
+	if err != nil || disposition != "attachment" {
+		return "", false
+	}
+	return params["filename"], true
+}
+
+// respBodyWriter captures the response body to be logged. It decides on the first
+// write, once the response headers are set: file downloads and Content-Types that
+// are not allowed by Options.LogBodyContentTypes are not captured, so that large
+// (binary) responses are never buffered. At most Options.LogBodyMaxLen bytes are
+// captured.
+//
+// The body is captured only if enabled and the written status qualifies for
+// Options.LogResponseBodyStatus, or if body logging was enabled by LogBody before
+// the first write.
+type respBodyWriter struct {
+	header   http.Header
+	status   func() int
+	o        *Options
+	rl       *requestLog
+	enabled  bool
+	buf      bytes.Buffer
+	decided  bool
+	download bool
+	redacted bool
+
+	// spill captures the full body to a temporary file, see Options.SpillBodyThreshold.
+	spill *bodySpill
+}
+
+func (bw *respBodyWriter) capturing() bool {
+	if !bw.decided {
+		switch bw.rl.bodyOverride() {
+		case bodyLog:
+			bw.enabled = true
+		case bodySkip:
+			bw.enabled = false
+		default:
+			if bw.o.LogResponseBodyStatus != nil {
+				bw.rl.callHook("LogResponseBodyStatus", func() { bw.enabled = bw.enabled && bw.o.LogResponseBodyStatus(bw.status()) })
+			}
+		}
+		_, bw.download = attachment(bw.header)
+		bw.redacted = !bw.download && !loggableContentType(bw.header.Get("Content-Type"), bw.o)
+		bw.decided = true
+	}
+	return bw.enabled && !bw.download && !bw.redacted
+}
+
+func (bw *respBodyWriter) Write(p []byte) (int, error) {
+	if !bw.capturing() {
+		return len(p), nil
+	}
+	if bw.spill != nil {
+		bw.spill.Write(p)
+	}
+	n := len(p)
+	if max := bw.o.LogBodyMaxLen; max > 0 {
+		// Capture one extra byte, so that logBody knows the body was trimmed.
+		n = min(n, max+1-bw.buf.Len())
+	}
+	bw.buf.Write(p[:n])
+	return len(p), nil
+}
+
+// body returns the captured body to be logged.
+func (bw *respBodyWriter) body() string {
+	switch {
+	case bw.download:
+		return "[file download]"
+	case bw.redacted:
+		return redactedBody(bw.header.Get("Content-Type"))
+	}
+	return logBody(&bw.buf, bw.header, bw.o)
+}
+
+func loggableContentType(contentType string, o *Options) bool {
+	for _, whitelisted := range o.LogBodyContentTypes {
+		if strings.HasPrefix(contentType, whitelisted) {
+			return true
+		}
+	}
+	return false
+}
+
+func redactedBody(contentType string) string {
+	return fmt.Sprintf("[body redacted for Content-Type: %s]", contentType)
+}
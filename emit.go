@@ -0,0 +1,88 @@
+package httplog
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+// defaultMaxPendingEmits is the default Options.MaxPendingEmits.
+const defaultMaxPendingEmits = 1000
+
+// pendingEmits counts the log writes still blocked in the log sink after
+// Options.EmitTimeout, across all request logger middlewares.
+var pendingEmits atomic.Int64
+
+// emit writes a log entry to the logger, recovering from the panics of the log
+// sink, so that a failing sink never fails the request. If Options.EmitTimeout
+// is positive, it stops waiting for a blocked log sink after the timeout; once
+// Options.MaxPendingEmits writes are pending, further entries are dropped
+// instead of piling up goroutines on the blocked sink. The failures are counted
+// in Stats.
+//
+// All the log entries of the request logger, i.e. the request logs, body
+// entries, partial entries, 5xx bursts and security entries, are written by emit.
+func emit(logger logr.Logger, level int, err error, msg string, kvs []any, o *Options) {
+	kvs = omitEmptyKeys(kvs)
+	write := func() {
+		defer func() {
+			if rec := recover(); rec != nil {
+				stats.emitPanics.Add(1)
+			}
+		}()
+		if level == 0 { // error
+			logger.Error(err, msg, kvs...)
+		} else {
+			logger.Info(msg, kvs...)
+		}
+	}
+	if o.EmitTimeout <= 0 {
+		write()
+		return
+	}
+
+	maxPending := int64(o.MaxPendingEmits)
+	if maxPending <= 0 {
+		maxPending = defaultMaxPendingEmits
+	}
+	if pendingEmits.Add(1) > maxPending {
+		pendingEmits.Add(-1)
+		stats.emitsDropped.Add(1)
+		return
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer pendingEmits.Add(-1)
+		defer close(done)
+		write()
+	}()
+	timer := time.NewTimer(o.EmitTimeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+	case <-timer.C:
+		stats.emitTimeouts.Add(1)
+	}
+}
+
+// omitEmptyKeys removes the key-value pairs with empty keys, i.e. the fields
+// disabled by the schema, e.g. by Schema.Concise or SchemaCEF. The key-value
+// pairs are only copied if there is an empty key.
+func omitEmptyKeys(kvs []any) []any {
+	for i := 0; i+1 < len(kvs); i += 2 {
+		if key, ok := kvs[i].(string); !ok || key != "" {
+			continue
+		}
+		result := append(make([]any, 0, len(kvs)), kvs[:i]...)
+		for i += 2; i < len(kvs); i += 2 {
+			if key, ok := kvs[i].(string); ok && key == "" && i+1 < len(kvs) {
+				continue
+			}
+			result = append(result, kvs[i:min(i+2, len(kvs))]...)
+		}
+		return result
+	}
+	return kvs
+}
@@ -0,0 +1,67 @@
+package httplog_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	httplog "github.com/rickliujh/chi-httplogr/v3"
+	"github.com/rickliujh/chi-httplogr/v3/httplogtest"
+)
+
+// blockingSink blocks all log writes until unblock is closed.
+type blockingSink struct {
+	unblock chan struct{}
+}
+
+func (s blockingSink) Init(logr.RuntimeInfo)          {}
+func (s blockingSink) Enabled(int) bool               { return true }
+func (s blockingSink) Info(int, string, ...any)       { <-s.unblock }
+func (s blockingSink) Error(error, string, ...any)    { <-s.unblock }
+func (s blockingSink) WithValues(...any) logr.LogSink { return s }
+func (s blockingSink) WithName(string) logr.LogSink   { return s }
+
+func TestMaxPendingEmits(t *testing.T) {
+	sink := blockingSink{unblock: make(chan struct{})}
+	defer close(sink.unblock)
+
+	handler := httplog.RequestLogger(logr.New(sink), &httplog.Options{
+		EmitTimeout:     time.Millisecond,
+		MaxPendingEmits: 2,
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+
+	before := httplog.ReadStats()
+	for i := 0; i < 5; i++ {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	}
+	after := httplog.ReadStats()
+
+	if got := after.EmitTimeouts - before.EmitTimeouts; got != 2 {
+		t.Errorf("got %d emit timeouts, want 2", got)
+	}
+	if got := after.EmitsDropped - before.EmitsDropped; got != 3 {
+		t.Errorf("got %d emits dropped, want 3", got)
+	}
+}
+
+func TestEmitOmitsDisabledFields(t *testing.T) {
+	rec := httplogtest.NewRecorder()
+	handler := httplog.RequestLogger(rec.Logger(), &httplog.Options{
+		Schema: httplog.SchemaECS.Concise(true),
+		Levels: &httplog.Levels{Warn: 1, Info: 1, Debug: 1},
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	_, entry := rec.RoundTrip(handler, httptest.NewRequest(http.MethodGet, "/", nil))
+	if len(entry.KVs) == 0 {
+		t.Fatal("no entry logged")
+	}
+	for i := 0; i < len(entry.KVs); i += 2 {
+		if entry.KVs[i] == "" {
+			t.Errorf("entry has an empty key: %v", entry.KVs)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package httplog
+
+import (
+	"fmt"
+	"time"
+)
+
+// DefaultValueEncoder is a value encoder normalizing the attribute values that
+// logr sinks encode inconsistently, see Options.ValueEncoder:
+//
+//   - time.Duration as float milliseconds,
+//   - errors as their messages,
+//   - fmt.Stringers as their strings.
+//
+// Other values are returned as is.
+func DefaultValueEncoder(v any) any {
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case time.Duration:
+		return float64(v.Nanoseconds()) / 1e6
+	case error:
+		return v.Error()
+	case fmt.Stringer:
+		return v.String()
+	default:
+		return v
+	}
+}
+
+// encodeValues applies the value encoder to the values of the keys and values,
+// incl. the values of the nested objects.
+func encodeValues(kvs []any, encode func(any) any) []any {
+	for i := 1; i < len(kvs); i += 2 {
+		kvs[i] = encodeValue(kvs[i], encode)
+	}
+	return kvs
+}
+
+func encodeValue(v any, encode func(any) any) any {
+	m, ok := v.(map[string]any)
+	if !ok {
+		return encode(v)
+	}
+	encoded := make(map[string]any, len(m))
+	for k, val := range m {
+		encoded[k] = encodeValue(val, encode)
+	}
+	return encoded
+}
@@ -0,0 +1,134 @@
+package httplog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+// Entry is the request log entry about to be written by the request logger.
+type Entry struct {
+	Request  *http.Request // Request as served by the underlying HTTP handler
+	Status   int           // Response status code
+	Duration time.Duration // Request duration
+
+	// Level is the log level of the entry: 0 error, -1 warning, -2 info, -3 debug.
+	// Entries with level 0 are logged by logr.Logger.Error.
+	Level   int
+	Message string
+	Err     error // Error logged with entries of level 0, e.g. *PanicError
+
+	// KeysAndValues are the attributes of the entry, keyed by the schema. Keys
+	// are not grouped yet, see Schema.GroupDelimiter.
+	KeysAndValues []any
+
+	Time   time.Time // Time the entry was created
+	Schema *Schema   // Schema the attributes are keyed by
+}
+
+// MarshalJSON encodes the entry as a JSON object, i.e. the "time", "level",
+// "msg" and "error" fields followed by the attributes in order, grouped into
+// nested objects by Schema.GroupDelimiter. Attributes override the fields with
+// the same key, so that each key is encoded once. It lets entries be written
+// directly to files or queues, e.g. by an EntryProcessor.
+func (e *Entry) MarshalJSON() ([]byte, error) {
+	kvs := []any{"time", e.Time, "level", levelName(e.Level), "msg", e.Message}
+	if e.Err != nil {
+		kvs = append(kvs, ErrorKey, e.Err.Error())
+	}
+	kvs = dedupeKVs(append(kvs, e.KeysAndValues...))
+	if e.Schema != nil && e.Schema.grouped() {
+		kvs = groupKVs(kvs, e.Schema)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i := 0; i+1 < len(kvs); i += 2 {
+		key, ok := kvs[i].(string)
+		if !ok {
+			key = fmt.Sprint(kvs[i])
+		}
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		writeJSON(&buf, key)
+		buf.WriteByte(':')
+		writeJSON(&buf, kvs[i+1])
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// writeJSON encodes the value without HTML escaping, falling back to its string
+// form for values that can't be encoded, e.g. errors or channels.
+func writeJSON(buf *bytes.Buffer, v any) {
+	if err, ok := v.(error); ok {
+		v = err.Error()
+	}
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		b.Reset()
+		_ = enc.Encode(fmt.Sprint(v))
+	}
+	buf.Write(bytes.TrimSuffix(b.Bytes(), []byte("\n")))
+}
+
+// levelName returns the name of the entry level, see Entry.Level.
+func levelName(level int) string {
+	switch {
+	case level >= 0:
+		return "error"
+	case level == -1:
+		return "warn"
+	case level == -2:
+		return "info"
+	default:
+		return "debug"
+	}
+}
+
+// EntryProcessor processes the request log entries before they are written,
+// e.g. to redact, enrich, sample or route them, see Options.Processors.
+type EntryProcessor interface {
+	// Process returns the processed entry, which may be e modified in place,
+	// or nil to drop the entry.
+	Process(e *Entry) *Entry
+}
+
+// EntryProcessorFunc is an adapter to use an ordinary function as an EntryProcessor.
+type EntryProcessorFunc func(e *Entry) *Entry
+
+// Process implements EntryProcessor.
+func (f EntryProcessorFunc) Process(e *Entry) *Entry {
+	return f(e)
+}
+
+// processEntry runs the entry through the processors in order. A panicking
+// processor is skipped, leaving the entry as is.
+func processEntry(e *Entry, processors []EntryProcessor, callHook func(name string, hook func())) *Entry {
+	for _, p := range processors {
+		next := e
+		callHook("Processors", func() { next = p.Process(e) })
+		if next == nil {
+			return nil
+		}
+		e = next
+	}
+	return e
+}
+
+// routeEntry returns the logger the entry is routed to by Options.Route at the
+// Options.Visibility, like the default logger, or the default logger if the
+// route returns a zero logr.Logger.
+func routeEntry(e *Entry, route func(e *Entry) logr.Logger, defaultLogger logr.Logger, visibility int) logr.Logger {
+	if l := route(e); l.GetSink() != nil {
+		return l.V(visibility)
+	}
+	return defaultLogger
+}
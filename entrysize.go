@@ -0,0 +1,166 @@
+package httplog
+
+import (
+	"fmt"
+	"sort"
+	"unicode/utf8"
+)
+
+const (
+	trimmedMarker        = "... [trimmed]"
+	trimmedHeadersMarker = "[headers trimmed]"
+)
+
+// estimateSize estimates the serialized size of the value in bytes.
+func estimateSize(v any) int {
+	switch v := v.(type) {
+	case nil:
+		return 4
+	case string:
+		return len(v) + 2
+	case []string:
+		n := 2
+		for _, s := range v {
+			n += len(s) + 3
+		}
+		return n
+	case map[string]any:
+		n := 2
+		for k, v := range v {
+			n += len(k) + 4 + estimateSize(v)
+		}
+		return n
+	case []any:
+		n := 2
+		for _, v := range v {
+			n += estimateSize(v) + 1
+		}
+		return n
+	case bool, int, int64, uint64, float64:
+		return 8
+	case error:
+		return len(v.Error()) + 2
+	}
+	return len(fmt.Sprint(v)) + 2
+}
+
+// estimateEntrySize estimates the serialized size of the key-value pairs in bytes.
+func estimateEntrySize(kvs []any) int {
+	n := 0
+	for i := 0; i+1 < len(kvs); i += 2 {
+		n += estimateSize(kvs[i]) + estimateSize(kvs[i+1]) + 2
+	}
+	return n
+}
+
+// trimEntry trims the largest contributors of the key-value pairs, i.e. bodies
+// first and then headers, until the estimated entry size fits maxBytes.
+func trimEntry(kvs []any, maxBytes int, s *Schema) []any {
+	excess := estimateEntrySize(kvs) - maxBytes
+	if excess <= 0 {
+		return kvs
+	}
+
+	// Trim the bodies, largest first.
+	bodies := indexesOf(kvs, s.RequestBody, s.ResponseBody)
+	sort.Slice(bodies, func(i, j int) bool {
+		return estimateSize(kvs[bodies[i]]) > estimateSize(kvs[bodies[j]])
+	})
+	for _, i := range bodies {
+		body, ok := kvs[i].(string)
+		if !ok || excess <= 0 || len(body) <= len(trimmedMarker) {
+			continue
+		}
+		kept := truncateUTF8(body, max(len(body)-excess-len(trimmedMarker), 0))
+		excess -= len(body) - len(kept) - len(trimmedMarker)
+		kvs[i] = kept + trimmedMarker
+	}
+
+	// Replace the headers, largest first.
+	headers := indexesOf(kvs, s.RequestHeaders, s.ResponseHeaders)
+	sort.Slice(headers, func(i, j int) bool {
+		return estimateSize(kvs[headers[i]]) > estimateSize(kvs[headers[j]])
+	})
+	for _, i := range headers {
+		if excess <= 0 {
+			break
+		}
+		excess -= estimateSize(kvs[i]) - estimateSize(trimmedHeadersMarker)
+		kvs[i] = trimmedHeadersMarker
+	}
+
+	return kvs
+}
+
+// indexesOf returns the indexes of the values of the given keys.
+func indexesOf(kvs []any, keys ...string) []int {
+	var indexes []int
+	for i := 0; i+1 < len(kvs); i += 2 {
+		k, ok := kvs[i].(string)
+		if !ok || k == "" {
+			continue
+		}
+		for _, key := range keys {
+			if k == key {
+				indexes = append(indexes, i+1)
+			}
+		}
+	}
+	return indexes
+}
+
+// capValues caps the length of the string values of the key-value pairs, incl.
+// the values nested in headers and other objects, to maxLen bytes. The bodies
+// are capped by Options.LogBodyMaxLen instead.
+func capValues(kvs []any, maxLen int, s *Schema) []any {
+	for i := 1; i < len(kvs); i += 2 {
+		if key := kvs[i-1]; key == s.RequestBody || key == s.ResponseBody {
+			continue
+		}
+		kvs[i] = capValue(kvs[i], maxLen)
+	}
+	return kvs
+}
+
+func capValue(v any, maxLen int) any {
+	switch v := v.(type) {
+	case string:
+		if len(v) > maxLen {
+			if maxLen <= len(trimmedMarker) {
+				return truncateUTF8(v, maxLen)
+			}
+			return truncateUTF8(v, maxLen-len(trimmedMarker)) + trimmedMarker
+		}
+	case []string:
+		capped := make([]string, len(v))
+		for i, s := range v {
+			capped[i] = capValue(s, maxLen).(string)
+		}
+		return capped
+	case map[string]any:
+		capped := make(map[string]any, len(v))
+		for k, val := range v {
+			capped[k] = capValue(val, maxLen)
+		}
+		return capped
+	case []any:
+		capped := make([]any, len(v))
+		for i, val := range v {
+			capped[i] = capValue(val, maxLen)
+		}
+		return capped
+	}
+	return v
+}
+
+// truncateUTF8 returns the longest prefix of s of at most n bytes that doesn't
+// cut a UTF-8 encoded character.
+func truncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
@@ -0,0 +1,52 @@
+package httplog_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	httplog "github.com/rickliujh/chi-httplogr/v3"
+	"github.com/rickliujh/chi-httplogr/v3/httplogtest"
+)
+
+func TestMaxAttrValueLen(t *testing.T) {
+	rec := httplogtest.NewRecorder()
+	handler := httplog.RequestLogger(rec.Logger(), &httplog.Options{
+		Schema:          httplog.SchemaECS,
+		Levels:          &httplog.Levels{Warn: 1, Info: 1, Debug: 1},
+		MaxAttrValueLen: 20,
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("User-Agent", strings.Repeat("ü", 20))
+	_, entry := rec.RoundTrip(handler, req)
+
+	ua, _ := entry.Value(httplog.SchemaECS.RequestUserAgent)
+	if s, _ := ua.(string); len(s) > 20 || !utf8.ValidString(s) || !strings.HasSuffix(s, "[trimmed]") {
+		t.Errorf("got user agent %q, want at most 20 bytes of valid UTF-8 with a marker", ua)
+	}
+}
+
+func TestMaxEntryBytes(t *testing.T) {
+	// The cut falls into a character for some of the sizes.
+	for maxBytes := 1000; maxBytes < 1004; maxBytes++ {
+		rec := httplogtest.NewRecorder()
+		handler := httplog.RequestLogger(rec.Logger(), &httplog.Options{
+			Schema:         httplog.SchemaECS,
+			Levels:         &httplog.Levels{Warn: 1, Info: 1, Debug: 1},
+			LogRequestBody: func(r *http.Request) bool { return true },
+			MaxEntryBytes:  maxBytes,
+		})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(strings.Repeat("ü", 500)))
+		req.Header.Set("Content-Type", "text/plain")
+		_, entry := rec.RoundTrip(handler, req)
+
+		body, _ := entry.Value(httplog.SchemaECS.RequestBody)
+		if s, _ := body.(string); !utf8.ValidString(s) || !strings.HasSuffix(s, "[trimmed]") {
+			t.Errorf("MaxEntryBytes %d: got body %q, want valid UTF-8 trimmed", maxBytes, body)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package httplog
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-logr/logr"
+)
+
+// errorDedupCacheSize is the maximum number of error fingerprints tracked by
+// the error log deduplication, see Options.ErrorDedupWindow.
+const errorDedupCacheSize = 1024
+
+// newErrorDedupCache returns the cache of recently logged error fingerprints,
+// or nil if the error log deduplication is disabled. The error logs collapsed
+// within the window are reported by a summary entry once it expires, unless
+// another identical error log carries their count first.
+func newErrorDedupCache(logger logr.Logger, o *Options, s *Schema) *lruCache {
+	if o.ErrorDedupWindow <= 0 {
+		return nil
+	}
+	c := newLRUCache(errorDedupCacheSize, o.ErrorDedupWindow)
+	c.flush = func(value any, repeats int) {
+		summary := value.(errorSummary)
+		kvs := appendKVs(summary.kvs, s.RepeatCount, repeats)
+		if s.grouped() {
+			kvs = groupKVs(kvs, s)
+		}
+		emit(logger, 0, nil, fmt.Sprintf("%s: %d identical error logs collapsed within %v", summary.msg, repeats, o.ErrorDedupWindow), kvs, o)
+	}
+	return c
+}
+
+// errorSummary holds the attributes of the summary entry reporting the error
+// logs collapsed after the first one, see Options.ErrorDedupWindow.
+type errorSummary struct {
+	msg string
+	kvs []any
+}
+
+func newErrorSummary(r *http.Request, o *Options, status int, kvs []any, s *Schema) errorSummary {
+	route := MetricsLabel(r, o)
+	summary := errorSummary{
+		msg: fmt.Sprintf("%s %s => HTTP %v", r.Method, route, status),
+		kvs: appendKVs(nil, s.RequestMethod, r.Method, s.RequestRoute, route, s.ResponseStatus, status),
+	}
+	for i := 0; i+1 < len(kvs); i += 2 {
+		if s.ErrorMessage != "" && kvs[i] == s.ErrorMessage {
+			summary.kvs = appendKVs(summary.kvs, s.ErrorMessage, kvs[i+1])
+		}
+	}
+	return summary
+}
+
+// errorFingerprint identifies identical error logs by the route, status and
+// error messages of the request log.
+func errorFingerprint(r *http.Request, o *Options, status int, kvs []any, s *Schema) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s %d", r.Method, MetricsLabel(r, o), status)
+	for i := 0; i+1 < len(kvs); i += 2 {
+		if kvs[i] == ErrorKey || (s.ErrorMessage != "" && kvs[i] == s.ErrorMessage) {
+			fmt.Fprintf(&b, "\n%v", kvs[i+1])
+		}
+	}
+	return b.String()
+}
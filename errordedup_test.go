@@ -0,0 +1,76 @@
+package httplog_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	httplog "github.com/rickliujh/chi-httplogr/v3"
+	"github.com/rickliujh/chi-httplogr/v3/httplogtest"
+)
+
+func TestErrorDedupWindow(t *testing.T) {
+	clock := httplogtest.NewClock(time.Now(), 0)
+	rec := httplogtest.NewRecorder()
+	handler := httplog.RequestLogger(rec.Logger(), &httplog.Options{
+		Clock:            clock,
+		ErrorDedupWindow: time.Hour,
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		httplog.SetError(r.Context(), errors.New("upstream unavailable"))
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+
+	for i := 0; i < 4; i++ {
+		rec.RoundTrip(handler, httptest.NewRequest(http.MethodGet, "/", nil))
+	}
+	if entries := rec.Entries(); len(entries) != 1 {
+		t.Fatalf("got %d entries, want the 3 identical errors collapsed into the first one", len(entries))
+	}
+	if _, ok := rec.LastEntry().Value(httplog.SchemaECS.RepeatCount); ok {
+		t.Errorf("the first error entry has a repeat count: %v", rec.LastEntry().KVs)
+	}
+
+	// The next identical error after the window carries the collapsed count.
+	clock.Advance(time.Hour)
+	_, entry := rec.RoundTrip(handler, httptest.NewRequest(http.MethodGet, "/", nil))
+	if !entry.HasKV(httplog.SchemaECS.RepeatCount, 3) {
+		t.Errorf("entry is missing the repeat count of 3: %v", entry.KVs)
+	}
+}
+
+func TestErrorDedupWindowFlush(t *testing.T) {
+	const window = 50 * time.Millisecond
+	rec := httplogtest.NewRecorder()
+	handler := httplog.RequestLogger(rec.Logger(), &httplog.Options{
+		ErrorDedupWindow: window,
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+
+	for i := 0; i < 3; i++ {
+		rec.RoundTrip(handler, httptest.NewRequest(http.MethodGet, "/", nil))
+	}
+
+	// The outage stops: the collapsed count is reported once the window expires.
+	deadline := time.Now().Add(5 * time.Second)
+	for len(rec.Entries()) < 2 && time.Now().Before(deadline) {
+		time.Sleep(window / 5)
+	}
+	entries := rec.Entries()
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want the first error and the summary", len(entries))
+	}
+	summary := entries[1]
+	if !summary.IsError || !summary.HasKV(httplog.SchemaECS.RepeatCount, 2) || !summary.HasKV(httplog.SchemaECS.ResponseStatus, 503) {
+		t.Errorf("got summary entry %q %v, want an error with the repeat count of 2", summary.Message, summary.KVs)
+	}
+
+	// The count was reported once, so the next error doesn't carry it again.
+	time.Sleep(window)
+	_, entry := rec.RoundTrip(handler, httptest.NewRequest(http.MethodGet, "/", nil))
+	if _, ok := entry.Value(httplog.SchemaECS.RepeatCount); ok {
+		t.Errorf("entry has the repeat count reported already: %v", entry.KVs)
+	}
+}
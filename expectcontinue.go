@@ -0,0 +1,47 @@
+package httplog
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// expectsContinue reports whether the client waits for the interim
+// "100 Continue" response before sending the request body.
+func expectsContinue(r *http.Request) bool {
+	return strings.EqualFold(r.Header.Get("Expect"), "100-continue")
+}
+
+// continueReader records the 100-continue negotiation of the request body.
+// The net/http server sends the interim "100 Continue" response on the first
+// read of the body, unless the final response headers were written already.
+type continueReader struct {
+	io.ReadCloser
+	clock  Clock
+	status func() int
+
+	sent      bool      // whether the interim response was sent
+	sentAt    time.Time // when the interim response was sent
+	firstByte time.Time // when the first byte of the body was received
+}
+
+func (cr *continueReader) Read(p []byte) (int, error) {
+	if cr.sentAt.IsZero() {
+		cr.sentAt = cr.clock.Now()
+		cr.sent = cr.status() == 0
+	}
+	n, err := cr.ReadCloser.Read(p)
+	if n > 0 && cr.firstByte.IsZero() {
+		cr.firstByte = cr.clock.Now()
+	}
+	return n, err
+}
+
+func (cr *continueReader) kvs(s *Schema) []any {
+	kvs := []any{s.RequestContinueSent, cr.sent}
+	if cr.sent && !cr.firstByte.IsZero() {
+		kvs = append(kvs, s.RequestContinueWait, float64(cr.firstByte.Sub(cr.sentAt).Milliseconds()))
+	}
+	return kvs
+}
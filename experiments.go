@@ -0,0 +1,48 @@
+package httplog
+
+import (
+	"context"
+)
+
+// experiment holds the variant of the named experiment set by SetExperiment.
+type experiment struct {
+	name    string
+	variant string
+}
+
+// SetExperiment records the variant of the named experiment or feature flag
+// assigned to the request, e.g. httplog.SetExperiment(ctx, "new-checkout", "b").
+// The assignments are logged as a nested Schema.Experiments object; setting the
+// same experiment again overrides its variant.
+func SetExperiment(ctx context.Context, name, variant string) {
+	if rl := getRequestLog(ctx); rl != nil {
+		rl.mu.Lock()
+		defer rl.mu.Unlock()
+		for i := range rl.experiments {
+			if rl.experiments[i].name == name {
+				rl.experiments[i].variant = variant
+				return
+			}
+		}
+		rl.experiments = append(rl.experiments, experiment{name: name, variant: variant})
+	}
+}
+
+// experimentsKVs returns the experiment assignments as a nested object, or nil.
+func experimentsKVs(ctx context.Context) map[string]any {
+	rl := getRequestLog(ctx)
+	if rl == nil {
+		return nil
+	}
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	if len(rl.experiments) == 0 {
+		return nil
+	}
+	m := make(map[string]any, len(rl.experiments))
+	for _, e := range rl.experiments {
+		m[e.name] = e.variant
+	}
+	return m
+}
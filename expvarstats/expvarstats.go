@@ -0,0 +1,32 @@
+// Package expvarstats publishes the request logger counters as an expvar
+// variable, served on /debug/vars by the expvar package:
+//
+//	expvarstats.Publish("httplog")
+//
+// It's a separate package, since importing expvar registers the /debug/vars
+// handler, incl. the command line and the memory stats, on
+// http.DefaultServeMux.
+package expvarstats
+
+import (
+	"expvar"
+
+	httplog "github.com/rickliujh/chi-httplogr/v3"
+)
+
+// Publish publishes the request logger counters (see httplog.ReadStats), incl.
+// the bandwidth stats (see httplog.ReadBandwidth) and the recovered panics (see
+// httplog.PanicsByRoute and httplog.RecentPanics), as an expvar variable with
+// the given name, e.g. "httplog".
+//
+// Like expvar.Publish, it panics if the name is already registered.
+func Publish(name string) {
+	expvar.Publish(name, expvar.Func(func() any {
+		return struct {
+			httplog.Stats
+			Bandwidth     []httplog.BandwidthStats `json:"bandwidth,omitempty"`
+			PanicsByRoute map[string]uint64        `json:"panicsByRoute,omitempty"`
+			RecentPanics  []httplog.PanicRecord    `json:"recentPanics,omitempty"`
+		}{httplog.ReadStats(), httplog.ReadBandwidth(), httplog.PanicsByRoute(), httplog.RecentPanics()}
+	}))
+}
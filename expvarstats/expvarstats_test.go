@@ -0,0 +1,25 @@
+package expvarstats_test
+
+import (
+	"encoding/json"
+	"expvar"
+	"testing"
+
+	"github.com/rickliujh/chi-httplogr/v3/expvarstats"
+)
+
+func TestPublish(t *testing.T) {
+	expvarstats.Publish("httplog")
+
+	v := expvar.Get("httplog")
+	if v == nil {
+		t.Fatal("httplog not published")
+	}
+	var got map[string]any
+	if err := json.Unmarshal([]byte(v.String()), &got); err != nil {
+		t.Fatalf("invalid JSON %s: %v", v.String(), err)
+	}
+	if _, ok := got["requestsLogged"]; !ok {
+		t.Errorf("requestsLogged not published: %s", v.String())
+	}
+}
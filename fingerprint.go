@@ -0,0 +1,28 @@
+package httplog
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// requestFingerprint returns a stable fingerprint of the request client, i.e.
+// the truncated SHA-256 of the method, the route pattern, the sorted header
+// names and the user agent, see Options.LogFingerprint. It doesn't include the
+// client IP, so that traffic can be clustered across rotating IPs.
+func requestFingerprint(r *http.Request, route string) string {
+	names := make([]string, 0, len(r.Header))
+	for name := range r.Header {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	h := sha256.New()
+	for _, part := range []string{r.Method, route, strings.Join(names, ","), r.UserAgent()} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil)[:8])
+}
@@ -0,0 +1,165 @@
+package httplog
+
+import (
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-logr/logr"
+)
+
+// NewLogFormatter returns an adapter implementing chi's middleware.LogFormatter,
+// which logs the requests using the given schema and options. It lets projects
+// wired to chi's middleware.RequestLogger migrate incrementally, e.g.:
+//
+//	r.Use(middleware.RequestLogger(httplog.NewLogFormatter(logger, opts)))
+//	r.Use(middleware.Recoverer)
+//
+// Only the core request and response attributes are logged. Features relying on
+// the request context (e.g. SetKVs) or on the response body require RequestLogger.
+func NewLogFormatter(logger logr.Logger, o *Options) middleware.LogFormatter {
+	if o == nil {
+		o = &defaultOptions
+	}
+	s := o.Schema
+	if s == nil {
+		s = SchemaECS
+	}
+	return &logFormatter{
+		logger:      logger.V(o.Visibility),
+		o:           o,
+		s:           s,
+		reqHeaders:  newHeaderMatcher(o.LogRequestHeaders, o),
+		respHeaders: newHeaderMatcher(o.LogResponseHeaders, o),
+	}
+}
+
+type logFormatter struct {
+	logger      logr.Logger
+	o           *Options
+	s           *Schema
+	reqHeaders  *headerMatcher
+	respHeaders *headerMatcher
+}
+
+func (f *logFormatter) NewLogEntry(r *http.Request) middleware.LogEntry {
+	return &logEntry{logFormatter: f, r: r}
+}
+
+type logEntry struct {
+	*logFormatter
+	r        *http.Request
+	panic    []any
+	panicErr *PanicError
+
+	hookErrors []string
+}
+
+func (e *logEntry) Write(status, bytes int, header http.Header, elapsed time.Duration, extra any) {
+	r, s, o := e.r, e.s, e.o
+	if len(e.panic) > 0 {
+		status = http.StatusInternalServerError
+	}
+	if status == 0 {
+		// The status wasn't written, e.g. as the client closed the request.
+		record := Record{Request: r}
+		record.complete(o, nil)
+		status = record.Status
+	}
+
+	if o.Skip != nil {
+		var skip bool
+		e.callHook("Skip", func() { skip = o.Skip(r, status) })
+		if skip {
+			stats.requestsSuppressed.Add(1)
+			return
+		}
+	}
+	if status < 400 && len(e.panic) == 0 {
+		if skip, _ := skipMethod(r.Method, o); skip {
+			stats.requestsSuppressed.Add(1)
+			return
+		}
+	}
+	lvl := statusLevel(status, r.Method, o)
+	if e.logger.GetV() > o.Levels.v(lvl) {
+		stats.requestsSuppressed.Add(1)
+		return
+	}
+
+	logkvs := appendKVs(e.panic, coreKVs(r, header, status, bytes, elapsed, s, o, e.reqHeaders, e.respHeaders)...)
+	if len(e.hookErrors) > 0 {
+		logkvs = appendKVs(logkvs, s.HookErrors, e.hookErrors)
+	}
+	if o.MaxAttrValueLen > 0 {
+		logkvs = capValues(logkvs, o.MaxAttrValueLen, s)
+	}
+	if o.DedupeKeys {
+		logkvs = dedupeKVs(logkvs)
+	}
+
+	entry := &Entry{
+		Request:       r,
+		Status:        status,
+		Duration:      elapsed,
+		Level:         lvl,
+		Message:       fmt.Sprintf("%s %s => HTTP %v (%v)", r.Method, redactedURL(r.URL, o), status, elapsed),
+		KeysAndValues: logkvs,
+		Time:          time.Now(),
+		Schema:        s,
+	}
+	if e.panicErr != nil {
+		entry.Err = e.panicErr
+	}
+	if len(o.Processors) > 0 {
+		entry = processEntry(entry, o.Processors, e.callHook)
+		if entry == nil {
+			stats.requestsSuppressed.Add(1)
+			return
+		}
+		logkvs = entry.KeysAndValues
+	}
+	if o.ValueEncoder != nil {
+		e.callHook("ValueEncoder", func() { logkvs = encodeValues(logkvs, o.ValueEncoder) })
+	}
+
+	if s.grouped() {
+		logkvs = groupKVs(logkvs, s)
+	}
+
+	stats.requestsLogged.Add(1)
+
+	logger := e.logger
+	if o.Route != nil {
+		e.callHook("Route", func() { logger = routeEntry(entry, o.Route, e.logger, o.Visibility) })
+	}
+	emit(logger.V(o.Levels.v(entry.Level)), entry.Level, entry.Err, entry.Message, logkvs, o)
+	if o.AfterEmit != nil {
+		e.callHook("AfterEmit", func() { o.AfterEmit(entry) })
+	}
+}
+
+func (e *logEntry) Panic(v any, stack []byte) {
+	if len(stack) == 0 {
+		stack = debug.Stack()
+	}
+	e.panicErr = &PanicError{Value: v, Stack: strings.Split(strings.TrimSpace(string(stack)), "\n")}
+	recordPanic(time.Now(), MetricsLabel(e.r, e.o), e.panicErr)
+	e.panic = appendKVs(e.panic,
+		e.s.ErrorMessage, e.panicErr.Error(),
+		e.s.ErrorStackTrace, e.panicErr.Stack,
+	)
+	if e.o.OnPanic != nil {
+		e.callHook("OnPanic", func() { e.o.OnPanic(e.r, e.panicErr) })
+	}
+}
+
+// callHook calls the user-provided hook, recording its failure on the entry.
+func (e *logEntry) callHook(name string, hook func()) {
+	if err := callHook(e.r.Context(), name, hook); err != nil {
+		e.hookErrors = append(e.hookErrors, err.Error())
+	}
+}
@@ -5,4 +5,4 @@ go 1.21
 
 require github.com/go-chi/chi/v5 v5.1.0
 
-require github.com/go-logr/logr v1.4.3 // indirect
+require github.com/go-logr/logr v1.4.3
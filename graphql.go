@@ -0,0 +1,69 @@
+package httplog
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// isGraphQL reports whether the request is made to one of the GraphQL endpoints.
+func isGraphQL(r *http.Request, o *Options) bool {
+	return len(o.GraphQLPaths) > 0 && slices.Contains(o.GraphQLPaths, r.URL.Path)
+}
+
+// graphQLKVs returns the operation name, operation type and truncated query hash
+// of the GraphQL request, read from the request body or the URL query (GET).
+func graphQLKVs(r *http.Request, body []byte, s *Schema) []any {
+	var req struct {
+		Query         string `json:"query"`
+		OperationName string `json:"operationName"`
+	}
+	if r.Method == http.MethodGet {
+		req.Query = r.URL.Query().Get("query")
+		req.OperationName = r.URL.Query().Get("operationName")
+	} else if err := json.Unmarshal(body, &req); err != nil {
+		return nil
+	}
+	if req.Query == "" {
+		return nil
+	}
+
+	hash := sha256.Sum256([]byte(req.Query))
+	kvs := []any{
+		s.GraphQLOperationType, graphQLOperationType(req.Query, req.OperationName),
+		s.GraphQLDocumentHash, hex.EncodeToString(hash[:8]),
+	}
+	if req.OperationName != "" {
+		kvs = append(kvs, s.GraphQLOperationName, req.OperationName)
+	}
+	return kvs
+}
+
+// graphQLOperationType returns the type (query, mutation or subscription) of the
+// named operation of the GraphQL document, or of its first operation.
+func graphQLOperationType(query, operationName string) string {
+	fields := strings.FieldsFunc(query, func(r rune) bool {
+		return r == ' ' || r == '\t' || r == '\n' || r == '\r' || r == ',' || r == '(' || r == '{'
+	})
+
+	var first string
+	for i, field := range fields {
+		switch field {
+		case "query", "mutation", "subscription":
+			if first == "" {
+				first = field
+			}
+			if operationName == "" || (i+1 < len(fields) && fields[i+1] == operationName) {
+				return field
+			}
+		}
+	}
+	if first == "" {
+		// Shorthand query syntax, e.g. "{ user { id } }".
+		return "query"
+	}
+	return first
+}
@@ -0,0 +1,48 @@
+package httplog
+
+import (
+	"net/http"
+	"reflect"
+	"runtime"
+	"strings"
+)
+
+// Named wraps the handler to log the given name of the handler as
+// Schema.HandlerName, so that the log entries identify which Go handler served
+// the request, not just the route pattern, e.g.:
+//
+//	r.Method(http.MethodGet, "/users/{id}", httplog.Named("", http.HandlerFunc(getUser)))
+//
+// If the name is empty, it's derived from the handler function or type, e.g.
+// "main.getUser" or "*api.UserHandler".
+func Named(name string, h http.Handler) http.Handler {
+	if name == "" {
+		name = handlerName(h)
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if rl := getRequestLog(r.Context()); rl != nil {
+			rl.mu.Lock()
+			rl.handler = name
+			rl.mu.Unlock()
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// handlerName returns the name of the handler function, or of the handler type.
+func handlerName(h http.Handler) string {
+	if f, ok := h.(http.HandlerFunc); ok {
+		if fn := runtime.FuncForPC(reflect.ValueOf(f).Pointer()); fn != nil {
+			// Trim the package path, e.g. "github.com/org/app/api.getUser".
+			name := fn.Name()
+			return name[strings.LastIndex(name, "/")+1:]
+		}
+	}
+	return reflect.TypeOf(h).String()
+}
+
+func (rl *requestLog) handlerName() string {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	return rl.handler
+}
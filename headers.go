@@ -0,0 +1,95 @@
+package httplog
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// redactedHeaderValue is the default placeholder of the redacted values of the
+// logged headers and query parameters, see Options.RedactPlaceholder.
+const redactedHeaderValue = "[REDACTED]"
+
+var defaultRedactHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key"}
+
+// headerMatcher selects the headers to be logged, see Options.LogRequestHeaders.
+// It's compiled once from the configured header names and patterns, which are
+// matched case-insensitively.
+type headerMatcher struct {
+	all      bool
+	names    []string
+	prefixes []string
+	redact   map[string]bool
+	lower    bool
+	o        *Options
+}
+
+func newHeaderMatcher(patterns []string, o *Options) *headerMatcher {
+	m := &headerMatcher{redact: map[string]bool{}, lower: o.LowercaseHeaderKeys, o: o}
+	for _, p := range patterns {
+		switch {
+		case p == "*":
+			m.all = true
+		case strings.HasSuffix(p, "*"):
+			m.prefixes = append(m.prefixes, http.CanonicalHeaderKey(strings.TrimSuffix(p, "*")))
+		default:
+			m.names = append(m.names, http.CanonicalHeaderKey(p))
+		}
+	}
+
+	redact := o.RedactHeaders
+	if redact == nil {
+		redact = defaultRedactHeaders
+	}
+	for _, h := range redact {
+		m.redact[http.CanonicalHeaderKey(h)] = true
+	}
+	return m
+}
+
+// kvs returns the selected headers as key-value pairs. The headers selected by
+// name come first in the configured order, followed by the wildcard matches in
+// alphabetical order.
+func (m *headerMatcher) kvs(header http.Header) []any {
+	kvs := make([]any, 0, len(m.names)*2)
+	for _, h := range m.names {
+		kvs = m.appendHeader(kvs, header, h)
+	}
+	if !m.all && len(m.prefixes) == 0 {
+		return kvs
+	}
+
+	keys := make([]string, 0, len(header))
+	for h := range header {
+		if slices.Contains(m.names, h) {
+			continue
+		}
+		if m.all || slices.ContainsFunc(m.prefixes, func(prefix string) bool { return strings.HasPrefix(h, prefix) }) {
+			keys = append(keys, h)
+		}
+	}
+	slices.Sort(keys)
+	for _, h := range keys {
+		kvs = m.appendHeader(kvs, header, h)
+	}
+	return kvs
+}
+
+func (m *headerMatcher) appendHeader(kvs []any, header http.Header, h string) []any {
+	vals := header.Values(h)
+	if len(vals) == 0 {
+		return kvs
+	}
+
+	key := h
+	if m.lower {
+		key = strings.ToLower(h)
+	}
+	switch {
+	case m.redact[h]:
+		return append(kvs, key, redactValues(vals, m.o))
+	case len(vals) == 1:
+		return append(kvs, key, vals[0])
+	}
+	return append(kvs, key, vals)
+}
@@ -0,0 +1,48 @@
+package httplog
+
+import (
+	"context"
+	"fmt"
+)
+
+// HookError is a panic recovered from a user-provided hook, e.g. Options.Skip or
+// Options.LogExtraAttrs. Hook failures are logged as Schema.HookErrors instead
+// of taking down the request or suppressing the request log.
+type HookError struct {
+	Hook  string // Name of the hook, i.e. the Options field
+	Value any    // Value passed to panic
+}
+
+func (e *HookError) Error() string {
+	return fmt.Sprintf("httplog: %s hook panic: %v", e.Hook, e.Value)
+}
+
+// callHook calls the user-provided hook, recovering from its panic. The hook
+// results are assigned by the hook closure, so they keep their zero values on
+// failure. The failure is recorded on the request log of ctx, if any.
+func callHook(ctx context.Context, name string, hook func()) error {
+	return getRequestLog(ctx).callHook(name, hook)
+}
+
+func (rl *requestLog) callHook(name string, hook func()) (err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			stats.hookPanics.Add(1)
+			hookErr := &HookError{Hook: name, Value: rec}
+			if rl != nil {
+				rl.mu.Lock()
+				rl.hookErrors = append(rl.hookErrors, hookErr.Error())
+				rl.mu.Unlock()
+			}
+			err = hookErr
+		}
+	}()
+	hook()
+	return nil
+}
+
+func (rl *requestLog) getHookErrors() []string {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	return rl.hookErrors
+}
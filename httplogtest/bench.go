@@ -0,0 +1,134 @@
+package httplogtest
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr"
+	httplog "github.com/rickliujh/chi-httplogr/v3"
+)
+
+// Scenario is a hot path of the request logger to be benchmarked, see
+// Scenarios, RunBenchmarks and CheckAllocs.
+type Scenario struct {
+	Name       string
+	Options    *httplog.Options
+	Handler    http.Handler
+	NewRequest func() *http.Request
+
+	// MaxAllocs is the allocation budget of one logged request, incl. the
+	// allocations of the handler and of the request recorder.
+	MaxAllocs float64
+}
+
+// Scenarios returns the hot paths of the request logger with their allocation
+// budgets: no body, body logging, panic recovery and the grouped GCP schema.
+// The requests are responded with HTTP 500, so that they are logged.
+func Scenarios() []Scenario {
+	body := `{"name":"gopher","tags":["a","b","c"]}`
+	failed := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"error":"internal"}`))
+	})
+	get := func() *http.Request {
+		return httptest.NewRequest(http.MethodGet, "/users/42?q=1", nil)
+	}
+	post := func() *http.Request {
+		r := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/json")
+		return r
+	}
+
+	return []Scenario{
+		{
+			Name:       "NoBody",
+			Options:    &httplog.Options{},
+			Handler:    failed,
+			NewRequest: get,
+			MaxAllocs:  100,
+		},
+		{
+			Name: "BodyLogging",
+			Options: &httplog.Options{
+				LogRequestBody:  func(*http.Request) bool { return true },
+				LogResponseBody: func(*http.Request) bool { return true },
+			},
+			Handler:    failed,
+			NewRequest: post,
+			MaxAllocs:  120,
+		},
+		{
+			Name:    "Panic",
+			Options: &httplog.Options{RecoverPanics: true},
+			Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				panic("boom")
+			}),
+			NewRequest: get,
+			MaxAllocs:  140,
+		},
+		{
+			Name:       "GroupedSchema",
+			Options:    &httplog.Options{Schema: httplog.SchemaGCP},
+			Handler:    failed,
+			NewRequest: get,
+			MaxAllocs:  155,
+		},
+	}
+}
+
+// RunBenchmarks runs the scenarios as sub-benchmarks, e.g.:
+//
+//	func BenchmarkRequestLogger(b *testing.B) {
+//		httplogtest.RunBenchmarks(b, httplogtest.Scenarios())
+//	}
+func RunBenchmarks(b *testing.B, scenarios []Scenario) {
+	for _, sc := range scenarios {
+		b.Run(sc.Name, func(b *testing.B) {
+			h := httplog.RequestLogger(discardLogger(), sc.Options)(sc.Handler)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				h.ServeHTTP(httptest.NewRecorder(), sc.NewRequest())
+			}
+		})
+	}
+}
+
+// CheckAllocs fails the test if any scenario exceeds its allocation budget,
+// so that performance regressions are caught by regular tests, e.g.:
+//
+//	func TestAllocs(t *testing.T) {
+//		httplogtest.CheckAllocs(t, httplogtest.Scenarios())
+//	}
+func CheckAllocs(t testing.TB, scenarios []Scenario) {
+	t.Helper()
+	for _, sc := range scenarios {
+		h := httplog.RequestLogger(discardLogger(), sc.Options)(sc.Handler)
+		allocs := testing.AllocsPerRun(100, func() {
+			h.ServeHTTP(httptest.NewRecorder(), sc.NewRequest())
+		})
+		if allocs > sc.MaxAllocs {
+			t.Errorf("%s: %v allocs per request, budget is %v", sc.Name, allocs, sc.MaxAllocs)
+		}
+	}
+}
+
+// discardLogger returns a logger enabled at all levels, which discards the
+// entries, so that the entries are fully built.
+func discardLogger() logr.Logger {
+	return logr.New(discardSink{})
+}
+
+type discardSink struct{}
+
+func (discardSink) Init(logr.RuntimeInfo)            {}
+func (discardSink) Enabled(int) bool                 { return true }
+func (discardSink) Info(int, string, ...any)         {}
+func (discardSink) Error(error, string, ...any)      {}
+func (s discardSink) WithValues(...any) logr.LogSink { return s }
+func (s discardSink) WithName(string) logr.LogSink   { return s }
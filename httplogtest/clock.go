@@ -0,0 +1,43 @@
+package httplogtest
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock is a fake clock implementing httplog.Clock, which makes the logged
+// durations deterministic. Each call to Now advances the time by Step.
+type Clock struct {
+	mu   sync.Mutex
+	now  time.Time
+	Step time.Duration
+}
+
+// NewClock returns a fake clock starting at the given time and advancing by
+// step on each call to Now.
+func NewClock(start time.Time, step time.Duration) *Clock {
+	return &Clock{now: start, Step: step}
+}
+
+// Now returns the current fake time and advances the clock by Step.
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now
+	c.now = c.now.Add(c.Step)
+	return now
+}
+
+// Since returns the fake time elapsed since t.
+func (c *Clock) Since(t time.Time) time.Duration {
+	return c.Now().Sub(t)
+}
+
+// Advance moves the clock forward by d.
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+}
@@ -0,0 +1,138 @@
+package httplogtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	httplog "github.com/rickliujh/chi-httplogr/v3"
+)
+
+// UpdateGoldenEnv is the environment variable which makes Golden (re)write the
+// golden files instead of comparing the entries with them, e.g.:
+//
+//	HTTPLOGTEST_UPDATE=1 go test ./...
+const UpdateGoldenEnv = "HTTPLOGTEST_UPDATE"
+
+// volatileFields are the schema fields normalized by Golden, i.e. the wall
+// clock durations, the throughputs derived from them and the per-middleware
+// sequence numbers.
+var volatileFields = []string{
+	"AbortElapsed",
+	"AbortNoticed",
+	"RequestDeadline",
+	"RequestDeadlineRemaining",
+	"RequestQueueTime",
+	"RejectQueueWait",
+	"RequestSequence",
+	"RequestContinueWait",
+	"RequestReadDeadline",
+	"RequestBytesPerSec",
+	"ResponseDuration",
+	"ResponseBytesPerSec",
+	"ResponseStalled",
+	"ResponseWriteDeadline",
+	"Timings",
+	"MiddlewareTimings",
+	"UpstreamDuration",
+}
+
+// Golden compares the request log entry with the golden file at path, so that
+// services can golden-test the shape of their request logs against schema or
+// option changes, e.g.:
+//
+//	var entry *httplog.Entry
+//	opts := &httplog.Options{AfterEmit: func(e *httplog.Entry) { entry = e }}
+//	// serve a request by the middleware
+//	httplogtest.Golden(t, entry, "testdata/entry.json")
+//
+// The entry is encoded by Entry.MarshalJSON, i.e. keyed and grouped by its
+// schema, and indented. The time, the durations (incl. the one in the message)
+// and the sequence number are normalized to zero values, so that the golden
+// file only changes with the shape of the entry. Other volatile values, e.g.
+// generated request IDs, should be set by the test, e.g. by the X-Request-Id
+// header. If UpdateGoldenEnv is set, the golden file is written instead.
+func Golden(t testing.TB, entry *httplog.Entry, path string) {
+	t.Helper()
+
+	if entry == nil {
+		t.Fatalf("httplogtest: no entry to compare with golden file %s", path)
+	}
+	data, err := json.Marshal(normalizeEntry(entry))
+	if err != nil {
+		t.Fatalf("httplogtest: encoding entry: %v", err)
+	}
+	var got bytes.Buffer
+	if err := json.Indent(&got, data, "", "  "); err != nil {
+		t.Fatalf("httplogtest: encoding entry: %v", err)
+	}
+	got.WriteByte('\n')
+
+	if os.Getenv(UpdateGoldenEnv) != "" {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("httplogtest: writing golden file: %v", err)
+		}
+		if err := os.WriteFile(path, got.Bytes(), 0o644); err != nil {
+			t.Fatalf("httplogtest: writing golden file: %v", err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("httplogtest: reading golden file (set %s=1 to write it): %v", UpdateGoldenEnv, err)
+	}
+	if !bytes.Equal(got.Bytes(), want) {
+		t.Errorf("httplogtest: entry doesn't match golden file %s (set %s=1 to update it)\ngot:\n%s\nwant:\n%s",
+			path, UpdateGoldenEnv, got.Bytes(), want)
+	}
+}
+
+// normalizeEntry returns a copy of the entry with the time, the duration and the
+// values of the volatile fields normalized to zero values.
+func normalizeEntry(entry *httplog.Entry) *httplog.Entry {
+	e := *entry
+	e.Time = time.Time{}
+	e.Duration = 0
+	e.Message = strings.ReplaceAll(e.Message, "("+entry.Duration.String()+")", "(0s)")
+
+	keys := map[string]bool{}
+	if e.Schema != nil {
+		schema := reflect.ValueOf(e.Schema).Elem()
+		for _, name := range volatileFields {
+			if key := schema.FieldByName(name).String(); key != "" {
+				keys[key] = true
+			}
+		}
+	}
+
+	e.KeysAndValues = make([]any, len(entry.KeysAndValues))
+	copy(e.KeysAndValues, entry.KeysAndValues)
+	for i := 0; i+1 < len(e.KeysAndValues); i += 2 {
+		if key, ok := e.KeysAndValues[i].(string); ok && keys[key] {
+			e.KeysAndValues[i+1] = zeroValue(e.KeysAndValues[i+1])
+		}
+	}
+	return &e
+}
+
+// zeroValue returns the zero value of v, or of each value of a map, e.g. of
+// Schema.Timings.
+func zeroValue(v any) any {
+	if m, ok := v.(map[string]any); ok {
+		zero := make(map[string]any, len(m))
+		for k, mv := range m {
+			zero[k] = zeroValue(mv)
+		}
+		return zero
+	}
+	if v == nil {
+		return nil
+	}
+	return reflect.Zero(reflect.TypeOf(v)).Interface()
+}
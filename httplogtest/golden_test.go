@@ -0,0 +1,70 @@
+package httplogtest_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	httplog "github.com/rickliujh/chi-httplogr/v3"
+	"github.com/rickliujh/chi-httplogr/v3/httplogtest"
+)
+
+// serveEntry serves a request by the middleware and returns its request log entry.
+func serveEntry(t *testing.T, status int) *httplog.Entry {
+	t.Helper()
+
+	var entry *httplog.Entry
+	rec := httplogtest.NewRecorder()
+	handler := httplog.RequestLogger(rec.Logger(), &httplog.Options{
+		Levels:       &httplog.Levels{Warn: 1, Info: 1, Debug: 1},
+		LogRequestID: true,
+		AfterEmit:    func(e *httplog.Entry) { entry = e },
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		fmt.Fprint(w, "ok")
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/users/1?debug=1", nil)
+	req.RemoteAddr = "203.0.113.7:4321"
+	req.Header.Set("X-Request-Id", "request-1")
+	req.Header.Set("User-Agent", "golden-test")
+	rec.RoundTrip(handler, req)
+	return entry
+}
+
+func TestGolden(t *testing.T) {
+	// The durations differ between the runs, but are normalized.
+	httplogtest.Golden(t, serveEntry(t, http.StatusOK), "testdata/entry.json")
+	httplogtest.Golden(t, serveEntry(t, http.StatusOK), "testdata/entry.json")
+}
+
+func TestGoldenMismatch(t *testing.T) {
+	tb := &recordingTB{TB: t}
+	httplogtest.Golden(tb, serveEntry(t, http.StatusCreated), "testdata/entry.json")
+	if !tb.failed {
+		t.Error("Golden didn't report the changed status")
+	}
+}
+
+func TestGoldenUpdate(t *testing.T) {
+	t.Setenv(httplogtest.UpdateGoldenEnv, "1")
+	path := filepath.Join(t.TempDir(), "testdata", "entry.json")
+	httplogtest.Golden(t, serveEntry(t, http.StatusCreated), path)
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("golden file wasn't written: %v", err)
+	}
+
+	t.Setenv(httplogtest.UpdateGoldenEnv, "")
+	httplogtest.Golden(t, serveEntry(t, http.StatusCreated), path)
+}
+
+// recordingTB records the failures instead of failing the test.
+type recordingTB struct {
+	testing.TB
+	failed bool
+}
+
+func (tb *recordingTB) Errorf(format string, args ...any) { tb.failed = true }
@@ -0,0 +1,153 @@
+// Package httplogtest provides utilities for testing the request logs
+// recorded by the httplog middleware.
+//
+// The package is meant to be imported by tests only: Golden, RunBenchmarks and
+// CheckAllocs take testing types, so importing it links the testing package
+// into the binary.
+package httplogtest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+
+	"github.com/go-logr/logr"
+)
+
+// Entry is a log entry recorded by the Recorder.
+type Entry struct {
+	Level   int   // V-level of the entry; errors are recorded with level 0
+	IsError bool  // Whether the entry was logged with logger.Error
+	Error   error // Error passed to logger.Error, if any
+	Message string
+	KVs     []any // Key-value pairs, including the logger's values
+}
+
+// Value returns the value of the last occurrence of the key in the entry.
+func (e Entry) Value(key string) (any, bool) {
+	for i := len(e.KVs) - 2; i >= 0; i -= 2 {
+		if k, ok := e.KVs[i].(string); ok && k == key {
+			return e.KVs[i+1], true
+		}
+	}
+	return nil, false
+}
+
+// HasKV reports whether the entry has the key with a deeply equal value, e.g.:
+//
+//	rec.LastEntry().HasKV("http.response.status_code", 500)
+func (e Entry) HasKV(key string, value any) bool {
+	v, ok := e.Value(key)
+	return ok && reflect.DeepEqual(v, value)
+}
+
+// Recorder is an in-memory logr.LogSink, which records all log entries so that
+// tests can assert on the request logs.
+type Recorder struct {
+	store  *store
+	values []any
+}
+
+type store struct {
+	mu      sync.Mutex
+	entries []Entry
+}
+
+var _ logr.LogSink = &Recorder{}
+
+// NewRecorder returns a new empty Recorder.
+func NewRecorder() *Recorder {
+	return &Recorder{store: &store{}}
+}
+
+// Logger returns a logger recording entries to the Recorder.
+func (r *Recorder) Logger() logr.Logger {
+	return logr.New(r)
+}
+
+// Entries returns all recorded entries.
+func (r *Recorder) Entries() []Entry {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	return append([]Entry(nil), r.store.entries...)
+}
+
+// LastEntry returns the last recorded entry, or an empty Entry if none was recorded.
+func (r *Recorder) LastEntry() Entry {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if len(r.store.entries) == 0 {
+		return Entry{}
+	}
+	return r.store.entries[len(r.store.entries)-1]
+}
+
+// Reset removes all recorded entries.
+func (r *Recorder) Reset() {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	r.store.entries = nil
+}
+
+// RoundTrip serves the request by the handler, which is expected to be wrapped
+// by the httplog middleware logging to the Recorder, and returns the response
+// along with the request log entry recorded during the round trip.
+//
+// The entry is empty if the request wasn't logged, e.g. due to Options.Skip.
+func (r *Recorder) RoundTrip(handler http.Handler, req *http.Request) (*http.Response, Entry) {
+	n := len(r.Entries())
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	var entry Entry
+	if entries := r.Entries(); len(entries) > n {
+		entry = entries[len(entries)-1]
+	}
+	return w.Result(), entry
+}
+
+// Init implements logr.LogSink.
+func (r *Recorder) Init(info logr.RuntimeInfo) {}
+
+// Enabled implements logr.LogSink. All levels are enabled.
+func (r *Recorder) Enabled(level int) bool {
+	return true
+}
+
+// Info implements logr.LogSink.
+func (r *Recorder) Info(level int, msg string, keysAndValues ...any) {
+	r.record(Entry{Level: level, Message: msg, KVs: r.kvs(keysAndValues)})
+}
+
+// Error implements logr.LogSink.
+func (r *Recorder) Error(err error, msg string, keysAndValues ...any) {
+	r.record(Entry{IsError: true, Error: err, Message: msg, KVs: r.kvs(keysAndValues)})
+}
+
+// WithValues implements logr.LogSink.
+func (r *Recorder) WithValues(keysAndValues ...any) logr.LogSink {
+	return &Recorder{store: r.store, values: r.kvs(keysAndValues)}
+}
+
+// WithName implements logr.LogSink. Names are ignored.
+func (r *Recorder) WithName(name string) logr.LogSink {
+	return r
+}
+
+func (r *Recorder) kvs(keysAndValues []any) []any {
+	kvs := make([]any, 0, len(r.values)+len(keysAndValues))
+	kvs = append(kvs, r.values...)
+	return append(kvs, keysAndValues...)
+}
+
+func (r *Recorder) record(e Entry) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	r.store.entries = append(r.store.entries, e)
+}
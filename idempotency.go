@@ -0,0 +1,38 @@
+package httplog
+
+import (
+	"net/http"
+	"time"
+)
+
+var defaultIdempotencyHeaders = []string{"Idempotency-Key", "X-Idempotency-Key"}
+
+// defaultDuplicateWindow is the default Options.DuplicateWindow.
+const defaultDuplicateWindow = 5 * time.Minute
+
+// idempotencyKey returns the idempotency key of the request, or an empty string.
+func idempotencyKey(r *http.Request, o *Options) string {
+	headers := o.IdempotencyHeaders
+	if len(headers) == 0 {
+		headers = defaultIdempotencyHeaders
+	}
+	for _, h := range headers {
+		if key := r.Header.Get(h); key != "" {
+			return key
+		}
+	}
+	return ""
+}
+
+// newDuplicateCache returns the cache of recently seen idempotency keys, or nil
+// if duplicate detection is disabled.
+func newDuplicateCache(o *Options) *lruCache {
+	if o.DuplicateCacheSize <= 0 {
+		return nil
+	}
+	window := o.DuplicateWindow
+	if window <= 0 {
+		window = defaultDuplicateWindow
+	}
+	return newLRUCache(o.DuplicateCacheSize, window)
+}
@@ -0,0 +1,93 @@
+package httplog
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"time"
+)
+
+// UUIDv7 returns a new time-ordered UUID version 7 (RFC 9562), e.g.
+// "0190163d-8694-739b-aea5-966c26f8ad91". It can be used as Options.IDGenerator.
+func UUIDv7() string {
+	var b [16]byte
+	rand.Read(b[6:])
+	ms := uint64(time.Now().UnixMilli())
+	b[0], b[1], b[2], b[3], b[4], b[5] = byte(ms>>40), byte(ms>>32), byte(ms>>24), byte(ms>>16), byte(ms>>8), byte(ms)
+	b[6] = b[6]&0x0f | 0x70 // version 7
+	b[8] = b[8]&0x3f | 0x80 // variant RFC 9562
+
+	var s [36]byte
+	hex.Encode(s[0:8], b[0:4])
+	s[8] = '-'
+	hex.Encode(s[9:13], b[4:6])
+	s[13] = '-'
+	hex.Encode(s[14:18], b[6:8])
+	s[18] = '-'
+	hex.Encode(s[19:23], b[8:10])
+	s[23] = '-'
+	hex.Encode(s[24:], b[10:])
+	return string(s[:])
+}
+
+// debugToken returns a short random token identifying the request, e.g.
+// "9f86d081884c7d65", see Options.EchoRequestIDHeader.
+func debugToken() string {
+	var b [8]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// crockford is the Crockford's Base32 alphabet of ULIDs.
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// ULID returns a new lexicographically sortable ULID, e.g.
+// "01J0B3V1M4E6Q9Z8X7W5T2R1P0". It can be used as Options.IDGenerator.
+func ULID() string {
+	var b [16]byte
+	rand.Read(b[6:])
+	ms := uint64(time.Now().UnixMilli())
+	b[0], b[1], b[2], b[3], b[4], b[5] = byte(ms>>40), byte(ms>>32), byte(ms>>24), byte(ms>>16), byte(ms>>8), byte(ms)
+
+	// 128 bits are encoded as 26 characters of 5 bits, from the most
+	// significant bits, with 2 leading zero bits.
+	hi, lo := binary.BigEndian.Uint64(b[:8]), binary.BigEndian.Uint64(b[8:])
+	var s [26]byte
+	for i := 25; i >= 0; i-- {
+		s[i] = crockford[lo&0x1f]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(s[:])
+}
+
+// ksuidEpoch is the epoch of KSUID timestamps, i.e. 2014-05-13T16:53:20Z.
+const ksuidEpoch = 1400000000
+
+// base62 is the alphabet of KSUIDs.
+const base62 = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// KSUID returns a new K-sortable unique ID, e.g.
+// "2i0Jh4yZ0TGmCWrCr7B0Vvw5K8M". It can be used as Options.IDGenerator.
+func KSUID() string {
+	var b [20]byte
+	binary.BigEndian.PutUint32(b[:4], uint32(time.Now().Unix()-ksuidEpoch))
+	rand.Read(b[4:])
+
+	// The 160 bits are encoded as 27 base62 digits by the long division of the
+	// big-endian 32-bit words.
+	var words [5]uint32
+	for i := range words {
+		words[i] = binary.BigEndian.Uint32(b[i*4:])
+	}
+	var s [27]byte
+	for i := len(s) - 1; i >= 0; i-- {
+		var rem uint64
+		for j := range words {
+			v := rem<<32 | uint64(words[j])
+			words[j], rem = uint32(v/62), v%62
+		}
+		s[i] = base62[rem]
+	}
+	return string(s[:])
+}
@@ -0,0 +1,88 @@
+package httplog_test
+
+import (
+	"math/big"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	httplog "github.com/rickliujh/chi-httplogr/v3"
+)
+
+func TestUUIDv7(t *testing.T) {
+	format := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	before := time.Now().UnixMilli()
+	id := httplog.UUIDv7()
+	after := time.Now().UnixMilli()
+
+	if !format.MatchString(id) {
+		t.Fatalf("%q isn't a UUIDv7 with the RFC 9562 variant", id)
+	}
+	ms, _ := new(big.Int).SetString(strings.ReplaceAll(id[:13], "-", ""), 16)
+	if ms.Int64() < before || ms.Int64() > after {
+		t.Errorf("%q: got timestamp %d, want within [%d, %d]", id, ms.Int64(), before, after)
+	}
+	if httplog.UUIDv7() == id {
+		t.Errorf("%q generated twice", id)
+	}
+}
+
+func TestULID(t *testing.T) {
+	const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+	before := time.Now().UnixMilli()
+	id := httplog.ULID()
+	after := time.Now().UnixMilli()
+
+	if len(id) != 26 || strings.Trim(id, crockford) != "" || id[0] > '7' {
+		t.Fatalf("%q isn't a 128-bit Crockford Base32 ULID", id)
+	}
+	// The first 10 characters encode the 48-bit timestamp.
+	var ms int64
+	for _, c := range id[:10] {
+		ms = ms<<5 | int64(strings.IndexRune(crockford, c))
+	}
+	if ms < before || ms > after {
+		t.Errorf("%q: got timestamp %d, want within [%d, %d]", id, ms, before, after)
+	}
+	if httplog.ULID() == id {
+		t.Errorf("%q generated twice", id)
+	}
+}
+
+func TestKSUID(t *testing.T) {
+	const base62 = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+	before := time.Now().Unix()
+	id := httplog.KSUID()
+	after := time.Now().Unix()
+
+	if len(id) != 27 || strings.Trim(id, base62) != "" {
+		t.Fatalf("%q isn't a 27-character base62 KSUID", id)
+	}
+	n := new(big.Int)
+	for _, c := range id {
+		n.Mul(n, big.NewInt(62))
+		n.Add(n, big.NewInt(int64(strings.IndexRune(base62, c))))
+	}
+	if n.BitLen() > 160 {
+		t.Fatalf("%q: got %d bits, want at most 160", id, n.BitLen())
+	}
+	// The first 32 bits are the seconds since the KSUID epoch 1400000000.
+	sec := new(big.Int).Rsh(n, 128).Int64() + 1400000000
+	if sec < before || sec > after {
+		t.Errorf("%q: got timestamp %d, want within [%d, %d]", id, sec, before, after)
+	}
+	if httplog.KSUID() == id {
+		t.Errorf("%q generated twice", id)
+	}
+}
+
+func TestIDsSortByTime(t *testing.T) {
+	for name, gen := range map[string]func() string{"UUIDv7": httplog.UUIDv7, "ULID": httplog.ULID} {
+		first := gen()
+		time.Sleep(2 * time.Millisecond)
+		if second := gen(); second <= first {
+			t.Errorf("%s: %q generated after %q sorts before it", name, second, first)
+		}
+	}
+}
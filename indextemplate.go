@@ -0,0 +1,221 @@
+package httplog
+
+import (
+	"encoding/json"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// fieldTypes are the Elasticsearch field types of the schema fields logged as
+// other values than strings. The other fields are mapped as keywords.
+var fieldTypes = map[string]string{
+	"Timestamp":       "date",
+	"Message":         "text",
+	"ErrorMessage":    "text",
+	"ErrorDetail":     "text",
+	"ErrorStackTrace": "text",
+	"RequestBody":     "text",
+	"ResponseBody":    "text",
+
+	"SourceLine":                "long",
+	"RequestRemotePort":         "long",
+	"RequestBytes":              "long",
+	"RequestMessages":           "long",
+	"RequestBytesUnread":        "long",
+	"RequestHeaderBytes":        "long",
+	"RequestHeaderCount":        "long",
+	"RequestSequence":           "long",
+	"RepeatCount":               "long",
+	"ErrorBurstCount":           "long",
+	"ResponseStatus":            "long",
+	"ResponseBytes":             "long",
+	"ResponseHeadContentLength": "long",
+	"ResponseMessages":          "long",
+	"WebSocketCloseCode":        "long",
+	"ResponseHeaderBytes":       "long",
+	"ResponseWireBytes":         "long",
+	"UpstreamStatus":            "long",
+	"UpstreamRetries":           "long",
+
+	"AbortElapsed":             "float",
+	"AbortNoticed":             "float",
+	"AbortProgress":            "float",
+	"ErrorBurstWindow":         "float",
+	"SamplingRate":             "float",
+	"RequestDeadline":          "float",
+	"RequestDeadlineRemaining": "float",
+	"RequestQueueTime":         "float",
+	"RejectQueueWait":          "float",
+	"RequestContinueWait":      "float",
+	"RequestReadDeadline":      "float",
+	"ResponseDuration":         "float",
+	"ResponseStalled":          "float",
+	"ResponseWriteDeadline":    "float",
+	"ResponseCompressionRatio": "float",
+	"RequestBytesPerSec":       "float",
+	"ResponseBytesPerSec":      "float",
+	"UpstreamDuration":         "float",
+
+	"AbortHeadersSent":         "boolean",
+	"SLOViolated":              "boolean",
+	"RequestOversized":         "boolean",
+	"RequestHeadersLarge":      "boolean",
+	"ContextKVsTruncated":      "boolean",
+	"ClientIsBot":              "boolean",
+	"BodyCaptureSkipped":       "boolean",
+	"RequestBodyValid":         "boolean",
+	"ClientMobile":             "boolean",
+	"ErrorBurst":               "boolean",
+	"SamplingSampled":          "boolean",
+	"RequestDuplicate":         "boolean",
+	"RequestContinueSent":      "boolean",
+	"RequestFullDuplex":        "boolean",
+	"ResponseNotModified":      "boolean",
+	"ResponseRangeSatisfiable": "boolean",
+	"Partial":                  "boolean",
+
+	"Baggage":                 "object",
+	"RequestQueryParams":      "object",
+	"RequestHeaders":          "object",
+	"RequestCookies":          "object",
+	"RequestTrailers":         "object",
+	"RequestUserAgentDetails": "object",
+	"Labels":                  "object",
+	"RequestConditional":      "object",
+	"UserClaims":              "object",
+	"ResponseHeaders":         "object",
+	"Timings":                 "object",
+	"MiddlewareTimings":       "object",
+	"Counters":                "object",
+	"Experiments":             "object",
+	"ResponseRateLimit":       "object",
+	"UpstreamHeaderDiff":      "object",
+	"RequestBodyWindows":      "object",
+	"ResponseBodyWindows":     "object",
+}
+
+// IndexTemplate generates an Elasticsearch (or OpenSearch) composable index
+// template with the mappings of the schema fields, so that the log store can be
+// provisioned consistently with the schema, e.g.:
+//
+//	template, err := httplog.IndexTemplate(httplog.SchemaECS, "logs-http-*")
+//	// PUT _index_template/logs-http with the template
+//
+// The field types are inferred from the values logged by the request logger,
+// e.g. long for Schema.ResponseStatus, and the custom fields of Schema.Extra are
+// mapped as keywords. Fields grouped by Schema.GroupDelimiter are mapped as
+// nested objects.
+//
+// If a field is also the parent of other fields, e.g. code.function and
+// code.function.name of SchemaOTEL, the fields can't be mapped as nested
+// objects. The fields are then mapped by their dotted paths with "subobjects":
+// false (Elasticsearch 8.3 or later), and the object fields as flattened.
+func IndexTemplate(s *Schema, indexPatterns ...string) ([]byte, error) {
+	fields := schemaFieldTypes(s)
+
+	mappings := map[string]any{}
+	if properties, ok := nestedMapping(fields); ok {
+		mappings["properties"] = properties
+	} else {
+		properties := map[string]any{}
+		for _, f := range fields {
+			typ := f.typ
+			if typ == "object" {
+				typ = "flattened"
+			}
+			if _, ok := properties[strings.Join(f.path, ".")]; !ok {
+				properties[strings.Join(f.path, ".")] = map[string]any{"type": typ}
+			}
+		}
+		mappings["subobjects"] = false
+		mappings["properties"] = properties
+	}
+
+	return json.MarshalIndent(map[string]any{
+		"index_patterns": indexPatterns,
+		"template": map[string]any{
+			"mappings": mappings,
+		},
+	}, "", "  ")
+}
+
+// fieldMapping is the path and the Elasticsearch type of a schema field.
+type fieldMapping struct {
+	path []string
+	typ  string
+}
+
+// schemaFieldTypes returns the fields of the schema with their paths, as split
+// by the delimiters of the schema, and their types.
+func schemaFieldTypes(s *Schema) []fieldMapping {
+	var fields []fieldMapping
+	add := func(name, typ string) {
+		path := strings.Split(name, ".")
+		_, overridden := s.FieldDelimiters[name]
+		if delimiter := s.FieldDelimiter(name); delimiter != "" {
+			path = strings.Split(name, delimiter)
+		} else if overridden || slices.Contains(s.RootFields, name) {
+			path = []string{name}
+		}
+		fields = append(fields, fieldMapping{path: path, typ: typ})
+	}
+
+	v := reflect.ValueOf(s).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).Type.Kind() != reflect.String || t.Field(i).Name == "GroupDelimiter" {
+			continue
+		}
+		name := v.Field(i).String()
+		if name == "" {
+			continue
+		}
+		typ, ok := fieldTypes[t.Field(i).Name]
+		if !ok {
+			typ = "keyword"
+		}
+		add(name, typ)
+	}
+	for _, name := range s.Extra {
+		if name != "" {
+			add(name, "keyword")
+		}
+	}
+	return fields
+}
+
+// nestedMapping returns the properties of the fields mapped as nested objects,
+// or false if a field is also the parent of other fields. The first mapping of
+// duplicate fields wins.
+func nestedMapping(fields []fieldMapping) (map[string]any, bool) {
+	properties := map[string]any{}
+	for _, f := range fields {
+		props := properties
+		for _, key := range f.path[:len(f.path)-1] {
+			parent, _ := props[key].(map[string]any)
+			if parent == nil {
+				parent = map[string]any{"properties": map[string]any{}}
+				props[key] = parent
+			}
+			child, ok := parent["properties"].(map[string]any)
+			if !ok {
+				return nil, false // the parent is a leaf field
+			}
+			props = child
+		}
+		key := f.path[len(f.path)-1]
+		if existing, ok := props[key].(map[string]any); ok {
+			if _, isObject := existing["properties"]; isObject && f.typ != "object" {
+				return nil, false // the leaf field is a parent
+			}
+			continue
+		}
+		if f.typ == "object" {
+			props[key] = map[string]any{"type": f.typ, "properties": map[string]any{}}
+		} else {
+			props[key] = map[string]any{"type": f.typ}
+		}
+	}
+	return properties, true
+}
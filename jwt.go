@@ -0,0 +1,85 @@
+package httplog
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// JWTClaims returns the selected claims of the JWT bearer token found in the
+// Authorization header of the given request. The token is parsed, but NOT
+// verified, so the claims must not be trusted for anything but logging.
+//
+// Claims that are missing in the token are omitted. It returns nil if the
+// request carries no parseable bearer token. The token itself is never returned.
+func JWTClaims(req *http.Request, claims ...string) map[string]any {
+	if len(claims) == 0 {
+		return nil
+	}
+
+	auth := req.Header.Get("Authorization")
+	if len(auth) < 7 || !strings.EqualFold(auth[:7], "Bearer ") {
+		return nil
+	}
+
+	parts := strings.Split(strings.TrimSpace(auth[7:]), ".")
+	if len(parts) != 3 {
+		return nil
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return nil
+	}
+
+	var all map[string]any
+	if err := json.Unmarshal(payload, &all); err != nil {
+		return nil
+	}
+
+	selected := make(map[string]any, len(claims))
+	for _, claim := range claims {
+		if v, ok := all[claim]; ok {
+			selected[claim] = v
+		}
+	}
+	if len(selected) == 0 {
+		return nil
+	}
+	return selected
+}
+
+// claimKVs returns the attributes of the JWT claims: the claims with a schema
+// field, i.e. "sub" (Schema.UserID), "preferred_username" (Schema.UserName),
+// "iss" (Schema.UserIssuer), "aud" (Schema.UserAudience) and "scope"
+// (Schema.ClientScope), are logged as the field unless it's omitted from the
+// schema or the user is already set by Options.IdentityFunc, and the other
+// claims as Schema.UserClaims object.
+func claimKVs(claims map[string]any, userID, username string, s *Schema) []any {
+	var kvs []any
+	rest := make(map[string]any, len(claims))
+	for claim, v := range claims {
+		rest[claim] = v
+	}
+	fields := []struct {
+		claim, key string
+		set        bool
+	}{
+		{"sub", s.UserID, userID != ""},
+		{"preferred_username", s.UserName, username != ""},
+		{"iss", s.UserIssuer, false},
+		{"aud", s.UserAudience, false},
+		{"scope", s.ClientScope, false},
+	}
+	for _, f := range fields {
+		if v, ok := rest[f.claim]; ok && f.key != "" && !f.set {
+			kvs = append(kvs, f.key, v)
+			delete(rest, f.claim)
+		}
+	}
+	if len(rest) > 0 {
+		kvs = append(kvs, s.UserClaims, rest)
+	}
+	return kvs
+}
@@ -0,0 +1,38 @@
+package httplog_test
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	httplog "github.com/rickliujh/chi-httplogr/v3"
+	"github.com/rickliujh/chi-httplogr/v3/httplogtest"
+)
+
+func TestJWTClaimFields(t *testing.T) {
+	rec := httplogtest.NewRecorder()
+	handler := httplog.RequestLogger(rec.Logger(), &httplog.Options{
+		Schema:       httplog.SchemaECS,
+		Levels:       &httplog.Levels{Warn: 1, Info: 1, Debug: 1},
+		LogJWTClaims: []string{"sub", "preferred_username", "iss", "aud", "scope", "azp"},
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	payload := base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"u1","preferred_username":"alice","iss":"https://idp","aud":["api"],"scope":"read write","azp":"web"}`))
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer e30."+payload+".sig")
+	_, entry := rec.RoundTrip(handler, req)
+
+	if !entry.HasKV(httplog.SchemaECS.UserID, "u1") || !entry.HasKV(httplog.SchemaECS.UserName, "alice") {
+		t.Errorf("entry is missing the user ID and name: %v", entry.KVs)
+	}
+	if !entry.HasKV(httplog.SchemaECS.UserIssuer, "https://idp") || !entry.HasKV(httplog.SchemaECS.UserAudience, []any{"api"}) ||
+		!entry.HasKV(httplog.SchemaECS.ClientScope, "read write") {
+		t.Errorf("entry is missing the issuer, audience and scope: %v", entry.KVs)
+	}
+	claims, _ := entry.Value(httplog.SchemaECS.UserClaims)
+	if want := map[string]any{"azp": "web"}; !reflect.DeepEqual(claims, want) {
+		t.Errorf("got claims %v, want %v", claims, want)
+	}
+}
@@ -0,0 +1,42 @@
+package httplog
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// statusClass returns the class of the HTTP status code, e.g. "2xx" or "5xx".
+func statusClass(statusCode int) string {
+	if statusCode < 100 || statusCode > 999 {
+		return "unknown"
+	}
+	return strconv.Itoa(statusCode/100) + "xx"
+}
+
+// eventOutcome returns the ECS event outcome of the request: "failure" for HTTP
+// 5xx responses, panics and errors set by SetError, "unknown" if the response
+// wasn't completed, e.g. due to a client abort, or "success". Client errors
+// (4xx) are successfully handled requests from the server's point of view.
+func eventOutcome(statusCode int, failed, incomplete bool) string {
+	switch {
+	case statusCode >= 500 || failed:
+		return "failure"
+	case incomplete:
+		return "unknown"
+	}
+	return "success"
+}
+
+// labels returns the low-cardinality attributes of the request, which are safe
+// to be used as log stream labels (e.g. Loki), unlike the high-cardinality fields
+// such as URL, client IP or request ID.
+func labels(r *http.Request, statusCode int, o *Options) map[string]string {
+	m := make(map[string]string, len(o.Labels)+3)
+	for k, v := range o.Labels {
+		m[k] = v
+	}
+	m["method"] = r.Method
+	m["route"] = MetricsLabel(r, o)
+	m["status_class"] = statusClass(statusCode)
+	return m
+}
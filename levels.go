@@ -0,0 +1,34 @@
+package httplog
+
+// Levels are the V-levels of the request logs by severity, compared to the
+// verbosity of the logger (logr.Logger.GetV): a request log is written unless
+// the logger's V-level is greater than the level of its severity, see
+// Options.Levels. The request logs are written at the V-level of their severity
+// (negative levels as V(0)), so that the log sink can filter them as well.
+type Levels struct {
+	Error int // HTTP 5xx responses and panics
+	Warn  int // HTTP 4xx responses (except for 429)
+	Info  int // other responses
+	Debug int // OPTIONS requests, see Options.OptionsPolicy
+}
+
+// DefaultLevels are the default V-levels of the request logs.
+var DefaultLevels = Levels{Error: 0, Warn: -1, Info: -2, Debug: -3}
+
+// v returns the V-level of the request log of the given severity: 0 error,
+// -1 warn, -2 info, -3 debug.
+func (l *Levels) v(severity int) int {
+	if l == nil {
+		l = &DefaultLevels
+	}
+	switch {
+	case severity >= 0:
+		return l.Error
+	case severity == -1:
+		return l.Warn
+	case severity == -2:
+		return l.Info
+	default:
+		return l.Debug
+	}
+}
@@ -0,0 +1,41 @@
+package httplog_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	httplog "github.com/rickliujh/chi-httplogr/v3"
+	"github.com/rickliujh/chi-httplogr/v3/httplogtest"
+)
+
+func TestLevels(t *testing.T) {
+	tests := []struct {
+		name      string
+		levels    *httplog.Levels
+		wantLog   bool
+		wantLevel int
+	}{
+		{name: "Default", levels: nil, wantLog: false},
+		{name: "AllZero", levels: &httplog.Levels{}, wantLog: true, wantLevel: 0},
+		{name: "Zapr", levels: &httplog.Levels{Error: 0, Warn: 1, Info: 2, Debug: 3}, wantLog: true, wantLevel: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httplogtest.NewRecorder()
+			handler := httplog.RequestLogger(rec.Logger(), &httplog.Options{
+				Levels: tt.levels,
+			})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+			entries := rec.Entries()
+			if got := len(entries) > 0; got != tt.wantLog {
+				t.Fatalf("logged: %v, want %v", got, tt.wantLog)
+			}
+			if tt.wantLog && entries[0].Level != tt.wantLevel {
+				t.Errorf("got level %d, want %d", entries[0].Level, tt.wantLevel)
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package httplog
+
+import (
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// preferredLocale returns the most preferred locale of the given Accept-Language
+// header value, e.g. "de-CH" for "fr;q=0.8, de-CH, en;q=0.9", or an empty string.
+func preferredLocale(acceptLanguage string) string {
+	var locale string
+	var best float64
+	for _, lang := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(lang, ";")
+		tag = strings.TrimSpace(tag)
+		if tag == "" || tag == "*" {
+			continue
+		}
+
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			var err error
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		if q > best {
+			locale, best = tag, q
+		}
+	}
+	return locale
+}
+
+// requestEncodingKVs returns the charset parameter of the request Content-Type
+// header and the Content-Language header, see Options.LogRequestEncoding.
+func requestEncodingKVs(header http.Header, s *Schema) []any {
+	var kvs []any
+	if _, params, err := mime.ParseMediaType(header.Get("Content-Type")); err == nil && params["charset"] != "" {
+		kvs = append(kvs, s.RequestCharset, strings.ToLower(params["charset"]))
+	}
+	if lang := strings.TrimSpace(header.Get("Content-Language")); lang != "" {
+		kvs = append(kvs, s.RequestContentLanguage, lang)
+	}
+	return kvs
+}
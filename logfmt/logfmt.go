@@ -0,0 +1,232 @@
+// Package logfmt provides a logr.LogSink rendering each log entry as a single
+// logfmt line (key=value pairs), for environments without structured log sinks,
+// so that plain-text log consumers still get parseable request logs:
+//
+//	logger := logfmt.New(os.Stdout, logfmt.Options{Schema: httplog.SchemaECS})
+//
+//	r.Use(httplog.RequestLogger(logger, &httplog.Options{
+//		Schema: httplog.SchemaECS,
+//	}))
+//
+// The "time", "level" and "msg" keys come first, followed by the keys of the
+// schema fields in the order of the Schema struct, and by the other keys in
+// alphabetical order. Nested objects are flattened with dots.
+package logfmt
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/go-logr/logr"
+	httplog "github.com/rickliujh/chi-httplogr/v3"
+)
+
+// Options configures the logfmt sink.
+type Options struct {
+	// Schema orders the keys of the request log fields, see the package doc.
+	// Use the schema of the request logger.
+	//
+	// If not provided, the keys are ordered alphabetically.
+	Schema *httplog.Schema
+
+	// TimeFormat is the layout of the "time" key.
+	//
+	// If not provided, the default is time.RFC3339Nano.
+	TimeFormat string
+
+	// Verbosity is the maximum V-level of the written entries, like the
+	// verbosity of funcr: entries logged with logger.V(n) for n > Verbosity are
+	// discarded.
+	//
+	// If not provided, only V(0) entries and errors are written.
+	Verbosity int
+}
+
+// New returns a logger writing logfmt lines to w, one per Write call.
+func New(w io.Writer, opts Options) logr.Logger {
+	if opts.TimeFormat == "" {
+		opts.TimeFormat = time.RFC3339Nano
+	}
+
+	return logr.New(&sink{
+		w:     &lockedWriter{w: w},
+		opts:  opts,
+		order: schemaOrder(opts.Schema),
+	})
+}
+
+type lockedWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (lw *lockedWriter) Write(p []byte) (int, error) {
+	lw.mu.Lock()
+	defer lw.mu.Unlock()
+	return lw.w.Write(p)
+}
+
+type sink struct {
+	w      *lockedWriter
+	opts   Options
+	order  map[string]int
+	name   string
+	values []any
+}
+
+var _ logr.LogSink = &sink{}
+
+func (s *sink) Init(info logr.RuntimeInfo) {}
+
+func (s *sink) Enabled(level int) bool {
+	return level <= s.opts.Verbosity
+}
+
+func (s *sink) Info(level int, msg string, keysAndValues ...any) {
+	lvl := "info"
+	if level > 0 {
+		lvl = "debug"
+	}
+	s.write(lvl, msg, nil, keysAndValues)
+}
+
+func (s *sink) Error(err error, msg string, keysAndValues ...any) {
+	s.write("error", msg, err, keysAndValues)
+}
+
+func (s *sink) WithValues(keysAndValues ...any) logr.LogSink {
+	values := append(append([]any(nil), s.values...), keysAndValues...)
+	return &sink{w: s.w, opts: s.opts, order: s.order, name: s.name, values: values}
+}
+
+func (s *sink) WithName(name string) logr.LogSink {
+	if s.name != "" {
+		name = s.name + "." + name
+	}
+	return &sink{w: s.w, opts: s.opts, order: s.order, name: name, values: s.values}
+}
+
+func (s *sink) write(level, msg string, err error, keysAndValues []any) {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "time=%s level=%s", time.Now().UTC().Format(s.opts.TimeFormat), level)
+	if s.name != "" {
+		fmt.Fprintf(&b, " logger=%s", value(s.name))
+	}
+	fmt.Fprintf(&b, " msg=%s", value(msg))
+	if err != nil {
+		fmt.Fprintf(&b, " error=%s", value(err.Error()))
+	}
+
+	fields := map[string]string{}
+	for _, kvs := range [][]any{s.values, keysAndValues} {
+		for i := 0; i+1 < len(kvs); i += 2 {
+			if key, ok := kvs[i].(string); ok && key != "" {
+				flatten(fields, key, kvs[i+1])
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		oi, iok := s.order[keys[i]]
+		oj, jok := s.order[keys[j]]
+		switch {
+		case iok && jok:
+			return oi < oj
+		case iok != jok:
+			return iok
+		}
+		return keys[i] < keys[j]
+	})
+	for _, key := range keys {
+		fmt.Fprintf(&b, " %s=%s", key, fields[key])
+	}
+	b.WriteString("\n")
+
+	s.w.Write([]byte(b.String()))
+}
+
+// schemaOrder returns the position of the keys of the schema fields in the
+// Schema struct, with the keys grouped by Schema.GroupDelimiter flattened with
+// dots, as logged.
+func schemaOrder(schema *httplog.Schema) map[string]int {
+	order := map[string]int{}
+	if schema == nil {
+		return order
+	}
+	v := reflect.ValueOf(schema).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).Type.Kind() != reflect.String || t.Field(i).Name == "GroupDelimiter" {
+			continue
+		}
+		key := v.Field(i).String()
+		if key == "" {
+			continue
+		}
+		if delimiter := schema.FieldDelimiter(key); delimiter != "" {
+			key = strings.ReplaceAll(key, delimiter, ".")
+		}
+		if _, ok := order[key]; !ok {
+			order[key] = len(order)
+		}
+	}
+	return order
+}
+
+// flatten adds the rendered value to the fields, flattening nested objects with
+// dots.
+func flatten(fields map[string]string, key string, v any) {
+	if m, ok := v.(map[string]any); ok {
+		for k, v := range m {
+			flatten(fields, key+"."+k, v)
+		}
+		return
+	}
+	fields[fieldKey(key)] = value(v)
+}
+
+// fieldKey returns a valid logfmt key, i.e. without spaces, '=' and '"'.
+func fieldKey(key string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) || r == '=' || r == '"' || !unicode.IsPrint(r) {
+			return '_'
+		}
+		return r
+	}, key)
+}
+
+// value renders the value, quoted if it's empty or contains spaces, '=', '"'
+// or non-printable characters.
+func value(v any) string {
+	var s string
+	switch v := v.(type) {
+	case string:
+		s = v
+	case error:
+		s = v.Error()
+	case time.Time:
+		s = v.Format(time.RFC3339Nano)
+	case fmt.Stringer:
+		s = v.String()
+	default:
+		s = fmt.Sprint(v)
+	}
+	if s == "" || strings.IndexFunc(s, func(r rune) bool {
+		return unicode.IsSpace(r) || r == '=' || r == '"' || !unicode.IsPrint(r)
+	}) >= 0 {
+		return strconv.Quote(s)
+	}
+	return s
+}
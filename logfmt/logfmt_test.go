@@ -0,0 +1,88 @@
+package logfmt_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	httplog "github.com/rickliujh/chi-httplogr/v3"
+	"github.com/rickliujh/chi-httplogr/v3/logfmt"
+)
+
+// fields returns the line without the time field.
+func fields(buf *bytes.Buffer) string {
+	line := strings.TrimSuffix(buf.String(), "\n")
+	_, rest, _ := strings.Cut(line, " ")
+	return rest
+}
+
+func TestValues(t *testing.T) {
+	tests := []struct {
+		name string
+		kvs  []any
+		want string
+	}{
+		{name: "Plain", kvs: []any{"k", "v", "n", 1}, want: "k=v n=1"},
+		{name: "Empty", kvs: []any{"k", ""}, want: `k=""`},
+		{name: "Space", kvs: []any{"k", "a b"}, want: `k="a b"`},
+		{name: "Quote", kvs: []any{"k", `a"b`}, want: `k="a\"b"`},
+		{name: "Equals", kvs: []any{"k", "a=b"}, want: `k="a=b"`},
+		{name: "Newline", kvs: []any{"k", "a\nb"}, want: `k="a\nb"`},
+		{name: "Error", kvs: []any{"k", errors.New("boom")}, want: "k=boom"},
+		{name: "InvalidKey", kvs: []any{`a b="c`, 1}, want: "a_b__c=1"},
+		{name: "Flattened", kvs: []any{"http", map[string]any{"request": map[string]any{"method": "GET"}}}, want: "http.request.method=GET"},
+		{name: "EmptyKey", kvs: []any{"", 1, 2, 3}, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logfmt.New(&buf, logfmt.Options{}).Info("msg", tt.kvs...)
+			want := strings.TrimSuffix("level=info msg=msg "+tt.want, " ")
+			if got := fields(&buf); got != want {
+				t.Errorf("got %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestKeyOrder(t *testing.T) {
+	var buf bytes.Buffer
+	logger := logfmt.New(&buf, logfmt.Options{Schema: httplog.SchemaECS})
+	logger.Error(errors.New("boom"), "GET /",
+		"z", 1, "a", 2,
+		httplog.SchemaECS.ResponseStatus, 500,
+		httplog.SchemaECS.RequestMethod, "GET",
+		httplog.SchemaECS.RequestURL, "http://example.com/",
+	)
+
+	want := "level=error msg=\"GET /\" error=boom url.full=http://example.com/ http.request.method=GET http.response.status_code=500 a=2 z=1"
+	if got := fields(&buf); got != want {
+		t.Errorf("got  %q\nwant %q", got, want)
+	}
+}
+
+func TestGroupedSchemaOrder(t *testing.T) {
+	var buf bytes.Buffer
+	s := httplog.SchemaGCP
+	logfmt.New(&buf, logfmt.Options{Schema: s}).Info("msg",
+		"z", 1,
+		"httpRequest", map[string]any{"status": 200, "requestMethod": "GET"},
+	)
+
+	want := "level=info msg=msg httpRequest.requestMethod=GET httpRequest.status=200 z=1"
+	if got := fields(&buf); got != want {
+		t.Errorf("got  %q\nwant %q", got, want)
+	}
+}
+
+func TestVerbosity(t *testing.T) {
+	var buf bytes.Buffer
+	logger := logfmt.New(&buf, logfmt.Options{Verbosity: 1}).WithName("http")
+	logger.V(1).Info("body")
+	logger.V(2).Info("debug")
+
+	if got := fields(&buf); got != "level=debug logger=http msg=body" {
+		t.Errorf("got %q", got)
+	}
+}
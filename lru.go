@@ -0,0 +1,128 @@
+package httplog
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// lruCache is a size-bounded set of recently seen keys, which expire after ttl.
+type lruCache struct {
+	mu    sync.Mutex
+	size  int
+	ttl   time.Duration
+	ll    *list.List
+	items map[string]*list.Element
+
+	// flush, if set, is called with the value and the number of repeats of a key
+	// counted by repeat, once its ttl expires or it's evicted.
+	flush func(value any, repeats int)
+}
+
+type lruEntry struct {
+	key     string
+	seen    time.Time
+	repeats int
+	value   any
+	timer   *time.Timer
+}
+
+func newLRUCache(size int, ttl time.Duration) *lruCache {
+	return &lruCache{
+		size:  size,
+		ttl:   ttl,
+		ll:    list.New(),
+		items: make(map[string]*list.Element, size),
+	}
+}
+
+// seen records the key and reports whether it was already seen within the ttl.
+func (c *lruCache) seen(key string, now time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		entry := el.Value.(*lruEntry)
+		dup := now.Sub(entry.seen) < c.ttl
+		entry.seen = now
+		c.ll.MoveToFront(el)
+		return dup
+	}
+
+	c.add(key, now, nil)
+	return false
+}
+
+// repeat reports whether the key was first seen within the ttl, in which case
+// it's counted as a repeat. Otherwise, the key is (re)recorded as first seen now
+// with the given value, and the number of repeats counted since it was
+// previously recorded and not yet flushed is returned.
+//
+// If c.flush is set, the repeats are flushed along with the value once the ttl
+// expires, so that they're reported even if the key isn't seen again.
+func (c *lruCache) repeat(key string, now time.Time, value any) (dup bool, repeats int) {
+	c.mu.Lock()
+
+	if el, ok := c.items[key]; ok {
+		entry := el.Value.(*lruEntry)
+		c.ll.MoveToFront(el)
+		if now.Sub(entry.seen) < c.ttl {
+			entry.repeats++
+			if entry.repeats == 1 && c.flush != nil {
+				seen := entry.seen
+				entry.timer = time.AfterFunc(c.ttl-now.Sub(seen), func() { c.expire(entry, seen) })
+			}
+			c.mu.Unlock()
+			return true, 0
+		}
+		if entry.timer != nil {
+			entry.timer.Stop()
+			entry.timer = nil
+		}
+		repeats = entry.repeats
+		entry.seen, entry.repeats, entry.value = now, 0, value
+		c.mu.Unlock()
+		return false, repeats
+	}
+
+	evicted := c.add(key, now, value)
+	c.mu.Unlock()
+	if evicted != nil && evicted.repeats > 0 && c.flush != nil {
+		c.flush(evicted.value, evicted.repeats)
+	}
+	return false, 0
+}
+
+// expire flushes the repeats of the entry once the ttl of the given first seen
+// time expired, unless they were already reported.
+func (c *lruCache) expire(entry *lruEntry, seen time.Time) {
+	c.mu.Lock()
+	if !entry.seen.Equal(seen) {
+		c.mu.Unlock()
+		return
+	}
+	repeats, value := entry.repeats, entry.value
+	entry.repeats, entry.timer = 0, nil
+	c.mu.Unlock()
+
+	if repeats > 0 {
+		c.flush(value, repeats)
+	}
+}
+
+// add records a new key, evicting the least recently used one, which is
+// returned if any. c.mu must be held.
+func (c *lruCache) add(key string, now time.Time, value any) (evicted *lruEntry) {
+	c.items[key] = c.ll.PushFront(&lruEntry{key: key, seen: now, value: value})
+	if c.ll.Len() <= c.size {
+		return nil
+	}
+	evicted = c.ll.Remove(c.ll.Back()).(*lruEntry)
+	delete(c.items, evicted.key)
+	if evicted.timer != nil {
+		evicted.timer.Stop()
+	}
+	// Keep a concurrently firing timer from flushing the repeats twice.
+	evicted.seen = time.Time{}
+	return evicted
+}
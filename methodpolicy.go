@@ -0,0 +1,57 @@
+package httplog
+
+import (
+	"math/rand"
+	"net/http"
+)
+
+// MethodPolicy defines how the logs of successful OPTIONS and HEAD requests are
+// recorded, see Options.OptionsPolicy and Options.HeadPolicy. Logs of failed
+// requests are always recorded at the level of their response status.
+type MethodPolicy int
+
+const (
+	// MethodPolicyDefault records OPTIONS requests at the debug level, and HEAD
+	// requests like any other request.
+	MethodPolicyDefault MethodPolicy = iota
+	// MethodPolicyLog records the requests like any other request.
+	MethodPolicyLog
+	// MethodPolicyDebug records the requests at the debug level.
+	MethodPolicyDebug
+	// MethodPolicySample records a fraction of the requests, see Options.MethodSampleRate.
+	MethodPolicySample
+	// MethodPolicySkip doesn't record the requests.
+	MethodPolicySkip
+)
+
+// methodPolicy returns the policy of the request method.
+func methodPolicy(method string, o *Options) MethodPolicy {
+	switch method {
+	case http.MethodOptions:
+		if o.OptionsPolicy == MethodPolicyDefault {
+			return MethodPolicyDebug
+		}
+		return o.OptionsPolicy
+	case http.MethodHead:
+		return o.HeadPolicy
+	default:
+		return MethodPolicyDefault
+	}
+}
+
+// skipMethod reports whether the log of the successful request is skipped by its
+// method policy, and the sampling rate if the policy is MethodPolicySample.
+func skipMethod(method string, o *Options) (skip bool, rate float64) {
+	switch methodPolicy(method, o) {
+	case MethodPolicySkip:
+		return true, 0
+	case MethodPolicySample:
+		rate = o.MethodSampleRate
+		if rate <= 0 {
+			rate = 0.01
+		}
+		return rate < 1 && rand.Float64() >= rate, rate
+	default:
+		return false, 0
+	}
+}
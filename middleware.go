@@ -7,9 +7,10 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"runtime"
+	"slices"
 	"strings"
-	"time"
+	"sync"
+	"sync/atomic"
 
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-logr/logr"
@@ -19,10 +20,22 @@ var (
 	ErrClientAborted = fmt.Errorf("request aborted: client disconnected before response was sent")
 )
 
+// StatusClientClosedRequest is the non-standard status of nginx logged for the
+// requests closed by the client before the response status was written, see
+// Options.ClientClosedStatus.
+const StatusClientClosedRequest = 499
+
 func RequestLogger(logger logr.Logger, o *Options) func(http.Handler) http.Handler {
 	if o == nil {
 		o = &defaultOptions
 	}
+	if len(o.PerHost) > 0 {
+		return perHostLogger(logger, o)
+	}
+	// Copy the options, so that the defaults aren't written to the options, which
+	// may be shared by multiple middlewares.
+	opts := *o
+	o = &opts
 	if len(o.LogBodyContentTypes) == 0 {
 		o.LogBodyContentTypes = defaultOptions.LogBodyContentTypes
 	}
@@ -33,34 +46,286 @@ func RequestLogger(logger logr.Logger, o *Options) func(http.Handler) http.Handl
 	if s == nil {
 		s = SchemaECS
 	}
+	clock := o.Clock
+	if clock == nil {
+		clock = systemClock{}
+	}
+	if o.EmitSchemaCheck {
+		EmitSchemaCheck(logger, s)
+	}
+	if o.SpillBodyKey != nil && o.SpillBodyThreshold > 0 {
+		if _, err := newSpillAEAD(o.SpillBodyKey); err != nil {
+			// Never write the bodies unencrypted: keep them in memory instead.
+			logger.Error(err, "httplog: invalid Options.SpillBodyKey; spilling of the bodies to files is disabled")
+			o.SpillBodyThreshold = 0
+		}
+	}
+	if o.SpillBodyThreshold > 0 && o.SpillBodyTTL > 0 {
+		go sweepSpillFiles(o.SpillBodyDir, o.SpillBodyTTL)
+	}
+
+	// seq numbers the request logs of this middleware instance, so that entries
+	// can be strictly ordered even when their timestamps collide. The number is
+	// assigned after the Skip and sampling decisions, but before the entries can
+	// still be suppressed, e.g. by Processors, ErrorDedupWindow or a blocked log
+	// sink (see Stats), so a gap doesn't necessarily mean a lost log.
+	var seq atomic.Uint64
+	reqHeaders := newHeaderMatcher(o.LogRequestHeaders, o)
+	respHeaders := newHeaderMatcher(o.LogResponseHeaders, o)
+	allHeaders := newHeaderMatcher([]string{"*"}, o)
+	duplicates := newDuplicateCache(o)
+	errorDedup := newErrorDedupCache(logger, o, s)
+	bursts := newBurstDetector(o)
+	var nestedWarning sync.Once
 
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if nestedRequestLogger(r) {
+				if o.SkipNested {
+					next.ServeHTTP(w, r)
+					return
+				}
+				nestedWarning.Do(func() {
+					logger.Info("httplog: request already logged by another request logger; set Options.SkipNested to skip the duplicate request logs")
+				})
+			}
+
 			ctx := logr.NewContext(r.Context(), logger)
-			ctx = context.WithValue(ctx, ctxKeyLogKVs{}, &[]any{})
-			logger = logger.V(o.Visibility)
+			rl := &requestLog{clock: clock, diffHeaders: o.LogProxyHeaderDiff, maxKVs: o.MaxContextKVs, maxKVBytes: o.MaxContextKVBytes}
+			ctx = context.WithValue(ctx, ctxKeyRequestLog{}, rl)
+			if o.IDGenerator != nil && requestID(ctx, r) == "" {
+				var id string
+				rl.callHook("IDGenerator", func() { id = o.IDGenerator() })
+				if id != "" {
+					ctx = context.WithValue(ctx, middleware.RequestIDKey, id)
+				}
+			}
+			if o.EchoRequestIDHeader != "" {
+				id := requestID(ctx, r)
+				if id == "" {
+					id = debugToken()
+					ctx = context.WithValue(ctx, middleware.RequestIDKey, id)
+				}
+				w.Header().Set(o.EchoRequestIDHeader, id)
+			}
+			if o.LogPriority || o.LowPrioritySampleRate > 0 {
+				rl.priority = requestPriority(r, o)
+			}
+			if o.Sampler != nil {
+				rl.sampling = newSamplingDecision(ctx, rl, r, o)
+			}
+			if o.ParentRequestIDHeader != "" {
+				rl.parent = parentRequest{header: o.ParentRequestIDHeader, requestID: r.Header.Get(middleware.RequestIDHeader)}
+			}
+			logger := logger.V(o.Visibility)
 
-			logReqBody := o.LogRequestBody != nil && o.LogRequestBody(r)
-			logRespBody := o.LogResponseBody != nil && o.LogResponseBody(r)
+			var logReqBody, logRespBody bool
+			if o.LogRequestBody != nil {
+				rl.callHook("LogRequestBody", func() { logReqBody = o.LogRequestBody(r) })
+			}
+			if o.LogResponseBody != nil {
+				rl.callHook("LogResponseBody", func() { logRespBody = o.LogResponseBody(r) })
+			} else {
+				logRespBody = o.LogResponseBodyStatus != nil
+			}
+			var verbose bool
+			if o.VerboseIf != nil {
+				rl.callHook("VerboseIf", func() { verbose = o.VerboseIf(r) })
+			}
+			reqHeaders, respHeaders := reqHeaders, respHeaders
+			if verbose {
+				// Log the bodies, unless disabled by SkipBody, and all headers.
+				logReqBody, logRespBody = true, true
+				rl.body = bodyLog
+				reqHeaders, respHeaders = allHeaders, allHeaders
+			}
+
+			graphQL := isGraphQL(r, o)
+			validateReqBody := o.ValidateRequestBody != nil && strings.Contains(mediaType(r.Header.Get("Content-Type")), "json")
+			replay := o.ReplayStore != nil && replayable(r, o)
+			bodyHooks := graphQL || validateReqBody || replay || o.InspectRequestBody != nil || o.LogExtraAttrs != nil
+			captureReqBody := logReqBody || bodyHooks
+
+			// The message streams of gRPC calls and WebSocket connections are never captured.
+			stream := streamType(r)
+			if stream != "" {
+				logReqBody, logRespBody = false, false
+			}
+			// The response body of HEAD requests is discarded by net/http, so it's never
+			// captured; its would-be Content-Length is logged instead.
+			head := r.Method == http.MethodHead
+			if head {
+				logRespBody = false
+			}
+
+			oversized := o.OversizedRequestBytes > 0 && r.ContentLength > o.OversizedRequestBytes
+
+			var headerBytes, headerCount int
+			if o.LogHeaderSize || o.LargeHeaderBytes > 0 {
+				headerBytes, headerCount = requestHeaderBytes(r)
+			}
+			largeHeaders := o.LargeHeaderBytes > 0 && headerBytes > o.LargeHeaderBytes
+			skipReqBody := (oversized && o.SkipOversizedBodies) || stream != ""
+
+			// Skip the body capture beyond the maximum number of concurrent captures,
+			// also of the bodies captured only for the hooks.
+			var captureSkipped bool
+			if o.MaxGlobalConcurrentCaptures > 0 && (captureReqBody || logRespBody) && !skipReqBody {
+				if activeCaptures.Add(1) > int64(o.MaxGlobalConcurrentCaptures) {
+					activeCaptures.Add(-1)
+					captureSkipped, skipReqBody = true, true
+					logRespBody = false
+				} else {
+					defer activeCaptures.Add(-1)
+				}
+			}
+			if skipReqBody {
+				logReqBody, captureReqBody = false, false
+			}
 
 			var reqBody bytes.Buffer
-			if logReqBody || o.LogExtraAttrs != nil {
-				r.Body = io.NopCloser(io.TeeReader(r.Body, &reqBody))
+			var reqSpill *bodySpill
+			var reqWindows *windowCapture
+			var reqReader *reqBodyReader
+			var reqCapped *cappedBuffer
+			if r.Body != nil && r.Body != http.NoBody && !skipReqBody {
+				var buf io.Writer = &reqBody
+				if o.SpillBodyThreshold > 0 && logReqBody {
+					reqSpill = newBodySpill(&reqBody, o)
+					buf = reqSpill
+				} else if limit := reqCaptureLimit(bodyHooks, o); limit >= 0 {
+					reqCapped = &cappedBuffer{buf: &reqBody, limit: limit}
+					buf = reqCapped
+				}
+				if len(o.LogBodyWindows) > 0 && logReqBody {
+					reqWindows = newWindowCapture(o.LogBodyWindows)
+					buf = io.MultiWriter(buf, reqWindows)
+				}
+				reqReader = &reqBodyReader{ReadCloser: r.Body, buf: buf, capture: captureReqBody, rl: rl,
+					oversizedAt: o.OversizedRequestBytes, skipOversized: o.SkipOversizedBodies}
+				r.Body = reqReader
+			}
+			var reqCounter *byteCounter
+			if o.AccountBandwidth && reqReader == nil && r.Body != nil && r.Body != http.NoBody {
+				reqCounter = &byteCounter{ReadCloser: r.Body}
+				r.Body = reqCounter
 			}
 
+			var snap *headerSnapshot
+			if o.SnapshotResponseHeaders || o.ServerTiming || o.StallThreshold > 0 {
+				snap = &headerSnapshot{capture: o.SnapshotResponseHeaders}
+				if o.ServerTiming {
+					snap.beforeSend = func(header http.Header) { header.Add("Server-Timing", rl.serverTiming()) }
+				}
+				if o.StallThreshold > 0 {
+					snap.clock = clock
+				}
+				w = wrapSnapshot(w, snap)
+			}
+			var reqFrames, respFrames *frameCounter
+			var webSocket *webSocketWriter
+			if o.LogStreamMessages {
+				if _, hj := w.(http.Hijacker); hj && stream == "websocket" {
+					reqFrames, respFrames = newWebSocketCounter(), newWebSocketCounter()
+					webSocket = &webSocketWriter{ResponseWriter: w, in: reqFrames, out: respFrames}
+					w = webSocket
+				}
+				// The base64-encoded gRPC-Web streams can't be counted.
+				if stream == "grpc" && !strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc-web-text") {
+					reqFrames, respFrames = newGRPCCounter(), newGRPCCounter()
+					if r.Body != nil && r.Body != http.NoBody {
+						r.Body = &frameReader{ReadCloser: r.Body, counter: reqFrames}
+					}
+				}
+			}
 			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			var expect *continueReader
+			if o.LogExpectContinue && expectsContinue(r) && r.Body != nil && r.Body != http.NoBody {
+				expect = &continueReader{ReadCloser: r.Body, clock: clock, status: ww.Status}
+				r.Body = expect
+			}
+
+			var tees []bodyCapturer
+			// The response body writer is always tee'd, as LogBody can enable it later.
+			respBody := &respBodyWriter{header: ww.Header(), status: ww.Status, o: o, rl: rl, enabled: logRespBody}
+			if o.SpillBodyThreshold > 0 {
+				respBody.spill = newBodySpill(&bytes.Buffer{}, o)
+			}
+			if stream != "" || captureSkipped || head {
+				respBody.decided = true
+			}
+			tees = append(tees, respBody)
+			var respWindows *windowCapture
+			if len(o.LogBodyWindows) > 0 && logRespBody {
+				respWindows = newWindowCapture(o.LogBodyWindows)
+				tees = append(tees, respWindows)
+			}
+			if stream == "grpc" && respFrames != nil {
+				tees = append(tees, respFrames)
+			}
+			var problem *problemWriter
+			if o.LogProblemDetails || len(o.ErrorMessageFields) > 0 {
+				problem = &problemWriter{
+					header:    ww.Header(),
+					status:    ww.Status,
+					problem:   o.LogProblemDetails,
+					errorJSON: len(o.ErrorMessageFields) > 0,
+				}
+				tees = append(tees, problem)
+			}
+			var sniff *sniffWriter
+			if o.DetectContentTypeMismatch {
+				sniff = &sniffWriter{header: ww.Header()}
+				tees = append(tees, sniff)
+			}
+			if len(tees) > 0 {
+				writers := make([]io.Writer, len(tees))
+				for i, tee := range tees {
+					writers[i] = tee
+				}
+				ww.Tee(io.MultiWriter(writers...))
+			}
+			rw := wrapReaderFrom(ww, tees)
+			var capabilities []string
+			if o.LogWriterCapabilities {
+				capabilities = writerCapabilities(rw)
+			}
+			if o.FailUnsupportedWriterOps {
+				rw = wrapStrict(rw, r.ProtoMajor)
+			}
+			var controller *controllerWriter
+			if o.LogResponseController {
+				controller = &controllerWriter{}
+				rw = wrapController(rw, controller)
+			}
 
-			var respBody bytes.Buffer
-			if o.LogResponseBody != nil && o.LogResponseBody(r) {
-				ww.Tee(&respBody)
+			start := clock.Now()
+			rl.start = start
+			partialVerbose := verbose // captured by value
+			rl.partial = func(ctx context.Context, msg string) {
+				if partialSkipped(ctx, r, rl, logger, o, ww.Status(), partialVerbose) {
+					return
+				}
+				emit(logger, 1, nil, msg, partialKVs(ctx, r, s, o, clock.Since(start), ww.BytesWritten()), o)
 			}
+			abort := watchAbort(ctx, start, clock)
+			rl.abort = abort
 
-			start := time.Now()
+			// served is the request as served by the underlying HTTP handler, e.g. with
+			// the http.ServeMux pattern set.
+			var served *http.Request
 
 			defer func() {
+				defer rl.closed.Store(true)
+				defer reqSpill.discard()
+				defer respBody.spill.discard()
+				abort.stop()
+
 				var logkvs []any
+				var panicErr *PanicError
 
-				if rec := recover(); rec != nil {
+				rec := recover()
+				if rec != nil {
 					// Return HTTP 500 if recover is enabled and no response status was set.
 					if o.RecoverPanics && ww.Status() == 0 && r.Header.Get("Connection") != "Upgrade" {
 						ww.WriteHeader(http.StatusInternalServerError)
@@ -69,143 +334,809 @@ func RequestLogger(logger logr.Logger, o *Options) func(http.Handler) http.Handl
 					if rec == http.ErrAbortHandler || !o.RecoverPanics {
 						// Re-panic http.ErrAbortHandler unconditionally, and re-panic other errors if panic recovery is disabled.
 						defer panic(rec)
+						if rec != http.ErrAbortHandler {
+							markPanicLogged(r.Context())
+						}
 					}
 
 					logkvs = appendKVs(logkvs, s.ErrorMessage, fmt.Sprintf("panic: %v", rec))
 
 					if rec != http.ErrAbortHandler {
-						pc := make([]uintptr, 10)   // Capture up to 10 stack frames.
-						n := runtime.Callers(3, pc) // Skip 3 frames (this middleware + runtime/panic.go).
-						pc = pc[:n]
-
-						// Process panic stack frames to print detailed information.
-						frames := runtime.CallersFrames(pc)
-						var stackValues []string
-						for frame, more := frames.Next(); more; frame, more = frames.Next() {
-							if !strings.Contains(frame.File, "runtime/panic.go") {
-								stackValues = append(stackValues, fmt.Sprintf("%s:%d", frame.File, frame.Line))
-							}
+						// Skip 3 frames (this middleware + runtime/panic.go).
+						panicErr = newPanicError(rec, 3)
+						if o.RecoverPanics {
+							recordPanic(clock.Now(), MetricsLabel(r, o), panicErr)
+						}
+						logkvs = appendKVs(logkvs, s.ErrorStackTrace, panicErr.Stack)
+						if o.OnPanic != nil {
+							rl.callHook("OnPanic", func() { o.OnPanic(r.WithContext(ctx), panicErr) })
+						}
+					}
+				} else if recovered := rl.recoveredPanic(); recovered != nil {
+					// The panic was recovered by Recoverer mounted after the request logger.
+					rec, panicErr = recovered.Value, recovered
+					logkvs = appendKVs(logkvs,
+						s.ErrorMessage, panicErr.Error(),
+						s.ErrorStackTrace, panicErr.Stack,
+					)
+					if o.OnPanic != nil {
+						rl.callHook("OnPanic", func() { o.OnPanic(r.WithContext(ctx), panicErr) })
+					}
+				}
+
+				if served != nil {
+					r = served
+				}
+				// The response is completed like a Record captured by Capture.
+				record := Record{Request: r, ww: ww, rl: rl, start: start}
+				record.complete(o, rec)
+				duration, statusCode := record.Duration, record.Status
+				if o.AccountBandwidth {
+					var reqBytes int64
+					if reqReader != nil {
+						reqBytes = reqReader.n
+					} else if reqCounter != nil {
+						reqBytes = reqCounter.n
+					}
+					accountBandwidth(r.WithContext(ctx), o, statusCode, reqBytes, ww.BytesWritten())
+				}
+				if o.Summarizer != nil {
+					o.Summarizer.add(MetricsLabel(r.WithContext(ctx), o), statusCode, duration, s)
+				}
+				if bursts != nil && statusCode >= 500 {
+					route := MetricsLabel(r.WithContext(ctx), o)
+					if burst, count := bursts.add(route, clock.Now()); burst {
+						kvs := burstKVs(r, route, count, bursts.window, s)
+						if s.grouped() {
+							kvs = groupKVs(kvs, s)
 						}
-						logkvs = appendKVs(logkvs, s.ErrorStackTrace, stackValues)
+						emit(logger, 0, nil, fmt.Sprintf("HTTP 5xx burst: %d errors of %s within %v", count, route, bursts.window), kvs, o)
 					}
 				}
+				if o.SecurityLogger.GetSink() != nil && authFailed(ctx, statusCode) {
+					kvs := securityKVs(ctx, r.WithContext(ctx), statusCode, o, s)
+					if s.grouped() {
+						kvs = groupKVs(kvs, s)
+					}
+					emit(o.SecurityLogger, 1, nil, fmt.Sprintf("HTTP auth failure: %s %s", r.Method, r.URL.Path), kvs, o)
+				}
+
+				rl.resolveTenant(ctx, r, o)
 
-				duration := time.Since(start)
-				statusCode := ww.Status()
-				if statusCode == 0 {
-					// If the handler never calls w.WriteHeader(statusCode) explicitly,
-					// Go's http package automatically sends HTTP 200 OK to the client.
-					statusCode = 200
+				corsType := CORSType(r)
+				if o.SkipCORSPreflight && corsType == CORSPreflight {
+					stats.requestsSuppressed.Add(1)
+					return
 				}
 
 				// Skip logging if the request is filtered by the Skip function.
-				if o.Skip != nil && o.Skip(r, statusCode) {
+				if o.Skip != nil {
+					var skip bool
+					rl.callHook("Skip", func() { skip = o.Skip(r.WithContext(ctx), statusCode) })
+					if skip {
+						stats.requestsSuppressed.Add(1)
+						return
+					}
+				}
+
+				// Skip logging of successful requests in errors-only mode, or if not sampled.
+				// The requests with large headers are logged as warnings, like the failed ones.
+				failed := statusCode >= 400 || rec != nil || errors.Is(ctx.Err(), context.Canceled) || largeHeaders
+				if o.OnlyErrors && !failed && !verbose {
+					stats.requestsSuppressed.Add(1)
 					return
 				}
+				// The sampling rate logged is the product of the rates of all the
+				// sampling decisions applied, i.e. the probability of the log.
+				sampled, samplingRate := true, 0.0
+				if rl.sampling != nil {
+					sampled, samplingRate = rl.sampling.get()
+					if !sampled && !failed && !verbose {
+						stats.requestsSuppressed.Add(1)
+						return
+					}
+				}
+				if !failed && !verbose {
+					skip, rate := skipMethod(r.Method, o)
+					if skip {
+						stats.requestsSuppressed.Add(1)
+						return
+					}
+					samplingRate = combineSamplingRates(samplingRate, rate)
+					skip, rate = skipLowPriority(ctx, r, rl.priority, o)
+					if skip {
+						stats.requestsSuppressed.Add(1)
+						return
+					}
+					samplingRate = combineSamplingRates(samplingRate, rate)
+				}
+				var samplingKVs []any
+				if samplingRate > 0 || rl.sampling != nil {
+					samplingKVs = []any{s.SamplingRate, samplingRate, s.SamplingSampled, sampled}
+				}
 
-				var lvl int
-				switch {
-				case statusCode >= 500:
-					lvl = 0 // error
-				case statusCode == 429:
-					lvl = -2 // info
-				case statusCode >= 400:
+				lvl := statusLevel(statusCode, r.Method, o)
+				if largeHeaders && lvl < -1 {
 					lvl = -1 // warning
-				case r.Method == "OPTIONS":
-					lvl = -3 // debug
-				default:
-					lvl = -2
+				}
+				aborted := rec == http.ErrAbortHandler
+				if aborted {
+					lvl = o.HandlerAbortedLevel
+				}
+
+				// Classify the error set by SetError, or the client abort.
+				clientAborted := !aborted && errors.Is(ctx.Err(), context.Canceled)
+				var errType string
+				if o.ClassifyError != nil {
+					err := rl.getError()
+					if err == nil && clientAborted {
+						err = ErrClientAborted
+					}
+					if err != nil {
+						var errLvl int
+						if rl.callHook("ClassifyError", func() { errType, errLvl = o.ClassifyError(err) }) == nil {
+							lvl = errLvl
+						}
+					}
 				}
 
 				// Skip logging if the message level is below the logger's level or the minimum level specified in options
-				if logger.GetV() > lvl {
+				if logger.GetV() > o.Levels.v(lvl) && !verbose {
+					stats.requestsSuppressed.Add(1)
 					return
 				}
 
+				sequence := seq.Add(1)
+				remoteIP, reqScheme, reqHost := r.RemoteAddr, scheme(r), r.Host
+				if o.LogURLParts {
+					reqHost = hostname(r)
+				}
+				var cdn cdnInfo
+				if o.LogCDNHeaders {
+					cdn = cdnHeaders(r)
+					if cdn.clientIP != "" {
+						remoteIP = cdn.clientIP
+					}
+					if cdn.scheme != "" {
+						reqScheme = cdn.scheme
+					}
+				}
+
+				respHeader := ww.Header()
+				if snap != nil && snap.header != nil {
+					respHeader = snap.header
+				}
+				remoteIP, remotePort := splitRemoteAddr(remoteIP)
 				logkvs = appendKVs(logkvs,
-					s.RequestURL, requestURL(r),
+					s.RequestURL, loggedURL(r, o),
 					s.RequestMethod, r.Method,
 					s.RequestPath, r.URL.Path,
-					s.RequestRemoteIP, r.RemoteAddr,
-					s.RequestHost, r.Host,
-					s.RequestScheme, scheme(r),
+					s.RequestRemoteIP, privacyHash(PrivacyRemoteIP, remoteIP, o),
+					s.RequestHost, reqHost,
+					s.RequestScheme, reqScheme,
 					s.RequestProto, r.Proto,
-					s.RequestHeaders, nestKVs(getHeaderKVs(r.Header, o.LogRequestHeaders)),
+					s.RequestHeaders, nestKVs(reqHeaders.kvs(r.Header)),
 					s.RequestBytes, r.ContentLength,
-					s.RequestUserAgent, r.UserAgent(),
-					s.RequestReferer, r.Referer(),
-					s.ResponseHeaders, nestKVs(getHeaderKVs(ww.Header(), o.LogResponseHeaders)),
+					s.RequestUserAgent, privacyHash(PrivacyUserAgent, r.UserAgent(), o),
+					s.RequestReferer, privacyHash(PrivacyReferer, referer(r.Referer(), o.RefererPolicy), o),
+					s.ResponseHeaders, nestKVs(respHeaders.kvs(respHeader)),
 					s.ResponseStatus, statusCode,
+					s.ResponseStatusClass, statusClass(statusCode),
 					s.ResponseDuration, float64(duration.Milliseconds()),
 					s.ResponseBytes, ww.BytesWritten(),
+					s.RequestSequence, sequence,
 				)
+				if remotePort != 0 {
+					logkvs = appendKVs(logkvs, s.RequestRemotePort, remotePort)
+				}
+				if head {
+					logkvs = appendKVs(logkvs, s.ResponseHeadContentLength, headContentLength(ww.Header(), ww.BytesWritten()))
+				}
+				logkvs = appendKVs(logkvs, samplingKVs...)
+
+				if o.LogLabels {
+					logkvs = appendKVs(logkvs, s.Labels, labels(r, statusCode, o))
+				}
+				if o.LogURLParts {
+					logkvs = appendKVs(logkvs, urlPartsKVs(r, reqScheme, s, o)...)
+				}
+				if len(o.LogQueryParams) > 0 {
+					if params := queryParamsKVs(r.URL.Query(), o); params != nil {
+						logkvs = appendKVs(logkvs, s.RequestQueryParams, params)
+					}
+				}
+				route := routePattern(r, o)
+				if route != "" {
+					logkvs = appendKVs(logkvs, s.RequestRoute, route)
+				}
+				if o.LogRouteMount {
+					if prefix, inner := chiMountedRoute(r); inner != "" {
+						logkvs = appendKVs(logkvs, s.RequestRouteMount, prefix, s.RequestRouteInner, inner)
+					}
+				}
+				if slo, ok := o.SLOs[route]; ok {
+					logkvs = appendKVs(logkvs, sloKVs(slo, statusCode, duration, s)...)
+				}
+				if o.LogFingerprint {
+					logkvs = appendKVs(logkvs, s.RequestFingerprint, requestFingerprint(r, route))
+				}
+				if handler := rl.handlerName(); handler != "" {
+					logkvs = appendKVs(logkvs, s.HandlerName, handler)
+				}
+				if o.LogRequestID {
+					if id := requestID(ctx, r); id != "" {
+						logkvs = appendKVs(logkvs, s.RequestID, id)
+					}
+				}
+				if o.ParentRequestIDHeader != "" {
+					if parentID := r.Header.Get(o.ParentRequestIDHeader); parentID != "" {
+						logkvs = appendKVs(logkvs, s.RequestParentID, parentID)
+					}
+				}
+				logkvs = appendKVs(logkvs, traceKVs(r, o, s)...)
+				if len(o.LogBaggage) > 0 {
+					if baggage := baggageKVs(r.Header, o.LogBaggage); baggage != nil {
+						logkvs = appendKVs(logkvs, s.Baggage, baggage)
+					}
+				}
+				if len(o.LogCookies) > 0 {
+					if cookies := cookiesKVs(r, o); cookies != nil {
+						logkvs = appendKVs(logkvs, s.RequestCookies, cookies)
+					}
+				}
+				if cdn.rayID != "" {
+					logkvs = appendKVs(logkvs, s.CDNRayID, cdn.rayID)
+				}
+				if cdn.edge != "" {
+					logkvs = appendKVs(logkvs, s.CDNEdge, cdn.edge)
+				}
+				if key := idempotencyKey(r, o); key != "" {
+					logkvs = appendKVs(logkvs, s.RequestIdempotencyKey, key)
+					if duplicates != nil {
+						logkvs = appendKVs(logkvs, s.RequestDuplicate, duplicates.seen(r.Method+" "+r.URL.Path+" "+key, start))
+					}
+				}
+				if o.LogWireBytes {
+					headerBytes := responseHeaderBytes(r.Proto, statusCode, ww.Header(), ww.BytesWritten())
+					logkvs = appendKVs(logkvs,
+						s.ResponseHeaderBytes, headerBytes,
+						s.ResponseWireBytes, headerBytes+ww.BytesWritten(),
+					)
+				}
+				if o.StallThreshold > 0 {
+					// The headers of responses without a body are sent when the handler returns.
+					firstByte := duration
+					if !snap.sentAt.IsZero() {
+						firstByte = snap.sentAt.Sub(start)
+					}
+					if firstByte > o.StallThreshold {
+						logkvs = appendKVs(logkvs, s.ResponseStalled, float64(firstByte.Milliseconds()))
+					}
+				}
+				if o.LogThroughput {
+					if bps := bytesPerSec(r.ContentLength, duration); bps > 0 {
+						logkvs = appendKVs(logkvs, s.RequestBytesPerSec, bps)
+					}
+					if bps := bytesPerSec(int64(ww.BytesWritten()), duration); bps > 0 {
+						logkvs = appendKVs(logkvs, s.ResponseBytesPerSec, bps)
+					}
+				}
+				if reqFrames != nil {
+					logkvs = appendKVs(logkvs,
+						s.RequestMessages, reqFrames.get(),
+						s.ResponseMessages, respFrames.get(),
+					)
+				}
+				if webSocket != nil {
+					logkvs = appendKVs(logkvs, webSocket.closeKVs(s)...)
+				}
+				if ratio := compressionRatio(ctx, ww.Header(), ww.BytesWritten()); ratio > 0 {
+					logkvs = appendKVs(logkvs, s.ResponseCompressionRatio, ratio)
+				}
+
+				if status := cacheStatus(ctx, ww.Header()); status != "" {
+					logkvs = appendKVs(logkvs, s.CacheStatus, status)
+				}
+
+				if o.UserAgentParser != nil {
+					var kvs []any
+					rl.callHook("UserAgentParser", func() { kvs = o.UserAgentParser(r.UserAgent()) })
+					if s.ClientIsBot != "" {
+						var isBot any
+						if kvs, isBot = splitIsBot(kvs); isBot != nil {
+							logkvs = appendKVs(logkvs, s.ClientIsBot, isBot)
+						}
+					}
+					if len(kvs) > 0 {
+						logkvs = appendKVs(logkvs, s.RequestUserAgentDetails, nestKVs(kvs))
+					}
+				}
+				if len(o.AuditSecurityHeaders) > 0 {
+					var missing []string
+					for _, h := range o.AuditSecurityHeaders {
+						if ww.Header().Get(h) == "" {
+							missing = append(missing, h)
+						}
+					}
+					if len(missing) > 0 {
+						logkvs = appendKVs(logkvs, s.SecurityMissingHeaders, missing)
+					}
+				}
+				if o.TrafficClassFunc != nil {
+					var class string
+					rl.callHook("TrafficClassFunc", func() { class = o.TrafficClassFunc(r.WithContext(ctx)) })
+					if class != "" {
+						logkvs = appendKVs(logkvs, s.TrafficClass, class)
+					}
+				}
+				if o.LogClientHints {
+					logkvs = appendKVs(logkvs, clientHintsKVs(r.Header, s)...)
+				}
+				if o.LogLocale {
+					if locale := preferredLocale(r.Header.Get("Accept-Language")); locale != "" {
+						logkvs = appendKVs(logkvs, s.ClientLocale, locale)
+					}
+				}
+				if o.LogPriority && rl.priority != "" {
+					logkvs = appendKVs(logkvs, s.RequestPriority, rl.priority)
+				}
+				if o.LogRequestEncoding {
+					logkvs = appendKVs(logkvs, requestEncodingKVs(r.Header, s)...)
+				}
+				if corsType != "" {
+					logkvs = appendKVs(logkvs, s.RequestCORSType, corsType, s.RequestOrigin, r.Header.Get("Origin"))
+				}
+				if statusCode >= 300 && statusCode < 400 {
+					if location := ww.Header().Get("Location"); location != "" {
+						logkvs = appendKVs(logkvs, s.ResponseRedirectLocation, location)
+					}
+				}
+				logkvs = appendKVs(logkvs, conditionalKVs(r, ww.Header(), statusCode, s)...)
+				logkvs = appendKVs(logkvs, rangeKVs(r, ww.Header(), statusCode, s)...)
+				if statusCode == http.StatusTooManyRequests {
+					if rl := rateLimit(ww.Header()); rl != nil {
+						logkvs = appendKVs(logkvs, s.ResponseRateLimit, rl)
+					}
+				}
+				logkvs = appendKVs(logkvs, upstreamKVs(ctx, s)...)
+				if o.LogQueueTime {
+					if queued, ok := queueTime(r, start); ok {
+						logkvs = appendKVs(logkvs, s.RequestQueueTime, float64(queued.Milliseconds()))
+					}
+				}
+				if controller != nil {
+					logkvs = appendKVs(logkvs, controller.kvs(s, start)...)
+				}
+				if capabilities != nil {
+					logkvs = appendKVs(logkvs, s.ResponseWriterCapabilities, capabilities)
+				}
+				if expect != nil {
+					logkvs = appendKVs(logkvs, expect.kvs(s)...)
+				}
+				// The deadline is set e.g. by middleware.Timeout mounted before the request
+				// logger. The contexts derived by the downstream handlers aren't visible
+				// here, so a deadline set after the request logger isn't logged.
+				if deadline, ok := r.Context().Deadline(); ok {
+					logkvs = appendKVs(logkvs,
+						s.RequestDeadline, float64(deadline.Sub(start).Milliseconds()),
+						s.RequestDeadlineRemaining, float64(deadline.Sub(clock.Now()).Milliseconds()),
+					)
+				}
+				if timings := timingsKVs(ctx); timings != nil {
+					logkvs = appendKVs(logkvs, s.Timings, timings)
+				}
+				if timings := middlewareTimingsKVs(ctx, duration); timings != nil {
+					logkvs = appendKVs(logkvs, s.MiddlewareTimings, timings)
+				}
+				if counters := countersKVs(ctx); counters != nil {
+					logkvs = appendKVs(logkvs, s.Counters, counters)
+				}
+				if experiments := experimentsKVs(ctx); experiments != nil {
+					logkvs = appendKVs(logkvs, s.Experiments, experiments)
+				}
 
-				if err := ctx.Err(); errors.Is(err, context.Canceled) {
-					logkvs = appendKVs(logkvs, ErrorKey, ErrClientAborted, s.ErrorType, "ClientAborted")
+				if aborted {
+					logkvs = appendKVs(logkvs, s.ErrorType, "HandlerAborted")
+					logkvs = appendKVs(logkvs, abort.kvs(s, ww.Status(), ww.Header(), ww.BytesWritten())...)
+				} else if clientAborted {
+					abortType := "ClientAborted"
+					if errType != "" {
+						abortType, errType = errType, ""
+					}
+					logkvs = appendKVs(logkvs, ErrorKey, ErrClientAborted, s.ErrorType, abortType)
+					logkvs = appendKVs(logkvs, abort.kvs(s, ww.Status(), ww.Header(), ww.BytesWritten())...)
 				}
+				if errType != "" {
+					logkvs = appendKVs(logkvs, s.ErrorType, errType)
+				}
+				logkvs = appendKVs(logkvs, s.EventOutcome, eventOutcome(statusCode, rec != nil || rl.getError() != nil, aborted || clientAborted))
 
-				if logReqBody || o.LogExtraAttrs != nil {
-					// Ensure the request body is fully read if the underlying HTTP handler didn't do so.
-					n, _ := io.Copy(io.Discard, r.Body)
-					if n > 0 {
-						logkvs = appendKVs(logkvs, s.RequestBytesUnread, n)
+				if o.LogResponseBodyStatus != nil {
+					rl.callHook("LogResponseBodyStatus", func() { logRespBody = logRespBody && o.LogResponseBodyStatus(statusCode) })
+				}
+				switch rl.bodyOverride() {
+				case bodyLog:
+					logReqBody, logRespBody = !skipReqBody, stream == "" && !captureSkipped && !head
+				case bodySkip:
+					logReqBody, logRespBody = false, false
+				}
+				if o.LogHeaderSize || largeHeaders {
+					logkvs = appendKVs(logkvs, s.RequestHeaderBytes, headerBytes, s.RequestHeaderCount, headerCount)
+				}
+				if largeHeaders {
+					logkvs = appendKVs(logkvs, s.RequestHeadersLarge, true)
+				}
+				if captureSkipped {
+					logkvs = appendKVs(logkvs, s.BodyCaptureSkipped, true)
+				}
+
+				if (captureReqBody || logReqBody || o.TrackUnreadBody) && !skipReqBody {
+					var unread int64
+					if !o.SkipDrainBody {
+						// Ensure the request body is fully read if the underlying HTTP handler didn't do so.
+						unread, _ = io.Copy(io.Discard, r.Body)
+					} else if reqReader != nil && r.ContentLength > 0 {
+						unread = r.ContentLength - reqReader.n
+					}
+					if unread > 0 {
+						logkvs = appendKVs(logkvs, s.RequestBytesUnread, unread)
+					}
+				}
+				// Bodies without Content-Length, e.g. chunked, are found oversized once read.
+				if reqReader != nil && reqReader.oversized && !oversized {
+					oversized = true
+					if o.SkipOversizedBodies {
+						skipReqBody, logReqBody, captureReqBody = true, false, false
+						reqBody.Reset()
+						reqSpill.discard()
+					}
+				}
+				if oversized {
+					logkvs = appendKVs(logkvs, s.RequestOversized, true)
+				}
+				// The trailers are set once the request body is read to the end.
+				if o.LogRequestTrailers && len(r.Trailer) > 0 {
+					if trailers := allHeaders.kvs(r.Trailer); len(trailers) > 0 {
+						logkvs = appendKVs(logkvs, s.RequestTrailers, nestKVs(trailers))
+					}
+				}
+				// Spilled and truncated bodies aren't fully kept in memory, so they can't be stored.
+				if replay && statusCode >= 500 && !skipReqBody && (reqSpill == nil || reqSpill.file == nil) && (reqCapped == nil || !reqCapped.truncated) {
+					var ref string
+					var err error
+					rl.callHook("ReplayStore", func() { ref, err = o.ReplayStore.Store(r, reqBody.Bytes()) })
+					if err == nil && ref != "" {
+						logkvs = appendKVs(logkvs, s.RequestReplayRef, ref)
 					}
 				}
+				if graphQL {
+					logkvs = appendKVs(logkvs, graphQLKVs(r, reqBody.Bytes(), s)...)
+				}
+				// A truncated body can't be validated.
+				if validateReqBody && reqBody.Len() > 0 && (reqCapped == nil || !reqCapped.truncated) {
+					var err error
+					if hookErr := rl.callHook("ValidateRequestBody", func() { err = o.ValidateRequestBody(r.WithContext(ctx), reqBody.Bytes()) }); hookErr != nil {
+						err = hookErr
+					}
+					logkvs = appendKVs(logkvs, s.RequestBodyValid, err == nil)
+					if err != nil {
+						logkvs = appendKVs(logkvs, s.RequestBodyError, err.Error())
+					}
+				}
+				if o.InspectRequestBody != nil {
+					var kvs []any
+					rl.callHook("InspectRequestBody", func() { kvs = o.InspectRequestBody(r.WithContext(ctx), reqBody.Bytes()) })
+					logkvs = appendKVs(logkvs, kvs...)
+				}
+				var bodyKVs []any
 				if logReqBody {
-					logkvs = appendKVs(logkvs, s.RequestBody, logBody(&reqBody, r.Header, o))
+					bodyKVs = appendKVs(bodyKVs, s.RequestBody, logBody(&reqBody, r.Header, o))
+				}
+				if ref := reqSpill.close(); ref != "" {
+					logkvs = appendKVs(logkvs, s.RequestBodyRef, ref)
+				}
+				if windows := reqWindows.kvs(); logReqBody && windows != nil {
+					logkvs = appendKVs(logkvs, s.RequestBodyWindows, windows)
 				}
 				if logRespBody {
-					logkvs = appendKVs(logkvs, s.ResponseBody, logBody(&respBody, ww.Header(), o))
+					bodyKVs = appendKVs(bodyKVs, s.ResponseBody, respBody.body())
+				}
+				if ref := respBody.spill.close(); ref != "" {
+					logkvs = appendKVs(logkvs, s.ResponseBodyRef, ref)
 				}
+				if windows := respWindows.kvs(); logRespBody && windows != nil {
+					logkvs = appendKVs(logkvs, s.ResponseBodyWindows, windows)
+				}
+				if !o.SplitBodies {
+					logkvs = appendKVs(logkvs, bodyKVs...)
+				}
+				if sniffed := sniff.mismatch(); sniffed != "" {
+					logkvs = appendKVs(logkvs, s.ResponseContentTypeMismatch, sniffed)
+				}
+				if statusCode == http.StatusMethodNotAllowed {
+					if methods := allowedMethods(ww.Header()); len(methods) > 0 {
+						logkvs = appendKVs(logkvs, s.ResponseAllowedMethods, methods)
+					}
+				}
+				if filename, ok := attachment(ww.Header()); ok && filename != "" {
+					logkvs = appendKVs(logkvs, s.ResponseFilename, filename)
+				}
+				logkvs = appendKVs(logkvs, problemDetailsKVs(problem, s)...)
+				if msg := errorMessage(problem, o.ErrorMessageFields); msg != "" {
+					logkvs = appendKVs(logkvs, s.ResponseErrorMessage, msg)
+				}
+				var userID, username string
+				if o.IdentityFunc != nil {
+					var extra []any
+					rl.callHook("IdentityFunc", func() { userID, username, extra = o.IdentityFunc(r.WithContext(ctx)) })
+					if userID != "" {
+						logkvs = appendKVs(logkvs, s.UserID, userID)
+					}
+					if username != "" {
+						logkvs = appendKVs(logkvs, s.UserName, username)
+					}
+					logkvs = appendKVs(logkvs, extra...)
+				}
+				if claims := JWTClaims(r, o.LogJWTClaims...); claims != nil {
+					logkvs = appendKVs(logkvs, claimKVs(claims, userID, username, s)...)
+				}
+				if fingerprint := apiKeyFingerprint(r, o); fingerprint != "" {
+					logkvs = appendKVs(logkvs, s.APIKeyHash, fingerprint)
+				}
+				if tenant := Tenant(ctx); tenant != "" {
+					logkvs = appendKVs(logkvs, s.TenantID, tenant)
+				}
+				logkvs = appendKVs(logkvs, authKVs(ctx, s)...)
+				logkvs = appendKVs(logkvs, rejectKVs(ctx, s)...)
 				if o.LogExtraAttrs != nil {
-					logkvs = appendKVs(logkvs, o.LogExtraAttrs(r, reqBody.String(), statusCode)...)
+					extraBody := reqBody.String()
+					if rl.bodyOverride() == bodySkip {
+						extraBody = ""
+					}
+					var kvs []any
+					rl.callHook("LogExtraAttrs", func() { kvs = o.LogExtraAttrs(r, extraBody, statusCode) })
+					logkvs = appendKVs(logkvs, kvs...)
 				}
 				logkvs = appendKVs(logkvs, getKVs(ctx)...)
+				logkvs = appendKVs(logkvs, getGroupKVs(ctx)...)
+				if rl.getKVsTruncated() {
+					logkvs = appendKVs(logkvs, s.ContextKVsTruncated, true)
+				}
+
+				if o.DedupeKeys {
+					logkvs = dedupeKVs(logkvs)
+				}
+				if o.MaxAttrValueLen > 0 {
+					logkvs = capValues(logkvs, o.MaxAttrValueLen, s)
+				}
+				if o.MaxEntryBytes > 0 {
+					logkvs = trimEntry(logkvs, o.MaxEntryBytes, s)
+				}
+
+				// Collapse identical error logs within the deduplication window.
+				if errorDedup != nil && lvl == 0 {
+					dup, repeats := errorDedup.repeat(errorFingerprint(r, o, statusCode, logkvs, s), clock.Now(), newErrorSummary(r, o, statusCode, logkvs, s))
+					if dup {
+						stats.requestsSuppressed.Add(1)
+						return
+					}
+					if repeats > 0 {
+						logkvs = appendKVs(logkvs, s.RepeatCount, repeats)
+					}
+				}
+
+				entry := &Entry{
+					Request:       r.WithContext(ctx),
+					Status:        statusCode,
+					Duration:      duration,
+					Level:         lvl,
+					Message:       fmt.Sprintf("%s %s => HTTP %v (%v)", r.Method, redactedURL(r.URL, o), statusCode, duration),
+					KeysAndValues: logkvs,
+					Time:          clock.Now(),
+					Schema:        s,
+				}
+				if panicErr != nil {
+					entry.Err = panicErr
+				} else if aborted {
+					entry.Err = http.ErrAbortHandler
+				}
+				if len(o.Processors) > 0 {
+					entry = processEntry(entry, o.Processors, func(name string, hook func()) { rl.callHook(name, hook) })
+					if entry == nil {
+						stats.requestsSuppressed.Add(1)
+						return
+					}
+					logkvs = entry.KeysAndValues
+				}
+				if o.ValueEncoder != nil {
+					rl.callHook("ValueEncoder", func() { logkvs = encodeValues(logkvs, o.ValueEncoder) })
+				}
+				entryLogger := logger
+				if o.Route != nil {
+					rl.callHook("Route", func() { entryLogger = routeEntry(entry, o.Route, logger, o.Visibility) })
+				}
+				// The hook errors are appended last, so that the panics of all hooks
+				// but AfterEmit, which runs after the entry was written, are logged.
+				if hookErrors := rl.getHookErrors(); len(hookErrors) > 0 {
+					logkvs = appendKVs(logkvs, s.HookErrors, hookErrors)
+				}
 
 				// Group attributes into nested objects, e.g. for GCP structured logs.
-				if s.GroupDelimiter != "" {
-					logkvs = groupKVs(logkvs, s.GroupDelimiter)
+				if s.grouped() {
+					logkvs = groupKVs(logkvs, s)
 				}
 
-				msg := fmt.Sprintf("%s %s => HTTP %v (%v)", r.Method, r.URL, statusCode, duration)
-				if lvl == 0 { // error
-					logger.Error(nil, msg, logkvs...)
-				} else {
-					logger.Info(msg, logkvs...)
+				stats.requestsLogged.Add(1)
+				stats.bodyBytesCaptured.Add(uint64(reqBody.Len() + respBody.buf.Len()))
+
+				emit(entryLogger.V(o.Levels.v(entry.Level)), entry.Level, entry.Err, entry.Message, logkvs, o)
+				if o.AfterEmit != nil {
+					rl.callHook("AfterEmit", func() { o.AfterEmit(entry) })
+				}
+
+				if o.SplitBodies && len(bodyKVs) > 0 {
+					linkKVs := []any{s.RequestSequence, sequence}
+					if id := requestID(ctx, r); id != "" {
+						linkKVs = append(linkKVs, s.RequestID, id)
+					}
+					logBodyEntries(entryLogger, bodyKVs, linkKVs, s, o)
 				}
 			}()
 
-			next.ServeHTTP(ww, r.WithContext(ctx))
+			serve := func(ctx context.Context) *http.Request {
+				served = r.WithContext(ctx)
+				next.ServeHTTP(rw, served)
+				return served
+			}
+			if o.TraceTasks {
+				serveTraced := serve
+				serve = func(ctx context.Context) *http.Request {
+					withTraceTask(ctx, r, o, func(ctx context.Context) *http.Request {
+						return serveTraced(ctx)
+					})
+					return served
+				}
+			}
+			if o.PprofLabels {
+				withPprofLabels(ctx, r, o, func(ctx context.Context) {
+					serve(ctx)
+				})
+				return
+			}
+			serve(ctx)
 		})
 	}
 }
 
+// statusLevel returns the log level of the request log by its response status:
+// 0 error, -1 warning, -2 info, -3 debug.
+func statusLevel(statusCode int, method string, o *Options) int {
+	switch {
+	case statusCode >= 500:
+		return 0 // error
+	case statusCode == 429:
+		return -2 // info
+	case statusCode >= 400:
+		return -1 // warning
+	case methodPolicy(method, o) == MethodPolicyDebug:
+		return -3 // debug
+	default:
+		return -2
+	}
+}
+
 func appendKVs(kvpairs []any, newkvs ...any) []any {
 	kvpairs = append(kvpairs, newkvs...)
 	return kvpairs
 }
 
-func groupKVs(kvs []any, delimiter string) []any {
-	var result []any
-	var nested = map[string][]any{}
+// dedupeKVs removes the duplicate keys, keeping the last value of each key at
+// the position of its first occurrence.
+func dedupeKVs(kvs []any) []any {
+	index := make(map[string]int, len(kvs)/2)
+	result := make([]any, 0, len(kvs))
+	for i := 0; i+1 < len(kvs); i += 2 {
+		key, ok := kvs[i].(string)
+		if !ok {
+			result = append(result, kvs[i], kvs[i+1])
+			continue
+		}
+		if j, ok := index[key]; ok {
+			result[j+1] = kvs[i+1]
+			continue
+		}
+		index[key] = len(result)
+		result = append(result, key, kvs[i+1])
+	}
+	return result
+}
 
-	for i, v := range kvs {
-		if i%2 == 0 {
-			str, ok := v.(string)
-			if !ok {
-				str = ""
-			}
-			prefix, key, found := strings.Cut(str, delimiter)
-			if !found {
-				result = append(result, str)
-				continue
+// groupKVs nests the keys into objects by the delimiter of each field, see
+// Schema.FieldDelimiter.
+func groupKVs(kvs []any, s *Schema) []any {
+	if len(s.FieldDelimiters) == 0 {
+		return groupByDelimiter(kvs, s.GroupDelimiter, s.RootFields...)
+	}
+
+	paths := make([][]string, 0, len(kvs)/2)
+	values := make([]any, 0, len(kvs)/2)
+	for i := 0; i+1 < len(kvs); i += 2 {
+		str, ok := kvs[i].(string)
+		if !ok {
+			str = ""
+		}
+		path := []string{str}
+		if delimiter := s.FieldDelimiter(str); delimiter != "" {
+			path = strings.Split(str, delimiter)
+		}
+		paths = append(paths, path)
+		values = append(values, kvs[i+1])
+	}
+	return groupPaths(paths, values)
+}
+
+// groupByDelimiter nests the keys containing the delimiter into objects, except
+// the root keys, which are kept at the top level, see Schema.RootFields. It's the
+// fast path of groupKVs for the schemas without Schema.FieldDelimiters.
+func groupByDelimiter(kvs []any, delimiter string, root ...string) []any {
+	result := make([]any, 0, len(kvs))
+	var nested map[string][]any
+	var prefixes []string
+
+	for i := 0; i+1 < len(kvs); i += 2 {
+		str, ok := kvs[i].(string)
+		if !ok {
+			str = ""
+		}
+		prefix, key, found := strings.Cut(str, delimiter)
+		if !found || slices.Contains(root, str) {
+			// Reuse the boxed key, if it's a string.
+			if ok {
+				result = append(result, kvs[i], kvs[i+1])
+			} else {
+				result = append(result, str, kvs[i+1])
 			}
-			nested[prefix] = append(nested[prefix], key, kvs[i+1])
+			continue
+		}
+		if nested == nil {
+			nested = map[string][]any{}
+		}
+		if _, ok := nested[prefix]; !ok {
+			prefixes = append(prefixes, prefix)
+		}
+		nested[prefix] = append(nested[prefix], key, kvs[i+1])
+	}
+
+	for _, prefix := range prefixes {
+		result = append(result, prefix, nestKVs(groupByDelimiter(nested[prefix], delimiter)))
+	}
+
+	return result
+}
+
+// groupPaths nests the values with multi-element paths into objects by the
+// first path element, in the order of appearance.
+func groupPaths(paths [][]string, values []any) []any {
+	var result []any
+	var prefixes []string
+	var nestedPaths = map[string][][]string{}
+	var nestedValues = map[string][]any{}
+
+	for i, path := range paths {
+		if len(path) == 1 {
+			result = append(result, path[0], values[i])
+			continue
 		}
+		prefix := path[0]
+		if _, ok := nestedPaths[prefix]; !ok {
+			prefixes = append(prefixes, prefix)
+		}
+		nestedPaths[prefix] = append(nestedPaths[prefix], path[1:])
+		nestedValues[prefix] = append(nestedValues[prefix], values[i])
 	}
 
-	for prefix, kvs := range nested {
-		result = append(result, prefix, nestKVs(kvs))
+	for _, prefix := range prefixes {
+		result = append(result, prefix, nestKVs(groupPaths(nestedPaths[prefix], nestedValues[prefix])))
 	}
 
 	return result
@@ -225,31 +1156,34 @@ func nestKVs(kvs []any) map[string]any {
 	return m
 }
 
-func getHeaderKVs(header http.Header, headers []string) []any {
-	kvs := make([]any, 0, len(headers))
-	for _, h := range headers {
-		vals := header.Values(h)
-		if len(vals) == 1 {
-			kvs = append(kvs, h, vals[0])
-		} else if len(vals) > 1 {
-			kvs = append(kvs, h, vals)
-		}
-	}
-	return kvs
-}
-
 func logBody(body *bytes.Buffer, header http.Header, o *Options) string {
 	if body.Len() == 0 {
 		return ""
 	}
 	contentType := header.Get("Content-Type")
-	for _, whitelisted := range o.LogBodyContentTypes {
-		if strings.HasPrefix(contentType, whitelisted) {
-			if o.LogBodyMaxLen <= 0 || o.LogBodyMaxLen >= body.Len() {
-				return body.String()
+	if !loggableContentType(contentType, o) {
+		return redactedBody(contentType)
+	}
+	b := body.Bytes()
+	if len(o.RedactBodyFields) > 0 {
+		b = redactBody(b, contentType, o)
+	}
+	if o.LogBodyMaxLen <= 0 || o.LogBodyMaxLen >= len(b) {
+		return string(b)
+	}
+	return string(b[:o.LogBodyMaxLen]) + "... [trimmed]"
+}
+
+// allowedMethods returns the methods listed by the Allow response header(s),
+// e.g. set by chi for HTTP 405 responses.
+func allowedMethods(header http.Header) []string {
+	var methods []string
+	for _, v := range header.Values("Allow") {
+		for _, m := range strings.Split(v, ",") {
+			if m = strings.TrimSpace(m); m != "" {
+				methods = append(methods, m)
 			}
-			return body.String()[:o.LogBodyMaxLen] + "... [trimmed]"
 		}
 	}
-	return fmt.Sprintf("[body redacted for Content-Type: %s]", contentType)
+	return methods
 }
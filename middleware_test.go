@@ -0,0 +1,173 @@
+package httplog_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-logr/logr"
+	httplog "github.com/rickliujh/chi-httplogr/v3"
+	"github.com/rickliujh/chi-httplogr/v3/httplogtest"
+)
+
+// TestSharedOptions serves concurrent requests by two routers sharing one
+// Options and logger; run it with -race.
+func TestSharedOptions(t *testing.T) {
+	rec := httplogtest.NewRecorder()
+	// The zero LogBodyContentTypes and LogBodyMaxLen are defaulted by RequestLogger.
+	opts := &httplog.Options{Schema: httplog.SchemaECS}
+	want := *opts
+
+	var routers []http.Handler
+	for i := 0; i < 2; i++ {
+		r := chi.NewRouter()
+		r.Use(httplog.RequestLogger(rec.Logger(), opts))
+		r.Get("/fail", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		})
+		routers = append(routers, r)
+	}
+
+	const requests = 50
+	var wg sync.WaitGroup
+	for _, r := range routers {
+		for i := 0; i < requests; i++ {
+			wg.Add(1)
+			go func(r http.Handler) {
+				defer wg.Done()
+				r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/fail", nil))
+			}(r)
+		}
+	}
+	wg.Wait()
+
+	if !reflect.DeepEqual(*opts, want) {
+		t.Errorf("Options mutated by RequestLogger:\ngot  %+v\nwant %+v", *opts, want)
+	}
+	if got := len(rec.Entries()); got != len(routers)*requests {
+		t.Errorf("got %d entries, want %d", got, len(routers)*requests)
+	}
+}
+
+func TestRequestDeadline(t *testing.T) {
+	rec := httplogtest.NewRecorder()
+	r := chi.NewRouter()
+	r.Use(middleware.Timeout(time.Minute))
+	r.Use(httplog.RequestLogger(rec.Logger(), &httplog.Options{Schema: httplog.SchemaECS}))
+	r.Get("/fail", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	_, entry := rec.RoundTrip(r, httptest.NewRequest(http.MethodGet, "/fail", nil))
+	if v, ok := entry.Value(httplog.SchemaECS.RequestDeadline); !ok || v.(float64) < 59000 {
+		t.Errorf("got deadline %v, want about 60000; entry: %v", v, entry.KVs)
+	}
+}
+
+func TestRouteVisibility(t *testing.T) {
+	rec, routed := httplogtest.NewRecorder(), httplogtest.NewRecorder()
+	handler := httplog.RequestLogger(rec.Logger(), &httplog.Options{
+		Visibility: 1,
+		Levels:     &httplog.Levels{Error: 2, Warn: 2, Info: 2, Debug: 2},
+		Route:      func(e *httplog.Entry) logr.Logger { return routed.Logger() },
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	entries := routed.Entries()
+	if len(entries) != 1 {
+		t.Fatalf("got %d routed entries, want 1", len(entries))
+	}
+	// Visibility 1 and the info level 2.
+	if entries[0].Level != 3 {
+		t.Errorf("got level %d, want 3", entries[0].Level)
+	}
+}
+
+func TestEventOutcome(t *testing.T) {
+	tests := []struct {
+		status int
+		want   string
+	}{
+		{http.StatusOK, "success"},
+		{http.StatusNotFound, "success"},
+		{http.StatusBadGateway, "failure"},
+	}
+	for _, tt := range tests {
+		rec := httplogtest.NewRecorder()
+		status := tt.status
+		handler := httplog.RequestLogger(rec.Logger(), &httplog.Options{
+			Schema: httplog.SchemaECS,
+			Levels: &httplog.Levels{Warn: 1, Info: 1, Debug: 1},
+		})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(status)
+		}))
+
+		_, entry := rec.RoundTrip(handler, httptest.NewRequest(http.MethodGet, "/", nil))
+		if !entry.HasKV(httplog.SchemaECS.EventOutcome, tt.want) {
+			t.Errorf("status %d: want outcome %q; entry: %v", tt.status, tt.want, entry.KVs)
+		}
+	}
+}
+
+func TestLogRequestID(t *testing.T) {
+	for _, logRequestID := range []bool{false, true} {
+		rec := httplogtest.NewRecorder()
+		handler := middleware.RequestID(httplog.RequestLogger(rec.Logger(), &httplog.Options{
+			Schema:       httplog.SchemaECS,
+			Levels:       &httplog.Levels{Warn: 1, Info: 1, Debug: 1},
+			LogRequestID: logRequestID,
+		})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+
+		_, entry := rec.RoundTrip(handler, httptest.NewRequest(http.MethodGet, "/", nil))
+		if _, ok := entry.Value(httplog.SchemaECS.RequestID); ok != logRequestID {
+			t.Errorf("LogRequestID %v: got request ID logged %v; entry: %v", logRequestID, ok, entry.KVs)
+		}
+	}
+}
+
+func TestSkipNested(t *testing.T) {
+	for _, skip := range []bool{false, true} {
+		rec := httplogtest.NewRecorder()
+		opts := &httplog.Options{
+			Levels:     &httplog.Levels{Warn: 1, Info: 1, Debug: 1},
+			SkipNested: skip,
+		}
+		handler := httplog.RequestLogger(rec.Logger(), opts)(
+			httplog.RequestLogger(rec.Logger(), opts)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+		var requestLogs int
+		for _, entry := range rec.Entries() {
+			if strings.HasPrefix(entry.Message, "GET /") {
+				requestLogs++
+			}
+		}
+		if want := map[bool]int{false: 2, true: 1}[skip]; requestLogs != want {
+			t.Errorf("SkipNested %v: got %d request logs, want %d", skip, requestLogs, want)
+		}
+	}
+}
+
+func TestHookErrorsOfLateHooks(t *testing.T) {
+	rec := httplogtest.NewRecorder()
+	handler := httplog.RequestLogger(rec.Logger(), &httplog.Options{
+		Schema: httplog.SchemaECS,
+		Levels: &httplog.Levels{Warn: 1, Info: 1, Debug: 1},
+		Processors: []httplog.EntryProcessor{
+			httplog.EntryProcessorFunc(func(e *httplog.Entry) *httplog.Entry { panic("processor") }),
+		},
+		Route: func(e *httplog.Entry) logr.Logger { panic("route") },
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	_, entry := rec.RoundTrip(handler, httptest.NewRequest(http.MethodGet, "/", nil))
+	hookErrors, _ := entry.Value(httplog.SchemaECS.HookErrors)
+	if errs, _ := hookErrors.([]string); len(errs) != 2 {
+		t.Errorf("got hook errors %v, want the Processors and Route panics", hookErrors)
+	}
+}
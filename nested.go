@@ -0,0 +1,17 @@
+package httplog
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// nestedRequestLogger reports whether the request is already logged by another
+// request logger, i.e. by RequestLogger or by chi's middleware.Logger (or
+// middleware.RequestLogger, incl. NewLogFormatter).
+func nestedRequestLogger(r *http.Request) bool {
+	if _, ok := r.Context().Value(ctxKeyRequestLog{}).(*requestLog); ok {
+		return true
+	}
+	return middleware.GetLogEntry(r) != nil
+}
@@ -1,9 +1,18 @@
 package httplog
 
 import (
+	"context"
 	"net/http"
+	"time"
+
+	"github.com/go-logr/logr"
 )
 
+// Options configures the request logger.
+//
+// Panics of the hooks (e.g. Skip, LogExtraAttrs) are recovered and logged as
+// Schema.HookErrors, see HookError. The panics of AfterEmit, which is called
+// after the entry was written, are only counted in Stats.HookPanics.
 type Options struct {
 	// Visibility defines the verbosity of the request logs:
 	// -3 Debug - log both request starts & responses (incl. OPTIONS)
@@ -12,6 +21,20 @@ type Options struct {
 	// 0 Error - log 5xx responses only
 	Visibility int
 
+	// Levels are the V-levels of the request logs by severity, compared to the
+	// verbosity of the logger, e.g. to align them with the verbosity conventions
+	// of the logr sink (zapr, stdr, ...). The request logs are written at these
+	// V-levels. All the levels are used as set, so copy httplog.DefaultLevels to
+	// change some of them, e.g.:
+	//
+	//	levels := httplog.DefaultLevels
+	//	levels.Info = 1
+	//	opts := &httplog.Options{Levels: &levels}
+	//
+	// If not provided, the default is httplog.DefaultLevels, i.e. 0 error, -1 warn,
+	// -2 info and -3 debug.
+	Levels *Levels
+
 	// Schema defines the mapping of semantic log fields to their corresponding
 	// field names in different logging systems and standards.
 	//
@@ -21,29 +44,414 @@ type Options struct {
 	// httplog.SchemaECS (Elastic Common Schema)
 	// httplog.SchemaOTEL (OpenTelemetry)
 	// httplog.SchemaGCP (Google Cloud Platform)
+	// httplog.SchemaCEF (ArcSight CEF / QRadar LEEF)
+	// httplog.SchemaSplunkCIM (Splunk Common Information Model)
 	//
 	// Append .Concise(true) to reduce log verbosity (e.g. for localhost development).
 	Schema *Schema
 
+	// EmitSchemaCheck emits one synthetic entry with all fields of the schema when
+	// the middleware is created, see EmitSchemaCheck.
+	EmitSchemaCheck bool
+
+	// PerHost defines the options of the requests by the host name (without port,
+	// matched case-insensitively), for servers of multiple virtual hosts, so that
+	// different sites get different schemas, sampling or redaction from one
+	// middleware mount. The requests of other hosts are logged with these options.
+	//
+	// The options of the hosts are complete on their own, i.e. they're not merged
+	// with these options, and their PerHost is ignored.
+	PerHost map[string]*Options
+
 	// RecoverPanics recovers from panics occurring in the underlying HTTP handlers
 	// and middlewares and returns HTTP 500 unless response status was already set.
 	//
 	// NOTE: Panics are logged as errors automatically, regardless of this setting.
 	RecoverPanics bool
 
+	// ClassifyError is an optional function classifying the last error set by
+	// SetError, or ErrClientAborted for client aborts, so that domain errors (e.g.
+	// validation vs dependency timeout vs auth) get consistent Schema.ErrorType
+	// values and log levels across services: 0 error, -1 warn, -2 info, -3 debug.
+	// The level overrides the level derived from the response status.
+	//
+	// An empty errType keeps the default error type, if any.
+	ClassifyError func(err error) (errType string, lvl int)
+
+	// HandlerAbortedLevel is the log level of the requests aborted by the handler
+	// panicking with http.ErrAbortHandler, e.g. by httputil.ReverseProxy, which are
+	// logged with Schema.ErrorType "HandlerAborted" and the response progress:
+	// 0 error (default), -1 warn, -2 info, -3 debug. Lower it for proxies aborting
+	// the responses intentionally.
+	HandlerAbortedLevel int
+
+	// ClientClosedStatus is the response status logged for the requests aborted by
+	// the client before the handler wrote the response status, e.g.
+	// StatusClientClosedRequest (the nginx-style HTTP 499), so that dashboards
+	// don't count the aborted requests as successes. The status is only logged;
+	// nothing is written to the closed connection.
+	//
+	// If not provided, such requests are logged with HTTP 200, the status net/http
+	// would have sent.
+	ClientClosedStatus int
+
+	// SkipNested skips logging of the requests already logged by another request
+	// logger, i.e. a second instance of RequestLogger or chi's middleware.Logger,
+	// as duplicate request logs are usually a misconfiguration.
+	//
+	// By default, such requests are logged twice, and the nested request logger
+	// logs a one-time warning.
+	SkipNested bool
+
+	// OnPanic is an optional hook called with the panics recovered from the
+	// underlying HTTP handlers, e.g. to report them to an error tracker. It's
+	// called regardless of RecoverPanics, except for http.ErrAbortHandler.
+	OnPanic func(req *http.Request, err *PanicError)
+
+	// Clock is an optional source of the current time used to measure the request
+	// duration. Inject a fake clock (e.g. httplogtest.NewClock) for deterministic tests.
+	//
+	// If not provided, the system clock is used.
+	Clock Clock
+
 	// Skip is an optional predicate function that determines whether to skip
 	// recording logs for a given request.
 	//
 	// If nil, all requests are recorded.
 	// If provided, requests where Skip returns true will not be recorded.
+	//
+	// Use httplog.Tenant(req.Context()) to skip or down-sample a noisy tenant.
 	Skip func(req *http.Request, respStatus int) bool
 
+	// OnlyErrors records logs of failed requests only, i.e. HTTP 4xx and 5xx
+	// responses, panics and requests aborted by the client.
+	OnlyErrors bool
+
+	// Sampler is an optional sampler of the logs of successful requests, e.g.
+	// httplog.NewAdaptiveSampler(100). Logs of failed requests, i.e. HTTP 4xx and
+	// 5xx responses, panics and requests aborted by the client, are always recorded.
+	// A TenantSampler also keys its decisions by the tenant ID, see TenantFunc.
+	//
+	// If not provided, all requests are recorded.
+	Sampler Sampler
+
+	// SamplingHeader is an optional header, e.g. "X-Log-Sampling", propagating the
+	// sampling decision between services, so that the logs of one request are
+	// sampled consistently. The header value is the sampling rate if the request
+	// is sampled, or "0" otherwise. The decision of the upstream service is only
+	// honored for requests accepted by TrustUpstream, since any client can send the
+	// header to suppress its logs; the decision is propagated downstream by
+	// UpstreamTransport.
+	//
+	// The sampling decision is logged as Schema.SamplingRate and Schema.SamplingSampled.
+	SamplingHeader string
+
+	// TrustUpstream is an optional function reporting whether the request comes
+	// from a trusted upstream service, e.g. by its mTLS peer certificate or its
+	// remote address, so that its SamplingHeader and its priority (see
+	// LowPrioritySampleRate) are honored.
+	//
+	// If not provided, no request is trusted.
+	TrustUpstream func(r *http.Request) bool
+
+	// ParentRequestIDHeader is an optional header, e.g. "X-Parent-Request-Id",
+	// correlating the child requests made by the handlers to their parent request,
+	// so that fan-out call trees can be reconstructed from the logs without
+	// tracing. UpstreamTransport sets the header of the child requests to the
+	// request ID, and the header value of the incoming requests is logged as
+	// Schema.RequestParentID.
+	ParentRequestIDHeader string
+
+	// ErrorBurstThreshold emits a distinct summary entry with Schema.ErrorBurst,
+	// once a route reaches the given number of HTTP 5xx responses within the
+	// ErrorBurstWindow, giving log-based alerting a cleaner signal than counting
+	// the individual request logs. The summary is emitted once per window.
+	//
+	// If not provided, the bursts are not detected.
+	ErrorBurstThreshold int
+
+	// ErrorBurstWindow is the window of ErrorBurstThreshold.
+	//
+	// If not provided, the default is 1 minute.
+	ErrorBurstWindow time.Duration
+
+	// SecurityLogger emits an additional security audit entry to the given logger
+	// for each request failing authentication or authorization, i.e. recorded by
+	// SetAuthResult with AuthFailure or responded with HTTP 401 or 403, with the
+	// client IP and User-Agent, the target resource and the authentication result.
+	// The entries are emitted regardless of the sampling, the level filters and
+	// Skip of the request logs, so that auth failures are never sampled away.
+	//
+	// If not provided, no security audit entries are emitted.
+	SecurityLogger logr.Logger
+
+	// ErrorDedupWindow collapses identical error logs (HTTP 5xx and panics) of the
+	// same route, status and error message within the window: only the first one
+	// is recorded, and once the window expires, the number of collapsed logs is
+	// reported as Schema.RepeatCount by a summary entry with the route, status and
+	// error message. This prevents a dependency outage from emitting millions of
+	// identical HTTP 503 logs.
+	//
+	// If not provided, error logs are not deduplicated.
+	ErrorDedupWindow time.Duration
+
+	// SkipCORSPreflight skips recording logs for CORS preflight requests, which are
+	// pure noise in most access logs. Use httplog.CORSType in Skip for finer control.
+	SkipCORSPreflight bool
+
+	// OptionsPolicy defines how the logs of successful OPTIONS requests are
+	// recorded, e.g. httplog.MethodPolicySkip for APIs behind gateways generating
+	// lots of preflight requests.
+	//
+	// If not provided, OPTIONS requests are recorded at the debug level.
+	OptionsPolicy MethodPolicy
+
+	// HeadPolicy defines how the logs of successful HEAD requests are recorded,
+	// e.g. httplog.MethodPolicySample for health checks of load balancers.
+	//
+	// If not provided, HEAD requests are recorded like any other request.
+	HeadPolicy MethodPolicy
+
+	// MethodSampleRate is the fraction of requests recorded by MethodPolicySample,
+	// between 0 and 1.
+	//
+	// If not provided, the default is 0.01.
+	MethodSampleRate float64
+
+	// LogPriority logs the priority class of the request, i.e. "high", "normal"
+	// or "low", as Schema.RequestPriority. It's parsed from the first of the
+	// PriorityHeaders found, or from the urgency of the Priority header (RFC
+	// 9218), where the urgencies 0 to 2 are high, 3 (default) normal and 4 to 7
+	// low. See also RequestPriority.
+	LogPriority bool
+
+	// PriorityHeaders is a list of custom request headers carrying the priority
+	// of the request, e.g. ["X-Priority"], with the values "high", "normal" or
+	// "low", or an RFC 9218 urgency from 0 to 7. They take precedence over the
+	// Priority header.
+	PriorityHeaders []string
+
+	// LowPrioritySampleRate is the fraction of the successful low-priority
+	// requests recorded, e.g. 0.01 for 1%, to log the low-priority traffic more
+	// aggressively sampled. The priority is set by the client, so it's only applied
+	// to requests accepted by TrustUpstream. The sampling rate is logged as
+	// Schema.SamplingRate.
+	//
+	// If not provided, the low-priority requests are recorded as any other.
+	LowPrioritySampleRate float64
+
+	// LogURLParts logs the structured parts of the request URL in addition to the
+	// full URL, i.e. Schema.RequestPort, Schema.RequestQuery (raw query string) and
+	// Schema.RequestFragment, and logs Schema.RequestHost without the port.
+	//
+	// WARNING: The query string may contain sensitive information, e.g. tokens.
+	LogURLParts bool
+
+	// LogQueryParams is a list of query parameters to be logged as a nested object
+	// Schema.RequestQueryParams, e.g. []string{"status", "page"}, so that requests
+	// can be filtered by them. Use "*" to log all parameters. The values of the
+	// RedactQueryParams are redacted.
+	//
+	// If not provided, no query parameters are logged.
+	LogQueryParams []string
+
+	// RedactQueryParams is a list of logged query parameters whose values are
+	// redacted, see RedactMode. The names are matched case-insensitively.
+	//
+	// If not provided, the default is ["token", "access_token", "id_token", "api_key",
+	// "apikey", "key", "password", "secret", "code", "sig", "signature"].
+	RedactQueryParams []string
+
+	// RefererPolicy defines how much of the Referer header is logged as
+	// Schema.RequestReferer: httplog.RefererFull, httplog.RefererNoQuery (without
+	// query string and fragment) or httplog.RefererOrigin (origin only).
+	//
+	// If not provided, the full Referer header is logged.
+	RefererPolicy RefererPolicy
+
+	// LogProxyHeaderDiff logs the names of the request headers added, removed and
+	// modified by the director of a reverse proxy instrumented by InstrumentProxy,
+	// as Schema.UpstreamHeaderDiff. Useful for debugging header-rewriting chains.
+	LogProxyHeaderDiff bool
+
+	// LogWireBytes logs the estimated size of the response status line and headers
+	// as Schema.ResponseHeaderBytes and the total size of the response on the wire
+	// as Schema.ResponseWireBytes, so that the bandwidth accounting from the logs
+	// matches the CDN and load balancer numbers more closely.
+	LogWireBytes bool
+
+	// LogThroughput logs the request and response throughput in bytes per second,
+	// i.e. the body sizes over the request duration, as Schema.RequestBytesPerSec
+	// and Schema.ResponseBytesPerSec, which tells slow clients apart from small
+	// responses. The request throughput is only logged for requests with a known
+	// Content-Length.
+	LogThroughput bool
+
+	// StallThreshold marks the responses whose first byte, i.e. the headers, was
+	// sent later than the threshold after the start of the request, by logging
+	// the time to the first byte in milliseconds as Schema.ResponseStalled, so
+	// that hung downstream dependencies show up explicitly in the logs rather
+	// than as large durations only.
+	//
+	// If not provided, the stalled responses are not marked.
+	StallThreshold time.Duration
+
+	// LogStreamMessages logs the number of messages of gRPC and gRPC-Web calls,
+	// and the number of frames of WebSocket connections, as Schema.RequestMessages
+	// (received) and Schema.ResponseMessages (sent), in place of the bodies, which
+	// are never captured for them. The counts are taken when the handler returns.
+	//
+	// The first close frame of WebSocket connections is logged as well, i.e. its
+	// status code, reason and sender as Schema.WebSocketCloseCode,
+	// Schema.WebSocketCloseReason and Schema.WebSocketCloseInitiator, or the
+	// status code 1006 (abnormal closure) if the connection was closed without
+	// close frames.
+	LogStreamMessages bool
+
+	// AccountBandwidth accounts the request and response bytes of all requests,
+	// incl. the ones not logged, per status class and route in the process-level
+	// stats, see ReadBandwidth and LogBandwidthSummary. The request bytes are the
+	// bytes read from the request body. The routes are labeled by MetricsLabel.
+	AccountBandwidth bool
+
+	// Summarizer aggregates all requests, incl. the ones not logged, per route
+	// into periodic summary entries, see NewSummarizer. The routes are labeled by
+	// MetricsLabel.
+	Summarizer *Summarizer
+
+	// LogQueueTime logs the time the request spent queued before reaching the app
+	// as Schema.RequestQueueTime, as recorded by the X-Request-Start or X-Queue-Start
+	// header of the load balancer (e.g. Heroku router, nginx "t=${msec}").
+	//
+	// Enable it only behind a proxy setting the header, as clients can spoof it.
+	LogQueueTime bool
+
+	// LogExpectContinue logs the 100-continue negotiation of requests with the
+	// "Expect: 100-continue" header, i.e. whether the interim "100 Continue" response
+	// was sent as Schema.RequestContinueSent and how long the client took to start
+	// sending the body after it as Schema.RequestContinueWait, to debug stalled
+	// uploads.
+	//
+	// The interim response is sent by net/http once the handler starts reading the
+	// request body, unless the final response was written before.
+	LogExpectContinue bool
+
+	// LogResponseController logs the changes made by the handler through
+	// http.ResponseController, i.e. the read and write deadlines relative to the
+	// request start as Schema.RequestReadDeadline and Schema.ResponseWriteDeadline
+	// (0 if cleared), and Schema.RequestFullDuplex, so that the behavior of
+	// streaming handlers is visible in the logs.
+	//
+	// The response writer passed to the handler then implements http.Flusher,
+	// http.Hijacker, http.Pusher and io.ReaderFrom regardless of the underlying
+	// writer; unsupported methods return http.ErrNotSupported.
+	LogResponseController bool
+
+	// LogWriterCapabilities logs the optional operations supported by the
+	// response writer passed to the handler, i.e. WriterFlush, WriterHijack and
+	// WriterPush, as Schema.ResponseWriterCapabilities, so that e.g. streaming
+	// handlers not flushing behind a wrapping middleware can be diagnosed.
+	LogWriterCapabilities bool
+
+	// FailUnsupportedWriterOps makes the response writer passed to the handler
+	// implement http.Flusher, and http.Hijacker on HTTP/1.x, regardless of the
+	// underlying writer, and panic with an UnsupportedWriterError when the
+	// handler calls an unsupported one, so that the request fails fast and is
+	// logged with the operation and the stack trace, instead of with an opaque
+	// error (e.g. http.ErrNotSupported from http.ResponseController) or a
+	// missing flush. Combine it with RecoverPanics to respond with HTTP 500.
+	//
+	// WARNING: Handlers detecting hijacking by the http.Hijacker interface, e.g.
+	// WebSocket libraries falling back to other transports, then see it on all
+	// HTTP/1.x writers. http.Pusher is only implemented if supported.
+	FailUnsupportedWriterOps bool
+
+	// LogCDNHeaders enables the preset capturing common CDN headers of Cloudflare,
+	// Fastly, Akamai and Amazon CloudFront. The original client IP (e.g. CF-Connecting-IP,
+	// True-Client-IP) and scheme (X-Forwarded-Proto) replace the logged connection's
+	// values, and the CDN request ID and edge are logged as Schema.CDNRayID and
+	// Schema.CDNEdge.
+	//
+	// WARNING: Enable only if the server is reachable through the CDN only, since
+	// the headers can be spoofed by clients otherwise.
+	LogCDNHeaders bool
+
 	// LogRequestHeaders is a list of headers to be logged as attributes.
 	// If not provided, the default is ["Content-Type", "Origin"].
 	//
+	// Use "*" to log all headers, or a prefix wildcard (e.g. "X-Internal-*") to log
+	// all headers with the prefix. The values of RedactHeaders are redacted.
+	//
 	// WARNING: Do not leak any request headers with sensitive information.
 	LogRequestHeaders []string
 
+	// RedactHeaders is a list of logged request and response headers whose values
+	// are redacted (see RedactMode), e.g. when logging all headers with "*".
+	//
+	// If not provided, the default is ["Authorization", "Proxy-Authorization",
+	// "Cookie", "Set-Cookie", "X-Api-Key"]. Set to an empty list to disable.
+	RedactHeaders []string
+
+	// RedactMode defines how the values of the headers, query parameters, cookies
+	// and body fields listed in RedactHeaders, RedactQueryParams, RedactCookies and
+	// RedactBodyFields are replaced: with the placeholder (default), the
+	// placeholder and the value length, or the HMAC of the value, see
+	// RedactModeHMAC. Multiple values are joined by ", " before being replaced.
+	RedactMode RedactMode
+
+	// RedactPlaceholder replaces the redacted values.
+	//
+	// If not provided, the default is "[REDACTED]".
+	RedactPlaceholder string
+
+	// RedactHMACKey is the secret key of RedactModeHMAC. Keep it stable across
+	// instances, so that the hashes can be compared across services.
+	//
+	// If not provided, RedactModeHMAC falls back to the placeholder.
+	RedactHMACKey []byte
+
+	// LowercaseHeaderKeys logs the header names lowercased (e.g. "content-type"),
+	// following the OpenTelemetry and ECS convention, instead of Go's canonical
+	// form (e.g. "Content-Type"). The configured header names are matched
+	// case-insensitively either way.
+	LowercaseHeaderKeys bool
+
+	// PrivacyFields are the high-entropy identifying fields replaced by their
+	// salted hashes, e.g. PrivacyUserAgent|PrivacyRemoteIP, so that the access
+	// logs can be retained long-term while equal values can still be counted
+	// and correlated. It doesn't apply to the headers logged by
+	// LogRequestHeaders, see RedactHeaders.
+	PrivacyFields PrivacyField
+
+	// PrivacySalt is the secret key of the PrivacyFields hashes. Keep it stable
+	// across instances, so that the hashes can be compared across services.
+	//
+	// WARNING: Without a salt, IP addresses can be recovered from the hashes by
+	// brute force.
+	PrivacySalt []byte
+
+	// VerboseIf is an optional function targeting requests to be logged verbosely,
+	// e.g. of users in a debug cohort: regardless of the log level, OnlyErrors and
+	// the Sampler, with the request and response bodies (unless SkipBody is called)
+	// and all headers (still redacted by RedactHeaders).
+	VerboseIf func(req *http.Request) bool
+
+	// SnapshotResponseHeaders logs the response headers as they were sent, i.e.
+	// a copy taken on the first write of the response (or on hijacking), instead
+	// of the headers after the handler returns, which miss the headers of
+	// hijacked connections and include headers mutated after the response was
+	// written.
+	SnapshotResponseHeaders bool
+
+	// ServerTiming adds the Server-Timing response header with the timings
+	// recorded by Mark and Span and the time elapsed until the response headers
+	// are sent as "total", so that the browser's developer tools show the same
+	// server-side timings as the logs. Only the timings recorded before the
+	// headers are sent are included.
+	//
+	// WARNING: The timings are exposed to the clients.
+	ServerTiming bool
+
 	// LogRequestBody is an optional predicate function that controls logging of request body.
 	//
 	// If the function returns true, the request body will be logged.
@@ -52,7 +460,78 @@ type Options struct {
 	// WARNING: Do not leak any request bodies with sensitive information.
 	LogRequestBody func(req *http.Request) bool
 
+	// GraphQLPaths is a list of URL paths of GraphQL endpoints, e.g. ["/graphql"].
+	// Requests to these endpoints are logged with the GraphQL operation name, type
+	// and truncated query hash, parsed from the request body or URL query.
+	GraphQLPaths []string
+
+	// ValidateRequestBody is an optional validator of JSON request bodies, e.g.
+	// against a JSON schema. The result is logged as Schema.RequestBodyValid and
+	// the validation error as Schema.RequestBodyError, which helps spotting
+	// malformed client payloads without enabling LogRequestBody.
+	//
+	// It's called with the captured body after the handler returns.
+	ValidateRequestBody func(req *http.Request, body []byte) error
+
+	// InspectRequestBody is an optional function that inspects the request body and
+	// returns key-value pairs to be added to the request log. It's called after the
+	// underlying HTTP handler returns, with the fully read request body.
+	//
+	// Use one of the presets, e.g. httplog.JSONRPCInspector(httplog.SchemaOTEL),
+	// to log the logical RPC method of RPC-over-POST traffic.
+	InspectRequestBody func(req *http.Request, body []byte) []any
+
+	// IdempotencyHeaders is a list of request headers carrying the idempotency key,
+	// which is logged as Schema.RequestIdempotencyKey.
+	//
+	// If not provided, the default is ["Idempotency-Key", "X-Idempotency-Key"].
+	IdempotencyHeaders []string
+
+	// ReplayStore is an optional store of the requests failed with HTTP 5xx,
+	// which are safe to be re-issued, i.e. with an idempotent method (GET, HEAD,
+	// OPTIONS, PUT or DELETE) or an idempotency key, see IdempotencyHeaders. The
+	// reference returned by the store is logged as Schema.RequestReplayRef, so
+	// that on-call engineers can re-issue the exact failing requests.
+	//
+	// The bodies of the replayable requests are captured in memory, up to
+	// MaxCaptureBytes. Bodies larger than MaxCaptureBytes, oversized bodies (see
+	// SkipOversizedBodies) and bodies spilled to a file (see SpillBodyThreshold)
+	// aren't stored.
+	ReplayStore ReplayStore
+
+	// LogRequestID logs the request ID set by chi's middleware.RequestID, or the
+	// X-Request-Id request header value, as Schema.RequestID.
+	LogRequestID bool
+
+	// IDGenerator is an optional generator of the IDs of the requests without a
+	// request ID, i.e. neither set by chi's middleware.RequestID nor by the
+	// X-Request-Id request header, e.g. httplog.UUIDv7, httplog.ULID or
+	// httplog.KSUID. The generated ID is set in the request context, so that
+	// middleware.GetReqID returns it, and logged with LogRequestID.
+	//
+	// If not provided, no request IDs are generated.
+	IDGenerator func() string
+
+	// EchoRequestIDHeader is an optional response header, e.g. "X-Request-Id",
+	// set to the request ID on every response, so that end users reporting
+	// errors can quote the ID of the request log. Requests without an ID (see
+	// IDGenerator) are assigned a short random debug token as their ID.
+	EchoRequestIDHeader string
+
+	// DuplicateCacheSize enables tagging of retried duplicate requests, i.e. requests
+	// with an idempotency key seen within the DuplicateWindow, as Schema.RequestDuplicate.
+	// It defines the number of recent idempotency keys to be remembered.
+	//
+	// If not provided, duplicate requests are not detected.
+	DuplicateCacheSize int
+
+	// DuplicateWindow defines how long the idempotency keys are remembered.
+	//
+	// If not provided, the default is 5 minutes.
+	DuplicateWindow time.Duration
+
 	// LogResponseHeaders controls a list of headers to be logged as attributes.
+	// It supports the same wildcards as LogRequestHeaders.
 	//
 	// If not provided, there are no default headers.
 	LogResponseHeaders []string
@@ -62,11 +541,44 @@ type Options struct {
 	// If the function returns true, the request body will be logged.
 	// If false, no request body will be logged.
 	//
+	// File downloads (Content-Disposition: attachment) and Content-Types not listed in
+	// LogBodyContentTypes are never buffered; the file name is logged instead.
+	// The bodies of HEAD responses, discarded by net/http, are never buffered
+	// either; their would-be Content-Length is logged as
+	// Schema.ResponseHeadContentLength instead.
+	//
 	// WARNING: Do not leak any response bodies with sensitive information.
 	LogResponseBody func(req *http.Request) bool
 
+	// LogResponseBodyStatus is an optional predicate function on the response status,
+	// e.g. func(status int) bool { return status >= 400 }. If set, the response body
+	// is logged only if the status qualifies (and LogResponseBody returns true, if
+	// provided). The body is captured only once a qualifying status was written,
+	// so the bodies of other responses are never copied.
+	LogResponseBodyStatus func(status int) bool
+
+	// LogProblemDetails logs the type, title and detail of RFC 7807 problem details
+	// responses (Content-Type: application/problem+json) as Schema.ErrorType,
+	// Schema.ErrorTitle and Schema.ErrorDetail, even if LogResponseBody is disabled.
+	LogProblemDetails bool
+
+	// ErrorMessageFields is an optional list of fields, e.g. []string{"error",
+	// "message", "detail"}. The value of the first field found in JSON responses
+	// with HTTP status >= 400 is logged as Schema.ResponseErrorMessage, even if
+	// LogResponseBody is disabled.
+	//
+	// If not provided, no error message is extracted.
+	ErrorMessageFields []string
+
+	// SplitBodies emits the logged request and response bodies as separate debug-level
+	// (V(1)) log entries, linked to the request log by the request ID and sequence
+	// number, instead of inlining them. This keeps the request log small and lets
+	// the bodies be routed and retained differently.
+	SplitBodies bool
+
 	// LogBodyContentTypes defines a list of body Content-Types that are safe to be logged
-	// with LogRequestBody or LogResponseBody options.
+	// with LogRequestBody or LogResponseBody options. The bodies of gRPC calls and
+	// WebSocket connections are never logged, see LogStreamMessages.
 	//
 	// If not provided, the default is ["application/json", "application/xml", "text/plain", "text/csv", "application/x-www-form-urlencoded", ""].
 	LogBodyContentTypes []string
@@ -76,6 +588,421 @@ type Options struct {
 	// If not provided, the default is 1024 bytes. Set to -1 to log the full body.
 	LogBodyMaxLen int
 
+	// RedactBodyFields is a list of fields of the logged JSON and form-encoded
+	// bodies whose values are redacted at any depth, see RedactMode. The names are
+	// matched case-insensitively. Bodies that can't be parsed are redacted as a
+	// whole, and JSON bodies with redacted fields are logged re-encoded.
+	//
+	// If not provided, no body fields are redacted.
+	RedactBodyFields []string
+
+	// MaxCaptureBytes is the maximum number of request body bytes captured in
+	// memory for GraphQLPaths, ValidateRequestBody, InspectRequestBody and
+	// ReplayStore, so that large request bodies are never fully buffered. The
+	// hooks get the truncated body, except for ValidateRequestBody and
+	// ReplayStore, which are skipped. The bodies captured only to be logged are
+	// limited to LogBodyMaxLen, and the bodies spilled to a file to
+	// SpillBodyThreshold. If LogBodyMaxLen is -1 or LogExtraAttrs is set, the full
+	// bodies are captured, as LogExtraAttrs gets the full body.
+	//
+	// If not provided, the default is 1 MiB.
+	MaxCaptureBytes int
+
+	// LogBodyWindows is an optional list of byte ranges of the logged request and
+	// response bodies, e.g. []httplog.BodyWindow{{0, 64}, {1024, 64}}, logged with
+	// their offsets and hex-encoded bytes as Schema.RequestBodyWindows and
+	// Schema.ResponseBodyWindows, e.g. to debug protocols with magic bytes. The
+	// windows are captured regardless of LogBodyContentTypes and LogBodyMaxLen.
+	LogBodyWindows []BodyWindow
+
+	// OversizedRequestBytes marks the requests with Content-Length larger than the
+	// threshold (in bytes), or with more body bytes read, e.g. chunked bodies
+	// without Content-Length, as Schema.RequestOversized.
+	//
+	// If not provided, the requests are not marked.
+	OversizedRequestBytes int64
+
+	// SkipOversizedBodies skips the capture of the bodies of oversized requests
+	// entirely, see OversizedRequestBytes, to protect the memory; bodies without
+	// Content-Length are captured up to the threshold and then dropped. Their
+	// bodies are neither logged nor passed to ValidateRequestBody,
+	// InspectRequestBody and LogExtraAttrs.
+	SkipOversizedBodies bool
+
+	// LogHeaderSize logs the estimated size of the request headers on the wire
+	// as Schema.RequestHeaderBytes and the number of header lines as
+	// Schema.RequestHeaderCount.
+	LogHeaderSize bool
+
+	// LargeHeaderBytes marks the requests with headers larger than the threshold
+	// (in bytes), e.g. with huge cookies or auth tokens, as
+	// Schema.RequestHeadersLarge, logs their header size (see LogHeaderSize) and
+	// raises their log level to warning at least, so that the clients at risk
+	// of HTTP 431 responses from load balancers can be identified. Like the
+	// failed requests, they are logged regardless of OnlyErrors and sampling.
+	//
+	// If not provided, the requests are not marked.
+	LargeHeaderBytes int
+
+	// MaxContextKVs limits the number of key-value pairs set per request by
+	// SetKVs and SetGroupKVs, so that a buggy loop can't produce huge request
+	// logs or grow the memory unbounded. The extra pairs are dropped and the
+	// request log is marked as Schema.ContextKVsTruncated.
+	//
+	// If not provided, the number of pairs isn't limited.
+	MaxContextKVs int
+
+	// MaxContextKVBytes limits the estimated size (in bytes) of the key-value
+	// pairs set per request by SetKVs and SetGroupKVs, see MaxContextKVs. Only
+	// the strings, byte slices and errors are measured; other values count as
+	// 8 bytes.
+	//
+	// If not provided, the size of pairs isn't limited.
+	MaxContextKVBytes int
+
+	// MaxGlobalConcurrentCaptures is a process-wide limit of the number of requests
+	// whose bodies are captured for logging or for the hooks reading them (see
+	// MaxCaptureBytes) at the same time, to protect the memory during traffic
+	// spikes. The captures of all request loggers in the process, e.g. of several
+	// routers, are counted together, and each request logger compares the total
+	// with its own limit, so set the same limit for all of them. The bodies of the
+	// requests beyond the limit are neither logged nor passed to the hooks, and the
+	// requests are marked by Schema.BodyCaptureSkipped.
+	//
+	// If not provided, the captures are not limited.
+	MaxGlobalConcurrentCaptures int
+
+	// TrackUnreadBody logs the number of request body bytes not read by the
+	// handler as Schema.RequestBytesUnread, also if the request body isn't
+	// captured, i.e. logged or passed to hooks like LogExtraAttrs. The rest of the
+	// body is read to count the bytes, unless SkipDrainBody is set.
+	TrackUnreadBody bool
+
+	// SkipDrainBody doesn't read the rest of the request body not read by the
+	// handler, e.g. of hostile uploads; net/http closes the connection instead.
+	// The unread bytes are derived from the Content-Length, and the logged request
+	// body is limited to the part read by the handler.
+	SkipDrainBody bool
+
+	// LogRequestTrailers logs the request trailers, e.g. checksums or signatures
+	// sent after the body by gRPC or upload clients, as a nested
+	// Schema.RequestTrailers object. The values of RedactHeaders are redacted.
+	// The trailers are only known if the request body was read to the end, i.e.
+	// by the handler or by draining the body, see SkipDrainBody.
+	LogRequestTrailers bool
+
+	// SpillBodyThreshold enables retaining the full request and response bodies
+	// being logged, e.g. for webhook debugging: bodies larger than the threshold
+	// (in bytes) are written to temporary files, referenced from the request log
+	// as Schema.RequestBodyRef and Schema.ResponseBodyRef, so that the memory
+	// stays bounded. Only the first bytes of the spilled bodies are passed to
+	// ValidateRequestBody, InspectRequestBody and LogExtraAttrs.
+	//
+	// The files are not removed by the middleware, unless SpillBodyTTL is set.
+	//
+	// If not provided, bodies are not spilled.
+	SpillBodyThreshold int
+
+	// SpillBodyDir is the directory of the spilled body files.
+	//
+	// If not provided, the default is os.TempDir().
+	SpillBodyDir string
+
+	// SpillBodyKey encrypts the spilled body files with AES-GCM, so that the
+	// captured payloads are encrypted at rest. It's an AES key of 16, 24 or 32
+	// bytes (AES-128, AES-192 or AES-256); use DecryptSpillBody to read the files.
+	// If the key is invalid, RequestLogger logs an error and disables spilling, so
+	// that the bodies are never written unencrypted.
+	//
+	// If not provided, the files are not encrypted.
+	SpillBodyKey []byte
+
+	// SpillBodyTTL removes the spilled body files once they're older than the
+	// TTL. The files of the middleware are removed by timers; files left behind
+	// by previous processes are removed from SpillBodyDir when the middleware is
+	// created.
+	//
+	// If not provided, the files are not removed.
+	SpillBodyTTL time.Duration
+
+	// IdentityFunc is an optional function that returns the identity of the user
+	// who made the request. It's evaluated after the underlying HTTP handler
+	// returns, so that auth middlewares and handlers had a chance to establish
+	// the identity, e.g. by storing a pointer in the request context upfront.
+	//
+	// The userID and username are logged as Schema.UserID and Schema.UserName
+	// (ECS "user.id" and "user.name"); empty values are omitted. The optional
+	// extra key-value pairs are appended to the request log as they are.
+	IdentityFunc func(req *http.Request) (userID, username string, extra []any)
+
+	// LogJWTClaims is a list of JWT claims (e.g. "sub", "iss", "aud", "scope") to be
+	// logged from the Authorization bearer token. The "sub" claim is logged as
+	// Schema.UserID and "preferred_username" as Schema.UserName, unless they are
+	// set by IdentityFunc, "iss" as Schema.UserIssuer, "aud" as Schema.UserAudience
+	// and "scope" as Schema.ClientScope; the other claims as Schema.UserClaims object.
+	//
+	// The token is parsed without verification and is never logged itself.
+	// If not provided, no claims are logged.
+	LogJWTClaims []string
+
+	// LogAPIKeyHeaders is a list of request headers carrying API keys or other client
+	// credentials, e.g. ["X-Api-Key"]. The first key found is logged as a short salted
+	// hash fingerprint (Schema.APIKeyHash), never as the raw value.
+	//
+	// If not provided, no fingerprint is logged.
+	LogAPIKeyHeaders []string
+
+	// APIKeySalt is the secret salt used to compute API key fingerprints.
+	//
+	// WARNING: Without a salt, short keys can be recovered from the fingerprint by brute force.
+	APIKeySalt string
+
+	// TenantFunc is an optional function that returns the tenant ID of the request,
+	// logged as Schema.TenantID. It's evaluated once, unless the tenant ID was set
+	// by httplog.SetTenant: before the sampling decision of a TenantSampler (see
+	// Sampler), or else after the underlying HTTP handler returns and before Skip.
+	TenantFunc func(req *http.Request) string
+
+	// UserAgentParser is an optional function that parses the User-Agent header into
+	// key-value pairs (e.g. browser, OS and device family), which are logged as
+	// Schema.RequestUserAgentDetails object. The boolean "is_bot" flag, if any, is
+	// logged as top-level Schema.ClientIsBot instead.
+	//
+	// Use the lightweight built-in httplog.ParseUserAgent, or plug in your own.
+	UserAgentParser func(userAgent string) []any
+
+	// DetectContentTypeMismatch sniffs the first bytes of textual responses (e.g.
+	// application/json) and logs the detected Content-Type as
+	// Schema.ResponseContentTypeMismatch, if it doesn't match the declared one,
+	// e.g. an HTML error page of a proxy served as application/json.
+	DetectContentTypeMismatch bool
+
+	// AuditSecurityHeaders is an optional list of security response headers, e.g.
+	// []string{"Strict-Transport-Security", "Content-Security-Policy",
+	// "X-Content-Type-Options"}. Headers missing in the response are logged as
+	// Schema.SecurityMissingHeaders, so that the rollout coverage of the headers
+	// can be monitored from the logs.
+	//
+	// If not provided, response headers are not audited.
+	AuditSecurityHeaders []string
+
+	// TrafficClassFunc is an optional function that classifies the request traffic,
+	// e.g. as real user or synthetic traffic, logged as Schema.TrafficClass. Use the
+	// built-in httplog.ClassifyTraffic, which recognizes Kubernetes probes, health
+	// checks, uptime checkers and bots, so that dashboards can filter out the noise.
+	//
+	// If not provided, the traffic is not classified.
+	TrafficClassFunc func(req *http.Request) string
+
+	// LogFingerprint logs a stable fingerprint of the request as
+	// Schema.RequestFingerprint, i.e. a hash of the method, the route pattern, the
+	// sorted request header names and the user agent, so that security teams can
+	// cluster scraping and abuse traffic across rotating client IPs.
+	LogFingerprint bool
+
+	// LogClientHints logs the User-Agent Client Hints of the request, i.e. the
+	// Sec-CH-UA brands, Sec-CH-UA-Platform and Sec-CH-UA-Mobile headers, as
+	// Schema.ClientBrands, Schema.ClientPlatform and Schema.ClientMobile. It's a
+	// structured alternative to UserAgentParser for Chromium-based browsers.
+	LogClientHints bool
+
+	// LogLocale logs the most preferred locale of the Accept-Language request header
+	// as Schema.ClientLocale.
+	LogLocale bool
+
+	// LogRequestEncoding logs the charset parameter of the request Content-Type
+	// header as Schema.RequestCharset and the Content-Language request header as
+	// Schema.RequestContentLanguage, e.g. to audit encoding problems reported by
+	// clients of internationalized APIs.
+	LogRequestEncoding bool
+
+	// RoutePattern is an optional provider of the route pattern of the request,
+	// logged as Schema.RequestRoute and used by route-based features.
+	//
+	// If not provided, httplog.DefaultRoutePattern is used, which supports both chi
+	// and http.ServeMux (Go 1.23+) patterns.
+	RoutePattern RoutePatternFunc
+
+	// LogRouteMount logs the mount prefix of the top-level chi sub-router that
+	// routed the request, e.g. "/api/v1" for r.Mount("/api/v1", apiRouter), as
+	// Schema.RequestRouteMount and the route pattern within the sub-router, e.g.
+	// "/users/{id}", as Schema.RequestRouteInner, so that the traffic can be
+	// attributed to the modules owning the sub-routers. Requests that weren't
+	// routed by a mounted sub-router are logged with Schema.RequestRoute only.
+	LogRouteMount bool
+
+	// SLOs are the service level objectives by route pattern, e.g.
+	// {"/users/{id}": {Latency: 300 * time.Millisecond}}. The requests of the
+	// routes are logged with Schema.SLOViolated (and Schema.SLOReason), so that
+	// the error budget burn can be computed directly from the logs.
+	SLOs map[string]SLO
+
+	// MetricsLabelFunc is an optional function that returns the route label of the
+	// request for metrics, see httplog.MetricsLabel. It must keep the label
+	// cardinality bounded.
+	//
+	// If not provided, the route pattern is used.
+	MetricsLabelFunc func(req *http.Request) string
+
+	// MetricsRoutes is an optional allowlist of route patterns used as metrics
+	// labels, see httplog.MetricsLabel. All other routes are bucketed as "other".
+	//
+	// If not provided, all route patterns are allowed.
+	MetricsRoutes []string
+
+	// PprofLabels runs the underlying HTTP handler with pprof labels for the route
+	// (see MetricsLabel) and request ID, so that CPU profiles can be sliced by
+	// endpoint and correlated back to the request logs (see LogRequestID).
+	PprofLabels bool
+
+	// TraceTasks runs the underlying HTTP handler within a runtime/trace task named
+	// after the route pattern, with the request ID attached as a trace event, which
+	// enables execution trace debugging of slow requests found in the logs.
+	TraceTasks bool
+
+	// MaxEntryBytes defines the maximum estimated size of the request log entry.
+	// Larger entries are trimmed, bodies first and then headers, with explicit
+	// "[trimmed]" markers, since many log backends silently drop large lines.
+	//
+	// If not provided, the entry size is not limited.
+	MaxEntryBytes int
+
+	// MaxAttrValueLen defines the maximum length of the string attribute values,
+	// incl. headers, user agent and values set by SetKVs. Longer values are cut
+	// to MaxAttrValueLen bytes, incl. a "[trimmed]" marker, without splitting a
+	// UTF-8 character. Bodies are limited by LogBodyMaxLen instead.
+	//
+	// If not provided, the attribute values are not limited.
+	MaxAttrValueLen int
+
+	// DedupeKeys enables last-write-wins semantics for the request log keys. When
+	// the same key is set multiple times, e.g. by SetKVs or LogExtraAttrs, only the
+	// last value is logged, at the position of the first occurrence. Some JSON
+	// encoders and log backends reject or silently drop duplicate keys.
+	DedupeKeys bool
+
+	// ValueEncoder normalizes the attribute values before they are passed to the
+	// logger, incl. the values of the nested objects, so that the output is
+	// consistent across logr sinks (zap, zerolog, slog, ...). Use
+	// httplog.DefaultValueEncoder, or wrap it to handle more types.
+	//
+	// If not provided, the values are passed as is.
+	ValueEncoder func(v any) any
+
+	// LogLabels logs the low-cardinality attributes of the request, i.e. method,
+	// route (see httplog.MetricsLabel), status class and the static Labels, as
+	// Schema.Labels object. Log shippers (e.g. promtail for Loki) can promote it
+	// to stream labels without exploding the label cardinality.
+	LogLabels bool
+
+	// Labels are static low-cardinality labels, e.g. {"service": "api"}, logged
+	// as part of Schema.Labels object if LogLabels is enabled.
+	Labels map[string]string
+
+	// TracePropagators is a list of trace context extractors, e.g.
+	// [httplog.TraceW3C, httplog.TraceAWS, httplog.TraceGCP, httplog.TraceAzure,
+	// httplog.TraceElasticAPM].
+	// The trace context found by the first matching propagator is logged as
+	// Schema.TraceID and Schema.SpanID.
+	//
+	// If not provided, no trace context is logged.
+	TracePropagators []TracePropagator
+
+	// TraceContext is an optional function returning the trace context of the
+	// request from the context of a tracing agent, e.g. the Elastic APM agent:
+	//
+	//	func(ctx context.Context) (traceID, transactionID, spanID string) {
+	//		tx := apm.TransactionFromContext(ctx)
+	//		if tx == nil {
+	//			return "", "", ""
+	//		}
+	//		tc := tx.TraceContext()
+	//		return tc.Trace.String(), tc.Span.String(), ""
+	//	}
+	//
+	// The trace context is logged as Schema.TraceID, Schema.TransactionID and
+	// Schema.SpanID, so that e.g. Kibana links the request logs to APM transactions.
+	// It takes precedence over TracePropagators.
+	TraceContext func(ctx context.Context) (traceID, transactionID, spanID string)
+
+	// GCPProjectID is the Google Cloud project ID of the traces. Use it with
+	// SchemaGCP: the trace IDs are logged as "projects/GCPProjectID/traces/ID",
+	// as required by Cloud Logging to correlate the logs with Cloud Trace.
+	//
+	// If not provided, the trace IDs are logged as is.
+	GCPProjectID string
+
+	// LogBaggage is a list of W3C Baggage keys, e.g. []string{"feature_flag",
+	// "synthetic"}, whose entries of the "baggage" request header are logged as
+	// a nested Schema.Baggage object. Use "*" to log all entries.
+	//
+	// If not provided, no baggage is logged.
+	LogBaggage []string
+
+	// LogCookies is a list of request cookies, e.g. []string{"ab_bucket", "locale"},
+	// logged as a nested Schema.RequestCookies object, so that the Cookie header,
+	// which is redacted by default, doesn't need to be logged. The names are matched
+	// case-sensitively.
+	//
+	// WARNING: Do not log cookies holding session IDs or other credentials,
+	// unless they are listed in RedactCookies.
+	//
+	// If not provided, no cookies are logged.
+	LogCookies []string
+
+	// RedactCookies is a list of logged cookies whose values are redacted, see
+	// RedactMode. The names are matched case-insensitively.
+	//
+	// If not provided, the default is ["session", "sessionid", "session_id", "sid",
+	// "token", "access_token", "refresh_token", "auth", "jwt"].
+	RedactCookies []string
+
+	// Processors process the request log entries in order before they are written,
+	// e.g. to redact, enrich, sample or route them as reusable units. A processor
+	// returning nil drops the entry.
+	Processors []EntryProcessor
+
+	// Route is an optional function returning the logger the entry is written to,
+	// e.g. an error logger with stack capturing sinks for HTTP 5xx entries or an
+	// audit logger for audit-worthy entries. It's called after the Processors, once
+	// the entry passed the level filters of the RequestLogger's logger. Returning a
+	// zero logr.Logger (incl. logr.Discard()) writes the entry to the RequestLogger's
+	// logger; use Processors to drop entries. The entry is written to the returned
+	// logger at the Visibility, like to the RequestLogger's logger.
+	//
+	// If not provided, all entries are written to the RequestLogger's logger.
+	Route func(e *Entry) logr.Logger
+
+	// EmitTimeout is the maximum time to wait for the log sink to write the request
+	// log, so that a blocked sink doesn't add to the request latency. The request
+	// log is still written once the sink unblocks, see MaxPendingEmits. The timeouts are counted in
+	// Stats.EmitTimeouts. Panics of the log sink are always recovered and counted
+	// in Stats.EmitPanics.
+	//
+	// If not provided, the request logger waits for the log sink.
+	EmitTimeout time.Duration
+
+	// MaxPendingEmits is the maximum number of log writes left pending in a blocked
+	// log sink after EmitTimeout, across all request loggers. Once reached, further
+	// log entries are dropped and counted in Stats.EmitsDropped, so that a blocked
+	// sink doesn't leak a goroutine per request.
+	//
+	// If not provided, the default is 1000.
+	MaxPendingEmits int
+
+	// AfterEmit is an optional function called after the entry was passed to the
+	// log sink, e.g. to update metrics or call webhooks on HTTP 5xx responses,
+	// based on the exact data that was logged. The entry holds the keys and values
+	// after the Processors, before Schema.HookErrors is appended and they are
+	// grouped by Schema.GroupDelimiter. It's called synchronously, so run slow
+	// actions in a goroutine.
+	//
+	// With EmitTimeout, AfterEmit is called once the sink wrote the entry or the
+	// timeout expired, so the write may still be pending in a blocked sink, or the
+	// entry may have been dropped (see MaxPendingEmits) or lost to a panicking
+	// sink; see Stats for these failures.
+	AfterEmit func(e *Entry)
+
 	// LogExtraAttrs is an optional function that lets you add extra attributes to the
 	// request log.
 	//
@@ -94,6 +1021,9 @@ type Options struct {
 	LogExtraAttrs func(req *http.Request, reqBody string, respStatus int) []any
 }
 
+// defaultMaxCaptureBytes is the default Options.MaxCaptureBytes.
+const defaultMaxCaptureBytes = 1 << 20
+
 var defaultOptions = Options{
 	Visibility:          0,
 	Schema:              SchemaECS,
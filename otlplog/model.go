@@ -0,0 +1,141 @@
+package otlplog
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// OTLP severity numbers, see https://opentelemetry.io/docs/specs/otel/logs/data-model/#field-severitynumber
+const (
+	severityDebug = 5
+	severityInfo  = 9
+	severityError = 17
+)
+
+// The types below follow the OTLP/HTTP JSON encoding of ExportLogsServiceRequest,
+// see https://opentelemetry.io/docs/specs/otlp/#json-protobuf-encoding
+
+type exportRequest struct {
+	ResourceLogs []resourceLogs `json:"resourceLogs"`
+}
+
+type resourceLogs struct {
+	Resource  resource    `json:"resource"`
+	ScopeLogs []scopeLogs `json:"scopeLogs"`
+}
+
+type resource struct {
+	Attributes []keyValue `json:"attributes,omitempty"`
+}
+
+type scopeLogs struct {
+	Scope      scope       `json:"scope"`
+	LogRecords []logRecord `json:"logRecords"`
+}
+
+type scope struct {
+	Name string `json:"name"`
+}
+
+type logRecord struct {
+	TimeUnixNano         string     `json:"timeUnixNano"`
+	ObservedTimeUnixNano string     `json:"observedTimeUnixNano"`
+	SeverityNumber       int        `json:"severityNumber"`
+	SeverityText         string     `json:"severityText"`
+	Body                 value      `json:"body"`
+	Attributes           []keyValue `json:"attributes,omitempty"`
+	TraceID              string     `json:"traceId,omitempty"`
+	SpanID               string     `json:"spanId,omitempty"`
+}
+
+type keyValue struct {
+	Key   string `json:"key"`
+	Value value  `json:"value"`
+}
+
+type value struct {
+	StringValue *string     `json:"stringValue,omitempty"`
+	BoolValue   *bool       `json:"boolValue,omitempty"`
+	IntValue    *string     `json:"intValue,omitempty"`
+	DoubleValue *float64    `json:"doubleValue,omitempty"`
+	ArrayValue  *arrayValue `json:"arrayValue,omitempty"`
+	KvlistValue *kvlist     `json:"kvlistValue,omitempty"`
+}
+
+type arrayValue struct {
+	Values []value `json:"values"`
+}
+
+type kvlist struct {
+	Values []keyValue `json:"values"`
+}
+
+// anyValue converts the log value to the OTLP AnyValue.
+func anyValue(v any) value {
+	switch v := v.(type) {
+	case string:
+		return value{StringValue: &v}
+	case bool:
+		return value{BoolValue: &v}
+	case int:
+		return intValue(int64(v))
+	case int32:
+		return intValue(int64(v))
+	case int64:
+		return intValue(v)
+	case uint:
+		return intValue(int64(v))
+	case uint32:
+		return intValue(int64(v))
+	case uint64:
+		if v > math.MaxInt64 {
+			return anyValue(strconv.FormatUint(v, 10))
+		}
+		return intValue(int64(v))
+	case float32:
+		f := float64(v)
+		return value{DoubleValue: &f}
+	case float64:
+		return value{DoubleValue: &v}
+	case time.Duration:
+		return intValue(v.Nanoseconds())
+	case error:
+		return anyValue(v.Error())
+	case fmt.Stringer:
+		return anyValue(v.String())
+	case []string:
+		values := make([]value, len(v))
+		for i, s := range v {
+			values[i] = anyValue(s)
+		}
+		return value{ArrayValue: &arrayValue{Values: values}}
+	case []any:
+		values := make([]value, len(v))
+		for i, s := range v {
+			values[i] = anyValue(s)
+		}
+		return value{ArrayValue: &arrayValue{Values: values}}
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		values := make([]keyValue, len(keys))
+		for i, k := range keys {
+			values[i] = keyValue{Key: k, Value: anyValue(v[k])}
+		}
+		return value{KvlistValue: &kvlist{Values: values}}
+	case nil:
+		return value{}
+	}
+	return anyValue(fmt.Sprint(v))
+}
+
+func intValue(n int64) value {
+	s := strconv.FormatInt(n, 10)
+	return value{IntValue: &s}
+}
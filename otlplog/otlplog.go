@@ -0,0 +1,343 @@
+// Package otlplog provides a logr.LogSink exporting the log entries directly as
+// OTLP log records over HTTP/JSON, so that request logs can be shipped to an
+// OpenTelemetry collector without an intermediate text encoding.
+//
+// Use it with httplog.SchemaOTEL, so that the request log attributes follow the
+// OpenTelemetry semantic conventions:
+//
+//	exporter := otlplog.NewExporter(otlplog.Options{
+//		Endpoint:    "http://localhost:4318/v1/logs",
+//		ServiceName: "example-app",
+//	})
+//	defer exporter.Close()
+//
+//	r.Use(httplog.RequestLogger(exporter.Logger(), &httplog.Options{
+//		Schema: httplog.SchemaOTEL,
+//	}))
+package otlplog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+// Options configures the Exporter.
+type Options struct {
+	// Endpoint is the OTLP/HTTP logs endpoint, e.g. "http://localhost:4318/v1/logs".
+	Endpoint string
+
+	// Headers are sent with each export request, e.g. for authentication.
+	Headers map[string]string
+
+	// ServiceName is set as the "service.name" resource attribute.
+	ServiceName string
+
+	// Client is the HTTP client used to export the records.
+	//
+	// If not provided, a client with 10 seconds timeout is used.
+	Client *http.Client
+
+	// BatchSize defines the number of records exported at once.
+	//
+	// If not provided, the default is 512.
+	BatchSize int
+
+	// FlushInterval defines how often the buffered records are exported.
+	//
+	// If not provided, the default is 5 seconds.
+	FlushInterval time.Duration
+
+	// MaxQueueSize is the maximum number of buffered records, incl. the records
+	// of failed exports kept to be retried. Records beyond it are dropped and
+	// counted, see Exporter.Dropped.
+	//
+	// If not provided, the default is 8 times the BatchSize.
+	MaxQueueSize int
+
+	// Verbosity is the maximum V-level of the exported entries, like the
+	// verbosity of funcr: entries logged with logger.V(n) for n > Verbosity are
+	// discarded.
+	//
+	// If not provided, only V(0) entries and errors are exported.
+	Verbosity int
+
+	// TraceIDKey and SpanIDKey are the keys of the hex-encoded trace context values,
+	// which are attached to the records as trace context instead of attributes.
+	//
+	// If not provided, the defaults are "trace_id" and "span_id".
+	TraceIDKey string
+	SpanIDKey  string
+
+	// OnError is an optional function called when an export fails.
+	OnError func(err error)
+}
+
+// Exporter buffers the log records and exports them in batches to the OTLP
+// endpoint. The records of failed exports are kept and retried with the next
+// export, within Options.MaxQueueSize.
+type Exporter struct {
+	opts Options
+
+	mu      sync.Mutex
+	records []logRecord
+	dropped atomic.Uint64
+
+	flush     chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+// NewExporter returns a new Exporter, which exports the records in the background
+// until closed.
+func NewExporter(opts Options) *Exporter {
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 512
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = 5 * time.Second
+	}
+	if opts.MaxQueueSize <= 0 {
+		opts.MaxQueueSize = 8 * opts.BatchSize
+	}
+	if opts.TraceIDKey == "" {
+		opts.TraceIDKey = "trace_id"
+	}
+	if opts.SpanIDKey == "" {
+		opts.SpanIDKey = "span_id"
+	}
+
+	e := &Exporter{
+		opts:  opts,
+		flush: make(chan struct{}, 1),
+		done:  make(chan struct{}),
+	}
+	e.wg.Add(1)
+	go e.run()
+	return e
+}
+
+// Logger returns a logger exporting its entries through the Exporter.
+func (e *Exporter) Logger() logr.Logger {
+	return logr.New(&sink{exporter: e})
+}
+
+// Flush exports all buffered records. If an export fails, the records not
+// exported yet are kept to be retried, and the error is returned.
+func (e *Exporter) Flush(ctx context.Context) error {
+	e.mu.Lock()
+	records := e.records
+	e.records = nil
+	e.mu.Unlock()
+
+	for len(records) > 0 {
+		n := min(len(records), e.opts.BatchSize)
+		if err := e.export(ctx, records[:n]); err != nil {
+			e.requeue(records)
+			return err
+		}
+		records = records[n:]
+	}
+	return nil
+}
+
+// Close stops the background exports and flushes the buffered records. The
+// records which can't be exported are dropped. Calling Close again only flushes
+// the records logged since.
+func (e *Exporter) Close() error {
+	e.closeOnce.Do(func() {
+		close(e.done)
+		e.wg.Wait()
+	})
+	err := e.Flush(context.Background())
+	if err != nil {
+		e.mu.Lock()
+		e.dropped.Add(uint64(len(e.records)))
+		e.records = nil
+		e.mu.Unlock()
+	}
+	return err
+}
+
+// Dropped returns the number of records dropped, as the queue was full or they
+// couldn't be exported on Close.
+func (e *Exporter) Dropped() uint64 {
+	return e.dropped.Load()
+}
+
+func (e *Exporter) run() {
+	defer e.wg.Done()
+
+	ticker := time.NewTicker(e.opts.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-e.done:
+			return
+		case <-ticker.C:
+		case <-e.flush:
+		}
+		if err := e.Flush(context.Background()); err != nil && e.opts.OnError != nil {
+			e.opts.OnError(err)
+		}
+	}
+}
+
+func (e *Exporter) enqueue(rec logRecord) {
+	e.mu.Lock()
+	if len(e.records) >= e.opts.MaxQueueSize {
+		e.mu.Unlock()
+		e.dropped.Add(1)
+		return
+	}
+	e.records = append(e.records, rec)
+	full := len(e.records) >= e.opts.BatchSize
+	e.mu.Unlock()
+
+	if full {
+		select {
+		case e.flush <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// requeue puts the records of a failed export back in front of the records
+// logged since, dropping the newest ones beyond Options.MaxQueueSize.
+func (e *Exporter) requeue(records []logRecord) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	queue := append(append([]logRecord(nil), records...), e.records...)
+	if excess := len(queue) - e.opts.MaxQueueSize; excess > 0 {
+		e.dropped.Add(uint64(excess))
+		queue = queue[:e.opts.MaxQueueSize]
+	}
+	e.records = queue
+}
+
+func (e *Exporter) export(ctx context.Context, records []logRecord) error {
+	var resourceAttrs []keyValue
+	if e.opts.ServiceName != "" {
+		resourceAttrs = append(resourceAttrs, keyValue{Key: "service.name", Value: anyValue(e.opts.ServiceName)})
+	}
+
+	payload, err := json.Marshal(exportRequest{
+		ResourceLogs: []resourceLogs{{
+			Resource: resource{Attributes: resourceAttrs},
+			ScopeLogs: []scopeLogs{{
+				Scope:      scope{Name: "github.com/rickliujh/chi-httplogr/v3"},
+				LogRecords: records,
+			}},
+		}},
+	})
+	if err != nil {
+		return fmt.Errorf("otlplog: encoding records: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.opts.Endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("otlplog: creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range e.opts.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := e.opts.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("otlplog: exporting %v records: %w", len(records), err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("otlplog: exporting %v records: HTTP %v", len(records), resp.StatusCode)
+	}
+	return nil
+}
+
+// sink is a logr.LogSink converting the log entries to OTLP log records.
+type sink struct {
+	exporter *Exporter
+	name     string
+	values   []any
+}
+
+var _ logr.LogSink = &sink{}
+
+func (s *sink) Init(info logr.RuntimeInfo) {}
+
+func (s *sink) Enabled(level int) bool {
+	return level <= s.exporter.opts.Verbosity
+}
+
+func (s *sink) Info(level int, msg string, keysAndValues ...any) {
+	severity, text := severityInfo, "INFO"
+	if level > 0 {
+		severity, text = severityDebug, "DEBUG"
+	}
+	s.exporter.enqueue(s.record(severity, text, msg, nil, keysAndValues))
+}
+
+func (s *sink) Error(err error, msg string, keysAndValues ...any) {
+	s.exporter.enqueue(s.record(severityError, "ERROR", msg, err, keysAndValues))
+}
+
+func (s *sink) WithValues(keysAndValues ...any) logr.LogSink {
+	values := append(append([]any(nil), s.values...), keysAndValues...)
+	return &sink{exporter: s.exporter, name: s.name, values: values}
+}
+
+func (s *sink) WithName(name string) logr.LogSink {
+	if s.name != "" {
+		name = s.name + "." + name
+	}
+	return &sink{exporter: s.exporter, name: name, values: s.values}
+}
+
+func (s *sink) record(severity int, severityText, msg string, err error, keysAndValues []any) logRecord {
+	now := strconv.FormatInt(time.Now().UnixNano(), 10)
+	rec := logRecord{
+		TimeUnixNano:         now,
+		ObservedTimeUnixNano: now,
+		SeverityNumber:       severity,
+		SeverityText:         severityText,
+		Body:                 anyValue(msg),
+	}
+	if s.name != "" {
+		rec.Attributes = append(rec.Attributes, keyValue{Key: "logger.name", Value: anyValue(s.name)})
+	}
+	if err != nil {
+		rec.Attributes = append(rec.Attributes, keyValue{Key: "exception.message", Value: anyValue(err.Error())})
+	}
+
+	for _, kvs := range [][]any{s.values, keysAndValues} {
+		for i := 0; i+1 < len(kvs); i += 2 {
+			key, ok := kvs[i].(string)
+			if !ok || key == "" {
+				continue
+			}
+			switch key {
+			case s.exporter.opts.TraceIDKey:
+				rec.TraceID = fmt.Sprint(kvs[i+1])
+			case s.exporter.opts.SpanIDKey:
+				rec.SpanID = fmt.Sprint(kvs[i+1])
+			default:
+				rec.Attributes = append(rec.Attributes, keyValue{Key: key, Value: anyValue(kvs[i+1])})
+			}
+		}
+	}
+	return rec
+}
@@ -0,0 +1,200 @@
+package otlplog_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rickliujh/chi-httplogr/v3/otlplog"
+)
+
+// collector is an OTLP/HTTP endpoint recording the exported records, failing
+// the exports while status is set to an error status.
+type collector struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	status  int
+	header  http.Header
+	records []map[string]any
+}
+
+func newCollector(t *testing.T) *collector {
+	c := &collector{status: http.StatusOK}
+	c.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if c.status != http.StatusOK {
+			w.WriteHeader(c.status)
+			return
+		}
+		var req struct {
+			ResourceLogs []struct {
+				Resource  map[string]any `json:"resource"`
+				ScopeLogs []struct {
+					LogRecords []map[string]any `json:"logRecords"`
+				} `json:"scopeLogs"`
+			} `json:"resourceLogs"`
+		}
+		body, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &req); err != nil {
+			t.Errorf("invalid export request %s: %v", body, err)
+		}
+		c.header = r.Header
+		for _, rl := range req.ResourceLogs {
+			for _, sl := range rl.ScopeLogs {
+				c.records = append(c.records, sl.LogRecords...)
+			}
+		}
+	}))
+	t.Cleanup(c.Close)
+	return c
+}
+
+func (c *collector) setStatus(status int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.status = status
+}
+
+func (c *collector) exported() []map[string]any {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.records
+}
+
+func newExporter(t *testing.T, c *collector, opts otlplog.Options) *otlplog.Exporter {
+	opts.Endpoint = c.URL
+	opts.FlushInterval = time.Hour
+	e := otlplog.NewExporter(opts)
+	t.Cleanup(func() { e.Close() })
+	return e
+}
+
+func TestRecord(t *testing.T) {
+	c := newCollector(t)
+	e := newExporter(t, c, otlplog.Options{ServiceName: "app", Headers: map[string]string{"Authorization": "Bearer token"}})
+
+	e.Logger().WithName("http").Error(errors.New("boom"), "GET /",
+		"trace_id", "0af7651916cd43dd8448eb211c80319c",
+		"span_id", "b7ad6b7169203331",
+		"str", "v", "int", 42, "bool", true, "float", 1.5, "duration", time.Millisecond,
+		"strings", []string{"a", "b"},
+		"map", map[string]any{"z": 1, "a": "x"},
+		"", "skipped",
+	)
+	if err := e.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	records := c.exported()
+	if len(records) != 1 {
+		t.Fatalf("got %d records, want 1", len(records))
+	}
+	rec := records[0]
+	if rec["severityText"] != "ERROR" || rec["severityNumber"] != float64(17) {
+		t.Errorf("unexpected severity: %v", rec)
+	}
+	if rec["traceId"] != "0af7651916cd43dd8448eb211c80319c" || rec["spanId"] != "b7ad6b7169203331" {
+		t.Errorf("trace context not attached: %v", rec)
+	}
+	if c.header.Get("Authorization") != "Bearer token" {
+		t.Errorf("headers not sent: %v", c.header)
+	}
+
+	attrs, _ := json.Marshal(rec["attributes"])
+	want := `[{"key":"logger.name","value":{"stringValue":"http"}},` +
+		`{"key":"exception.message","value":{"stringValue":"boom"}},` +
+		`{"key":"str","value":{"stringValue":"v"}},` +
+		`{"key":"int","value":{"intValue":"42"}},` +
+		`{"key":"bool","value":{"boolValue":true}},` +
+		`{"key":"float","value":{"doubleValue":1.5}},` +
+		`{"key":"duration","value":{"intValue":"1000000"}},` +
+		`{"key":"strings","value":{"arrayValue":{"values":[{"stringValue":"a"},{"stringValue":"b"}]}}},` +
+		`{"key":"map","value":{"kvlistValue":{"values":[{"key":"a","value":{"stringValue":"x"}},{"key":"z","value":{"intValue":"1"}}]}}}]`
+	if string(attrs) != want {
+		t.Errorf("got attributes\n%s\nwant\n%s", attrs, want)
+	}
+}
+
+func TestVerbosity(t *testing.T) {
+	c := newCollector(t)
+	e := newExporter(t, c, otlplog.Options{Verbosity: 1})
+
+	e.Logger().V(1).Info("body")
+	e.Logger().V(2).Info("debug")
+	if err := e.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if records := c.exported(); len(records) != 1 || records[0]["severityText"] != "DEBUG" {
+		t.Errorf("want the V(1) record only; got %v", records)
+	}
+}
+
+func TestExportFailure(t *testing.T) {
+	c := newCollector(t)
+	c.setStatus(http.StatusServiceUnavailable)
+	e := newExporter(t, c, otlplog.Options{BatchSize: 10, MaxQueueSize: 3})
+	logger := e.Logger()
+
+	logger.Info("1")
+	logger.Info("2")
+	if err := e.Flush(context.Background()); err == nil {
+		t.Fatal("want an export error")
+	}
+
+	// The failed records are requeued in front of the records logged since,
+	// dropping the newest ones beyond MaxQueueSize.
+	logger.Info("3")
+	logger.Info("4")
+	if got := e.Dropped(); got != 1 {
+		t.Errorf("got %d dropped records, want 1", got)
+	}
+
+	c.setStatus(http.StatusOK)
+	if err := e.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	var got []any
+	for _, rec := range c.exported() {
+		got = append(got, rec["body"].(map[string]any)["stringValue"])
+	}
+	if len(got) != 3 || got[0] != "1" || got[1] != "2" || got[2] != "3" {
+		t.Errorf("got records %v, want [1 2 3]", got)
+	}
+}
+
+func TestCloseDropsFailedRecords(t *testing.T) {
+	c := newCollector(t)
+	c.setStatus(http.StatusInternalServerError)
+	e := otlplog.NewExporter(otlplog.Options{Endpoint: c.URL, FlushInterval: time.Hour})
+
+	e.Logger().Info("1")
+	e.Logger().Info("2")
+	if err := e.Close(); err == nil {
+		t.Error("want an export error")
+	}
+	if got := e.Dropped(); got != 2 {
+		t.Errorf("got %d dropped records, want 2", got)
+	}
+}
+
+func TestFullBatchExport(t *testing.T) {
+	c := newCollector(t)
+	e := newExporter(t, c, otlplog.Options{BatchSize: 2})
+
+	e.Logger().Info("1")
+	e.Logger().Info("2")
+	for deadline := time.Now().Add(5 * time.Second); len(c.exported()) < 2; {
+		if time.Now().After(deadline) {
+			t.Fatal("full batch not exported in the background")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
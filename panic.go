@@ -0,0 +1,49 @@
+package httplog
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+// PanicError is a panic recovered from the underlying HTTP handler. It's logged
+// as the error of the request log and passed to Options.OnPanic, so that panics
+// can be reported like any other error, e.g. errors.As(err, &panicErr).
+type PanicError struct {
+	// Value is the value passed to panic().
+	Value any
+
+	// Stack is the stack trace of the panic, as "file:line" frames.
+	Stack []string
+}
+
+// Error implements error.
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// Unwrap returns the panic value, if it's an error.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// newPanicError returns a PanicError capturing up to 10 stack frames of the
+// caller, skipping the given number of frames and runtime/panic.go.
+func newPanicError(rec any, skip int) *PanicError {
+	pc := make([]uintptr, 10)
+	n := runtime.Callers(skip+1, pc)
+
+	var stack []string
+	frames := runtime.CallersFrames(pc[:n])
+	for {
+		frame, more := frames.Next()
+		if !strings.Contains(frame.File, "runtime/panic.go") {
+			stack = append(stack, fmt.Sprintf("%s:%d", frame.File, frame.Line))
+		}
+		if !more {
+			break
+		}
+	}
+	return &PanicError{Value: rec, Stack: stack}
+}
@@ -0,0 +1,72 @@
+package httplog
+
+import (
+	"fmt"
+	"maps"
+	"sync"
+	"time"
+)
+
+// PanicRecord is a panic recovered from an HTTP handler, see RecentPanics.
+type PanicRecord struct {
+	Time  time.Time `json:"time"`
+	Route string    `json:"route"` // Bounded-cardinality route, see MetricsLabel
+	Value string    `json:"value"` // Value passed to panic(), formatted with %v
+	Stack []string  `json:"stack"` // Stack frames, as in PanicError.Stack
+}
+
+// maxRecentPanics is the number of panic records kept by RecentPanics.
+const maxRecentPanics = 16
+
+// panics records the panics recovered by all request logger middlewares in the
+// process.
+var panics = struct {
+	mu      sync.Mutex
+	byRoute map[string]uint64
+	recent  []PanicRecord // ring buffer of up to maxRecentPanics records
+	next    int           // index of the next record in recent
+}{byRoute: map[string]uint64{}}
+
+// recordPanic counts the recovered panic for the route and keeps its record in
+// the recent panics.
+func recordPanic(t time.Time, route string, panicErr *PanicError) {
+	stats.panicsRecovered.Add(1)
+
+	record := PanicRecord{Time: t, Route: route, Value: fmt.Sprintf("%v", panicErr.Value), Stack: panicErr.Stack}
+
+	panics.mu.Lock()
+	defer panics.mu.Unlock()
+	panics.byRoute[route]++
+	if len(panics.recent) < maxRecentPanics {
+		panics.recent = append(panics.recent, record)
+	} else {
+		panics.recent[panics.next] = record
+	}
+	panics.next = (panics.next + 1) % maxRecentPanics
+}
+
+// RecentPanics returns the most recent panics recovered from the HTTP handlers,
+// up to 16, oldest first, so that operators can inspect the crash history
+// without searching the logs. They're also published by expvarstats.Publish.
+func RecentPanics() []PanicRecord {
+	panics.mu.Lock()
+	defer panics.mu.Unlock()
+
+	result := make([]PanicRecord, 0, len(panics.recent))
+	if len(panics.recent) == maxRecentPanics {
+		result = append(result, panics.recent[panics.next:]...)
+		return append(result, panics.recent[:panics.next]...)
+	}
+	return append(result, panics.recent...)
+}
+
+// PanicsByRoute returns a snapshot of the counts of the panics recovered from the
+// HTTP handlers per route, see MetricsLabel. It's nil if no panic was recovered.
+func PanicsByRoute() map[string]uint64 {
+	panics.mu.Lock()
+	defer panics.mu.Unlock()
+	if len(panics.byRoute) == 0 {
+		return nil
+	}
+	return maps.Clone(panics.byRoute)
+}
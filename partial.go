@@ -0,0 +1,78 @@
+package httplog
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+// EmitPartial writes an intermediate log entry of a long-lived request, e.g. of
+// a Server-Sent Events stream, with the keys and values set so far (see SetKVs),
+// the elapsed time and the response bytes written so far, marked by
+// Schema.Partial. The request log is still written when the request completes.
+//
+// The intermediate entries are filtered like the request log, by the response
+// status written so far (200 if none): by Options.Skip, OnlyErrors, the
+// sampling decision, the method policies and the log levels.
+//
+// Call it from the handler's goroutine, as the response bytes are counted by
+// the response writer. It's a no-op once the request log was written.
+func EmitPartial(ctx context.Context, msg string) {
+	rl := getRequestLog(ctx)
+	if rl == nil || rl.partial == nil || rl.closed.Load() {
+		return
+	}
+	rl.partial(ctx, msg)
+}
+
+// partialSkipped reports whether the intermediate log entry of the request with
+// the response status written so far is filtered out, like the request log.
+func partialSkipped(ctx context.Context, r *http.Request, rl *requestLog, logger logr.Logger, o *Options, status int, verbose bool) bool {
+	if status == 0 {
+		status = http.StatusOK
+	}
+	if o.Skip != nil {
+		var skip bool
+		rl.callHook("Skip", func() { skip = o.Skip(r.WithContext(ctx), status) })
+		if skip {
+			return true
+		}
+	}
+	if verbose || status >= 400 {
+		return false
+	}
+	if o.OnlyErrors {
+		return true
+	}
+	if rl.sampling != nil {
+		if sampled, _ := rl.sampling.get(); !sampled {
+			return true
+		}
+	}
+	if skip, _ := skipMethod(r.Method, o); skip {
+		return true
+	}
+	return logger.GetV() > o.Levels.v(statusLevel(status, r.Method, o))
+}
+
+// partialKVs returns the keys and values of the intermediate log entry.
+func partialKVs(ctx context.Context, r *http.Request, s *Schema, o *Options, elapsed time.Duration, bytesWritten int) []any {
+	kvs := appendKVs(nil,
+		s.RequestURL, loggedURL(r, o),
+		s.RequestMethod, r.Method,
+		s.ResponseDuration, float64(elapsed.Milliseconds()),
+		s.ResponseBytes, bytesWritten,
+		s.Partial, true,
+	)
+	if id := requestID(ctx, r); id != "" {
+		kvs = appendKVs(kvs, s.RequestID, id)
+	}
+	kvs = appendKVs(kvs, getKVs(ctx)...)
+	kvs = appendKVs(kvs, getGroupKVs(ctx)...)
+	if s.grouped() {
+		kvs = groupKVs(kvs, s)
+	}
+	return kvs
+}
@@ -0,0 +1,50 @@
+package httplog_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	httplog "github.com/rickliujh/chi-httplogr/v3"
+	"github.com/rickliujh/chi-httplogr/v3/httplogtest"
+)
+
+func TestEmitPartial(t *testing.T) {
+	infoLevels := &httplog.Levels{Warn: 1, Info: 1, Debug: 1}
+	tests := []struct {
+		name         string
+		opts         httplog.Options
+		wantPartials int
+	}{
+		{name: "Logged", opts: httplog.Options{Levels: infoLevels}, wantPartials: 1},
+		{name: "BelowLevel", opts: httplog.Options{}},
+		{name: "Skipped", opts: httplog.Options{Levels: infoLevels, Skip: func(r *http.Request, status int) bool { return true }}},
+		{name: "OnlyErrors", opts: httplog.Options{Levels: infoLevels, OnlyErrors: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httplogtest.NewRecorder()
+			var reqCtx context.Context
+			handler := httplog.RequestLogger(rec.Logger(), &tt.opts)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				reqCtx = r.Context()
+				httplog.EmitPartial(r.Context(), "progress")
+			}))
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+			// No-op once the request log was written.
+			httplog.EmitPartial(reqCtx, "progress")
+
+			var partials int
+			for _, entry := range rec.Entries() {
+				if entry.Message == "progress" {
+					partials++
+				}
+			}
+			if partials != tt.wantPartials {
+				t.Errorf("got %d partial entries, want %d", partials, tt.wantPartials)
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package httplog
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/go-logr/logr"
+)
+
+// perHostLogger returns the request logger dispatching the requests to the
+// request loggers of Options.PerHost by the request host name.
+func perHostLogger(logger logr.Logger, o *Options) func(http.Handler) http.Handler {
+	base := *o
+	base.PerHost = nil
+	defaultLogger := RequestLogger(logger, &base)
+
+	hostLoggers := make(map[string]func(http.Handler) http.Handler, len(o.PerHost))
+	for host, hostOpts := range o.PerHost {
+		if hostOpts == nil {
+			continue
+		}
+		opts := *hostOpts
+		opts.PerHost = nil
+		hostLoggers[strings.ToLower(host)] = RequestLogger(logger, &opts)
+	}
+
+	return func(next http.Handler) http.Handler {
+		defaultHandler := defaultLogger(next)
+		handlers := make(map[string]http.Handler, len(hostLoggers))
+		for host, hostLogger := range hostLoggers {
+			handlers[host] = hostLogger(next)
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if h, ok := handlers[strings.ToLower(hostname(r))]; ok {
+				h.ServeHTTP(w, r)
+				return
+			}
+			defaultHandler.ServeHTTP(w, r)
+		})
+	}
+}
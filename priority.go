@@ -0,0 +1,87 @@
+package httplog
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// The priority classes of requests, see Options.LogPriority.
+const (
+	PriorityHigh   = "high"
+	PriorityNormal = "normal"
+	PriorityLow    = "low"
+)
+
+// RequestPriority returns the priority class of the request, i.e. PriorityHigh,
+// PriorityNormal or PriorityLow, or an empty string if it's unknown. It's only
+// known if Options.LogPriority or Options.LowPrioritySampleRate is set, e.g.
+// to skip low-priority traffic by Options.Skip.
+func RequestPriority(ctx context.Context) string {
+	if rl := getRequestLog(ctx); rl != nil {
+		rl.mu.Lock()
+		defer rl.mu.Unlock()
+		return rl.priority
+	}
+	return ""
+}
+
+// requestPriority returns the priority class of the request from the first of
+// Options.PriorityHeaders found, or from the Priority header (RFC 9218), or an
+// empty string if none was found.
+func requestPriority(r *http.Request, o *Options) string {
+	for _, h := range o.PriorityHeaders {
+		v := strings.ToLower(strings.TrimSpace(r.Header.Get(h)))
+		switch v {
+		case "":
+			continue
+		case PriorityHigh, PriorityNormal, PriorityLow:
+			return v
+		}
+		if u, err := strconv.Atoi(v); err == nil {
+			return urgencyPriority(u)
+		}
+	}
+
+	v := r.Header.Get("Priority")
+	if v == "" {
+		return ""
+	}
+	urgency := 3 // default urgency
+	for _, param := range strings.Split(v, ",") {
+		key, val, _ := strings.Cut(strings.TrimSpace(param), "=")
+		if key == "u" {
+			if u, err := strconv.Atoi(val); err == nil {
+				urgency = u
+			}
+		}
+	}
+	return urgencyPriority(urgency)
+}
+
+// urgencyPriority returns the priority class of the RFC 9218 urgency, i.e. 0 to
+// 2 high, 3 normal and 4 to 7 low.
+func urgencyPriority(urgency int) string {
+	switch {
+	case urgency < 0 || urgency > 7:
+		return ""
+	case urgency < 3:
+		return PriorityHigh
+	case urgency == 3:
+		return PriorityNormal
+	default:
+		return PriorityLow
+	}
+}
+
+// skipLowPriority reports whether the log of the successful request is skipped
+// by its priority, and the sampling rate used for the decision, if any.
+func skipLowPriority(ctx context.Context, r *http.Request, priority string, o *Options) (skip bool, rate float64) {
+	if priority != PriorityLow || o.LowPrioritySampleRate <= 0 || !trustedUpstream(ctx, r, o) {
+		return false, 0
+	}
+	rate = o.LowPrioritySampleRate
+	return rate < 1 && rand.Float64() >= rate, rate
+}
@@ -0,0 +1,35 @@
+package httplog
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// PrivacyField is a set of high-entropy identifying request fields, which are
+// replaced by their salted hashes, see Options.PrivacyFields.
+type PrivacyField int
+
+const (
+	// PrivacyUserAgent replaces Schema.RequestUserAgent by its hash.
+	PrivacyUserAgent PrivacyField = 1 << iota
+
+	// PrivacyReferer replaces Schema.RequestReferer by its hash, after
+	// Options.RefererPolicy is applied.
+	PrivacyReferer
+
+	// PrivacyRemoteIP replaces Schema.RequestRemoteIP by its hash.
+	PrivacyRemoteIP
+)
+
+// privacyHash returns the value replaced by its truncated HMAC-SHA256 keyed by
+// Options.PrivacySalt, e.g. "hmac:1f2e3d4c5b6a7988", if the field is listed in
+// Options.PrivacyFields. Empty values are kept as is.
+func privacyHash(field PrivacyField, v string, o *Options) string {
+	if o.PrivacyFields&field == 0 || v == "" {
+		return v
+	}
+	mac := hmac.New(sha256.New, o.PrivacySalt)
+	mac.Write([]byte(v))
+	return "hmac:" + hex.EncodeToString(mac.Sum(nil)[:8])
+}
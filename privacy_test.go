@@ -0,0 +1,48 @@
+package httplog_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	httplog "github.com/rickliujh/chi-httplogr/v3"
+	"github.com/rickliujh/chi-httplogr/v3/httplogtest"
+)
+
+func TestPrivacyFields(t *testing.T) {
+	salt := []byte("salt")
+	hash := func(v string) string {
+		mac := hmac.New(sha256.New, salt)
+		mac.Write([]byte(v))
+		return "hmac:" + hex.EncodeToString(mac.Sum(nil)[:8])
+	}
+
+	rec := httplogtest.NewRecorder()
+	handler := httplog.RequestLogger(rec.Logger(), &httplog.Options{
+		Levels:        &httplog.Levels{Warn: 1, Info: 1, Debug: 1},
+		PrivacyFields: httplog.PrivacyUserAgent | httplog.PrivacyRemoteIP,
+		PrivacySalt:   salt,
+		RefererPolicy: httplog.RefererFull,
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "203.0.113.7:1234"
+	req.Header.Set("User-Agent", "curl/8.0")
+	req.Header.Set("Referer", "https://example.com/")
+	_, entry := rec.RoundTrip(handler, req)
+
+	s := httplog.SchemaECS
+	if !entry.HasKV(s.RequestUserAgent, hash("curl/8.0")) {
+		t.Errorf("want the user agent hashed as %s: %v", hash("curl/8.0"), entry.KVs)
+	}
+	if !entry.HasKV(s.RequestRemoteIP, hash("203.0.113.7")) {
+		t.Errorf("want the remote IP hashed as %s: %v", hash("203.0.113.7"), entry.KVs)
+	}
+	// Fields not listed in PrivacyFields are kept as is.
+	if !entry.HasKV(s.RequestReferer, "https://example.com/") {
+		t.Errorf("want the referer as is: %v", entry.KVs)
+	}
+}
@@ -0,0 +1,94 @@
+package httplog
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// maxProblemDetailsLen is the maximum size of a problem details document to be parsed.
+const maxProblemDetailsLen = 16 << 10
+
+// problemWriter captures the response body, if it's a RFC 7807 problem details
+// document or, if errorJSON is set, any JSON error response (HTTP status >= 400).
+// It decides on the first write, once the response headers are set.
+type problemWriter struct {
+	header    http.Header
+	status    func() int
+	problem   bool
+	errorJSON bool
+	buf       bytes.Buffer
+	decided   bool
+	capture   bool
+}
+
+func (pw *problemWriter) capturing() bool {
+	if !pw.decided {
+		mediaType, _, _ := mime.ParseMediaType(pw.header.Get("Content-Type"))
+		switch {
+		case mediaType == "application/problem+json":
+			pw.capture = pw.problem || pw.errorJSON
+		case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+			pw.capture = pw.errorJSON && pw.status() >= 400
+		}
+		pw.decided = true
+	}
+	return pw.capture
+}
+
+func (pw *problemWriter) Write(p []byte) (int, error) {
+	if pw.capturing() {
+		pw.buf.Write(p[:min(len(p), maxProblemDetailsLen-pw.buf.Len())])
+	}
+	return len(p), nil
+}
+
+// problemDetailsKVs returns the type, title and detail of the captured problem
+// details document, or nil if none was captured.
+func problemDetailsKVs(pw *problemWriter, s *Schema) []any {
+	if pw == nil || !pw.problem || pw.buf.Len() == 0 {
+		return nil
+	}
+
+	var problem struct {
+		Type   string `json:"type"`
+		Title  string `json:"title"`
+		Detail string `json:"detail"`
+	}
+	if err := json.Unmarshal(pw.buf.Bytes(), &problem); err != nil {
+		return nil
+	}
+
+	var kvs []any
+	if problem.Type != "" {
+		kvs = append(kvs, s.ErrorType, problem.Type)
+	}
+	if problem.Title != "" {
+		kvs = append(kvs, s.ErrorTitle, problem.Title)
+	}
+	if problem.Detail != "" {
+		kvs = append(kvs, s.ErrorDetail, problem.Detail)
+	}
+	return kvs
+}
+
+// errorMessage returns the value of the first of the given fields found in the
+// captured JSON error response, e.g. {"error": "invalid input"}.
+func errorMessage(pw *problemWriter, fields []string) string {
+	if pw == nil || !pw.errorJSON || pw.buf.Len() == 0 {
+		return ""
+	}
+
+	var doc map[string]any
+	if err := json.Unmarshal(pw.buf.Bytes(), &doc); err != nil {
+		return ""
+	}
+	for _, field := range fields {
+		if msg, ok := doc[field].(string); ok && msg != "" {
+			return msg
+		}
+	}
+	return ""
+}
@@ -0,0 +1,46 @@
+package httplog
+
+import (
+	"context"
+	"net/http"
+	"runtime/pprof"
+	"runtime/trace"
+)
+
+// withPprofLabels calls serve with pprof labels for the route and request ID,
+// so that CPU profiles can be sliced by endpoint and correlated to the logs.
+//
+// The route is labeled by MetricsLabel, to bound the cardinality of the labels.
+// It's only known when the middleware is mounted after the request was routed
+// (e.g. in a chi route group), otherwise it's OtherRoute.
+func withPprofLabels(ctx context.Context, r *http.Request, o *Options, serve func(ctx context.Context)) {
+	labels := []string{"http.method", r.Method, "http.route", MetricsLabel(r, o)}
+	if id := requestID(ctx, r); id != "" {
+		labels = append(labels, "request.id", id)
+	}
+
+	pprof.Do(ctx, pprof.Labels(labels...), serve)
+}
+
+// withTraceTask calls serve within a runtime/trace task and region named after
+// the route pattern, with the request ID and final route logged as trace events.
+//
+// The route is only known when the middleware is mounted after the request was
+// routed (e.g. in a chi route group), otherwise the task is named "HTTP request".
+func withTraceTask(ctx context.Context, r *http.Request, o *Options, serve func(ctx context.Context) *http.Request) {
+	name := "HTTP request"
+	if route := routePattern(r, o); route != "" {
+		name = r.Method + " " + route
+	}
+
+	ctx, task := trace.NewTask(ctx, name)
+	defer task.End()
+
+	if id := requestID(ctx, r); id != "" {
+		trace.Log(ctx, "request.id", id)
+	}
+	trace.WithRegion(ctx, "handler", func() {
+		served := serve(ctx)
+		trace.Log(ctx, "http.route", routePattern(served, o))
+	})
+}
@@ -0,0 +1,172 @@
+package httplog
+
+import (
+	"context"
+	"net/http"
+	"net/http/httputil"
+	"slices"
+	"time"
+)
+
+// upstream holds the attributes of the upstream request made by a reverse proxy.
+type upstream struct {
+	target   string
+	status   int
+	duration time.Duration
+	attempts int
+
+	// headerDiff holds the names of the "added", "removed" and "modified" headers.
+	headerDiff map[string][]string
+}
+
+// RecordUpstream records an attempt to proxy the request to the given upstream
+// target on the request log. Each call counts as one attempt; the last attempt's
+// target, status and duration are logged along with the number of retries.
+//
+// Use it from custom proxies, or instrument httputil.ReverseProxy with InstrumentProxy.
+func RecordUpstream(ctx context.Context, target string, status int, duration time.Duration) {
+	if rl := getRequestLog(ctx); rl != nil {
+		rl.mu.Lock()
+		defer rl.mu.Unlock()
+		rl.upstream.target = target
+		rl.upstream.status = status
+		rl.upstream.duration = duration
+		rl.upstream.attempts++
+	}
+}
+
+// InstrumentProxy instruments the given reverse proxy to record the upstream
+// target, status, latency and retry count on the request log, see RecordUpstream.
+//
+// It wraps the proxy's Transport and ErrorHandler, so it must be called after
+// they're set. Errors reaching the upstream are set on the request log too.
+func InstrumentProxy(p *httputil.ReverseProxy) *httputil.ReverseProxy {
+	p.Transport = UpstreamTransport(p.Transport)
+
+	// Record the headers rewritten by the director, see Options.LogProxyHeaderDiff.
+	if rewrite := p.Rewrite; rewrite != nil {
+		p.Rewrite = func(pr *httputil.ProxyRequest) {
+			rewrite(pr)
+			if rl := getRequestLog(pr.In.Context()); rl != nil && rl.diffHeaders {
+				recordHeaderDiff(rl, pr.In.Header, pr.Out.Header)
+			}
+		}
+	} else if director := p.Director; director != nil {
+		p.Director = func(req *http.Request) {
+			rl := getRequestLog(req.Context())
+			if rl == nil || !rl.diffHeaders {
+				director(req)
+				return
+			}
+			before := req.Header.Clone()
+			director(req)
+			recordHeaderDiff(rl, before, req.Header)
+		}
+	}
+
+	errorHandler := p.ErrorHandler
+	p.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+		SetError(r.Context(), err)
+		if errorHandler != nil {
+			errorHandler(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusBadGateway)
+	}
+
+	return p
+}
+
+// UpstreamTransport returns a http.RoundTripper recording each round trip of the
+// given transport on the request log, see RecordUpstream. If nil, the
+// http.DefaultTransport is used.
+//
+// It also propagates the sampling decision of the request log downstream, see
+// Options.SamplingHeader, and the request ID as the parent request ID of the
+// child requests, see Options.ParentRequestIDHeader.
+//
+// Wrap the innermost transport, so that retries made by outer transports are counted.
+func UpstreamTransport(rt http.RoundTripper) http.RoundTripper {
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	return upstreamTransport{rt}
+}
+
+type upstreamTransport struct {
+	http.RoundTripper
+}
+
+func (t upstreamTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// Propagate the sampling decision downstream, see Options.SamplingHeader.
+	if rl := getRequestLog(req.Context()); rl != nil && rl.sampling != nil && rl.sampling.header != "" {
+		sampled, rate := rl.sampling.get()
+		req = req.Clone(req.Context())
+		req.Header.Set(rl.sampling.header, samplingHeaderValue(sampled, rate))
+	}
+	req = propagateRequestID(req.Context(), req)
+
+	start := time.Now()
+	resp, err := t.RoundTripper.RoundTrip(req)
+
+	var status int
+	if resp != nil {
+		status = resp.StatusCode
+	}
+	RecordUpstream(req.Context(), req.URL.Host, status, time.Since(start))
+
+	return resp, err
+}
+
+// recordHeaderDiff records the names of the headers added, removed and modified
+// by the proxy director on the request log.
+func recordHeaderDiff(rl *requestLog, before, after http.Header) {
+	diff := map[string][]string{}
+	for h, vals := range after {
+		prev, ok := before[h]
+		switch {
+		case !ok:
+			diff["added"] = append(diff["added"], h)
+		case !slices.Equal(prev, vals):
+			diff["modified"] = append(diff["modified"], h)
+		}
+	}
+	for h := range before {
+		if _, ok := after[h]; !ok {
+			diff["removed"] = append(diff["removed"], h)
+		}
+	}
+	for _, names := range diff {
+		slices.Sort(names)
+	}
+
+	rl.mu.Lock()
+	rl.upstream.headerDiff = diff
+	rl.mu.Unlock()
+}
+
+func upstreamKVs(ctx context.Context, s *Schema) []any {
+	rl := getRequestLog(ctx)
+	if rl == nil {
+		return nil
+	}
+	rl.mu.Lock()
+	u := rl.upstream
+	rl.mu.Unlock()
+	if u.attempts == 0 {
+		return nil
+	}
+
+	kvs := []any{
+		s.UpstreamAddress, u.target,
+		s.UpstreamDuration, float64(u.duration.Milliseconds()),
+		s.UpstreamRetries, u.attempts - 1,
+	}
+	if u.status != 0 {
+		kvs = append(kvs, s.UpstreamStatus, u.status)
+	}
+	if len(u.headerDiff) > 0 {
+		kvs = append(kvs, s.UpstreamHeaderDiff, u.headerDiff)
+	}
+	return kvs
+}
@@ -0,0 +1,87 @@
+package httplog
+
+import (
+	"net/http"
+	"net/url"
+	"slices"
+)
+
+var defaultRedactQueryParams = []string{"token", "access_token", "id_token", "api_key", "apikey", "key", "password", "secret", "code", "sig", "signature"}
+
+// queryParamsKVs returns the query parameters selected by Options.LogQueryParams
+// as a nested object, or nil.
+func queryParamsKVs(query url.Values, o *Options) map[string]any {
+	if len(query) == 0 {
+		return nil
+	}
+	all := slices.Contains(o.LogQueryParams, "*")
+	redact := redactQueryParams(o)
+
+	params := map[string]any{}
+	for name, vals := range query {
+		if !all && !slices.Contains(o.LogQueryParams, name) {
+			continue
+		}
+		switch {
+		case redactedQueryParam(redact, name):
+			params[name] = redactValues(vals, o)
+		case len(vals) == 1:
+			params[name] = vals[0]
+		default:
+			params[name] = vals
+		}
+	}
+	if len(params) == 0 {
+		return nil
+	}
+	return params
+}
+
+// redactQueryParams returns Options.RedactQueryParams or the default list.
+func redactQueryParams(o *Options) []string {
+	if o.RedactQueryParams == nil {
+		return defaultRedactQueryParams
+	}
+	return o.RedactQueryParams
+}
+
+func redactedQueryParam(redact []string, name string) bool {
+	return redactedName(redact, name)
+}
+
+// redactedURL returns the request URL with the values of the
+// Options.RedactQueryParams redacted. It returns u itself when there is nothing
+// to redact.
+func redactedURL(u *url.URL, o *Options) *url.URL {
+	if u.RawQuery == "" {
+		return u
+	}
+	redact := redactQueryParams(o)
+	query, err := url.ParseQuery(u.RawQuery)
+	if err != nil {
+		// The query can't be parsed back into the same parameters, so don't
+		// risk logging a secret verbatim.
+		c := *u
+		c.RawQuery = redactValue(u.RawQuery, o)
+		return &c
+	}
+	var redacted bool
+	for name, vals := range query {
+		if redactedQueryParam(redact, name) {
+			query[name] = []string{redactValues(vals, o)}
+			redacted = true
+		}
+	}
+	if !redacted {
+		return u
+	}
+	c := *u
+	c.RawQuery = query.Encode()
+	return &c
+}
+
+// loggedURL returns the absolute request URL as logged in Schema.RequestURL,
+// with the Options.RedactQueryParams redacted.
+func loggedURL(r *http.Request, o *Options) string {
+	return scheme(r) + "://" + r.Host + redactedURL(r.URL, o).String()
+}
@@ -0,0 +1,31 @@
+package httplog_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr/funcr"
+	httplog "github.com/rickliujh/chi-httplogr/v3"
+)
+
+func TestRedactQueryParamsInURL(t *testing.T) {
+	var logs strings.Builder
+	logger := funcr.NewJSON(func(obj string) { logs.WriteString(obj + "\n") }, funcr.Options{})
+
+	handler := httplog.RequestLogger(logger, &httplog.Options{
+		LogQueryParams: []string{"*"},
+		LogURLParts:    true,
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/search?page=2&access_token=s3cr3t", nil))
+
+	if strings.Contains(logs.String(), "s3cr3t") {
+		t.Errorf("redacted query parameter logged:\n%s", logs.String())
+	}
+	if !strings.Contains(logs.String(), "page=2") {
+		t.Errorf("query parameter page=2 not logged:\n%s", logs.String())
+	}
+}
@@ -0,0 +1,43 @@
+package httplog
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var queueStartHeaders = []string{"X-Request-Start", "X-Queue-Start"}
+
+// queueTime returns the time the request spent queued before reaching the app,
+// as recorded by the X-Request-Start or X-Queue-Start header of the load balancer
+// or proxy (e.g. Heroku router, nginx "t=${msec}").
+func queueTime(r *http.Request, start time.Time) (time.Duration, bool) {
+	for _, h := range queueStartHeaders {
+		v := strings.TrimPrefix(r.Header.Get(h), "t=")
+		if v == "" {
+			continue
+		}
+		ts, err := strconv.ParseFloat(v, 64)
+		if err != nil || ts <= 0 {
+			continue
+		}
+
+		// The timestamp is in seconds, milliseconds or microseconds since epoch.
+		var queued time.Time
+		switch {
+		case ts > 1e15:
+			queued = time.UnixMicro(int64(ts))
+		case ts > 1e12:
+			queued = time.UnixMilli(int64(ts))
+		default:
+			queued = time.UnixMicro(int64(ts * 1e6))
+		}
+
+		if d := start.Sub(queued); d >= 0 {
+			return d, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
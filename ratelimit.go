@@ -0,0 +1,44 @@
+package httplog
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// rateLimitHeaders maps the normalized rate limit attribute names to the standard
+// response headers, and their legacy X- prefixed variants, in order of preference.
+var rateLimitHeaders = []struct {
+	key     string
+	headers []string
+}{
+	{"retry_after", []string{"Retry-After"}},
+	{"limit", []string{"RateLimit-Limit", "X-RateLimit-Limit"}},
+	{"remaining", []string{"RateLimit-Remaining", "X-RateLimit-Remaining"}},
+	{"reset", []string{"RateLimit-Reset", "X-RateLimit-Reset"}},
+	{"policy", []string{"RateLimit-Policy", "X-RateLimit-Policy"}},
+	{"ratelimit", []string{"RateLimit"}},
+}
+
+// rateLimit returns the rate limiting response headers as a normalized object,
+// or nil if none were found. Numeric values are logged as numbers.
+func rateLimit(header http.Header) map[string]any {
+	var m map[string]any
+	for _, rl := range rateLimitHeaders {
+		for _, h := range rl.headers {
+			v := header.Get(h)
+			if v == "" {
+				continue
+			}
+			if m == nil {
+				m = make(map[string]any, len(rateLimitHeaders))
+			}
+			if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+				m[rl.key] = n
+			} else {
+				m[rl.key] = v
+			}
+			break
+		}
+	}
+	return m
+}
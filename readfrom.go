@@ -0,0 +1,63 @@
+package httplog
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// bodyCapturer is a tee'd writer capturing (parts of) the response body to be
+// logged. It decides whether to capture once the response headers are set.
+type bodyCapturer interface {
+	io.Writer
+	capturing() bool
+}
+
+// readerFromWriter preserves the io.ReaderFrom optimization of the underlying
+// response writer, e.g. sendfile(2) for static files served by http.FileServer,
+// unless the response body is being captured for logging.
+type readerFromWriter struct {
+	middleware.WrapResponseWriter
+	tees []bodyCapturer
+}
+
+// wrapReaderFrom returns ww wrapped by readerFromWriter, if the underlying
+// response writer supports io.ReaderFrom, or ww otherwise.
+func wrapReaderFrom(ww middleware.WrapResponseWriter, tees []bodyCapturer) http.ResponseWriter {
+	_, fl := ww.(http.Flusher)
+	_, hj := ww.(http.Hijacker)
+	_, rf := ww.(io.ReaderFrom)
+	if len(tees) == 0 || !fl || !hj || !rf {
+		return ww
+	}
+	return &readerFromWriter{WrapResponseWriter: ww, tees: tees}
+}
+
+func (w *readerFromWriter) ReadFrom(r io.Reader) (int64, error) {
+	for _, tee := range w.tees {
+		if tee.capturing() {
+			// Copy through Write, so that the body is tee'd.
+			return io.Copy(writerOnly{w.WrapResponseWriter}, r)
+		}
+	}
+
+	// None of the tee'd writers captures this response.
+	w.WrapResponseWriter.Tee(nil)
+	return w.WrapResponseWriter.(io.ReaderFrom).ReadFrom(r)
+}
+
+func (w *readerFromWriter) Flush() {
+	w.WrapResponseWriter.(http.Flusher).Flush()
+}
+
+func (w *readerFromWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return w.WrapResponseWriter.(http.Hijacker).Hijack()
+}
+
+// writerOnly hides the io.ReaderFrom implementation of the writer from io.Copy.
+type writerOnly struct {
+	io.Writer
+}
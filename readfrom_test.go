@@ -0,0 +1,56 @@
+package httplog_test
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	httplog "github.com/rickliujh/chi-httplogr/v3"
+	"github.com/rickliujh/chi-httplogr/v3/httplogtest"
+)
+
+// BenchmarkServeLargeFile serves a large static file over a TCP connection, so
+// that net/http can use sendfile(2) through io.ReaderFrom, with and without the
+// middleware.
+func BenchmarkServeLargeFile(b *testing.B) {
+	const size = 8 << 20
+	dir := b.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "large.bin"), bytes.Repeat([]byte{'x'}, size), 0o644); err != nil {
+		b.Fatal(err)
+	}
+	files := http.FileServer(http.Dir(dir))
+	logger := httplogtest.NewRecorder().Logger()
+
+	benchmarks := []struct {
+		name    string
+		handler http.Handler
+	}{
+		{"NoMiddleware", files},
+		{"RequestLogger", httplog.RequestLogger(logger, &httplog.Options{})(files)},
+		{"RequestLoggerBodyLogging", httplog.RequestLogger(logger, &httplog.Options{
+			LogResponseBody: func(*http.Request) bool { return true },
+		})(files)},
+	}
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			srv := httptest.NewServer(bm.handler)
+			defer srv.Close()
+
+			b.SetBytes(size)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				resp, err := srv.Client().Get(srv.URL + "/large.bin")
+				if err != nil {
+					b.Fatal(err)
+				}
+				io.Copy(io.Discard, resp.Body)
+				resp.Body.Close()
+			}
+		})
+	}
+}
@@ -0,0 +1,221 @@
+package httplog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-logr/logr"
+)
+
+// Record holds the request and response data to be logged by Emit. It's either
+// captured by Capture, or filled in manually, e.g. by custom frameworks or
+// background jobs, which don't serve HTTP handlers.
+type Record struct {
+	// Request is the request to be served. Captured records carry the request log
+	// in the context, so that SetKVs and friends can be used by the handler.
+	Request *http.Request
+	// Writer is the response writer to be served, capturing the response.
+	Writer http.ResponseWriter
+
+	ResponseHeader http.Header
+	Status         int
+	Bytes          int
+	Duration       time.Duration
+	Err            error // Error logged with HTTP 5xx responses, e.g. *PanicError
+
+	// Schema keys the attributes encoded by MarshalJSON, or SchemaECS if nil.
+	// Emit uses its own schema.
+	Schema *Schema
+
+	ww    middleware.WrapResponseWriter
+	rl    *requestLog
+	start time.Time
+}
+
+// Capture starts capturing the request and the response, which is completed
+// and logged by Emit. It's the capture stage of RequestLogger without options,
+// for use outside of chi middlewares, e.g.:
+//
+//	rec := httplog.Capture(r, w)
+//	handler.ServeHTTP(rec.Writer, rec.Request)
+//	httplog.Emit(logger, httplog.SchemaECS, rec)
+func Capture(r *http.Request, w http.ResponseWriter) *Record {
+	rl := &requestLog{clock: systemClock{}}
+	rl.start = rl.clock.Now()
+	ctx := context.WithValue(r.Context(), ctxKeyRequestLog{}, rl)
+	ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+	return &Record{
+		Request: r.WithContext(ctx),
+		Writer:  ww,
+		ww:      ww,
+		rl:      rl,
+		start:   rl.start,
+	}
+}
+
+// Emit logs the record using the schema, or SchemaECS if nil. It's the emit
+// stage of RequestLogger, logging the core request and response attributes
+// and the keys and values set by SetKVs and SetGroupKVs.
+//
+// Captured records are completed first, i.e. the status, the response size and
+// headers and the duration are set from the captured response, unless set, as
+// RequestLogger does. Records with a *PanicError and no status are logged as
+// HTTP 500.
+func Emit(logger logr.Logger, s *Schema, rec *Record) {
+	if s == nil {
+		s = SchemaECS
+	}
+	r := rec.Request
+	if rec.rl != nil {
+		defer rec.rl.closed.Store(true)
+	}
+	o := &defaultOptions
+	rec.complete(o, rec.recovered())
+
+	lvl := statusLevel(rec.Status, r.Method, o)
+	if logger.GetV() > o.Levels.v(lvl) {
+		stats.requestsSuppressed.Add(1)
+		return
+	}
+
+	e := rec.entry(s, o)
+	logkvs := e.KeysAndValues
+	if s.grouped() {
+		logkvs = groupKVs(logkvs, s)
+	}
+
+	stats.requestsLogged.Add(1)
+
+	emit(logger.V(o.Levels.v(lvl)), lvl, e.Err, e.Message, logkvs, o)
+}
+
+// MarshalJSON encodes the record as the JSON object of the entry logged by Emit
+// with Record.Schema, see Entry.MarshalJSON. Captured records are completed
+// first, as by Emit.
+func (rec *Record) MarshalJSON() ([]byte, error) {
+	s := rec.Schema
+	if s == nil {
+		s = SchemaECS
+	}
+	o := &defaultOptions
+	rec.complete(o, rec.recovered())
+	return rec.entry(s, o).MarshalJSON()
+}
+
+// recovered returns the value recovered from the handler panic, if any.
+func (rec *Record) recovered() any {
+	if panicErr, ok := rec.Err.(*PanicError); ok {
+		return panicErr.Value
+	}
+	return nil
+}
+
+// entry returns the entry of the completed record, with ungrouped keys.
+func (rec *Record) entry(s *Schema, o *Options) *Entry {
+	r := rec.Request
+	reqHeaders, respHeaders := defaultHeaderMatchers()
+	logkvs := coreKVs(r, rec.ResponseHeader, rec.Status, rec.Bytes, rec.Duration, s, o, reqHeaders, respHeaders)
+	ctx := r.Context()
+	logkvs = appendKVs(logkvs, getKVs(ctx)...)
+	logkvs = appendKVs(logkvs, getGroupKVs(ctx)...)
+	logkvs = appendKVs(logkvs, s.EventOutcome, eventOutcome(rec.Status, rec.Err != nil, false))
+
+	var now time.Time
+	if rec.rl != nil {
+		now = rec.rl.clock.Now()
+	} else {
+		now = time.Now()
+	}
+	return &Entry{
+		Request:       r,
+		Status:        rec.Status,
+		Duration:      rec.Duration,
+		Level:         statusLevel(rec.Status, r.Method, o),
+		Message:       fmt.Sprintf("%s %s => HTTP %v (%v)", r.Method, redactedURL(r.URL, o), rec.Status, rec.Duration),
+		Err:           rec.Err,
+		KeysAndValues: logkvs,
+		Time:          now,
+		Schema:        s,
+	}
+}
+
+// complete completes the captured record from the response, unless set. It's
+// shared by Emit and RequestLogger. If the response status was never written,
+// the status is 500 if the handler panicked with the recovered value, the
+// Options.ClientClosedStatus if the client closed the request, or 200 as sent by
+// net/http.
+func (rec *Record) complete(o *Options, recovered any) {
+	if rec.ww != nil {
+		if rec.Status == 0 {
+			rec.Status = rec.ww.Status()
+		}
+		if rec.Bytes == 0 {
+			rec.Bytes = rec.ww.BytesWritten()
+		}
+		if rec.ResponseHeader == nil {
+			rec.ResponseHeader = rec.ww.Header()
+		}
+		if rec.Duration == 0 {
+			rec.Duration = rec.rl.clock.Since(rec.start)
+		}
+	}
+	if rec.Status != 0 {
+		return
+	}
+	switch {
+	case recovered != nil && recovered != http.ErrAbortHandler:
+		// The panic is re-panicked, so that it's recovered by an outer middleware
+		// (e.g. Recoverer responding with HTTP 500) or aborts the response.
+		rec.Status = http.StatusInternalServerError
+	case recovered == nil && o.ClientClosedStatus != 0 && errors.Is(rec.Request.Context().Err(), context.Canceled):
+		// The client closed the request before the response status was written.
+		rec.Status = o.ClientClosedStatus
+	default:
+		// If the handler never calls w.WriteHeader(statusCode) explicitly,
+		// Go's http package automatically sends HTTP 200 OK to the client.
+		rec.Status = http.StatusOK
+	}
+}
+
+// defaultHeaderMatchers returns the request and response header matchers of the
+// default options, built once.
+var defaultHeaderMatchers = sync.OnceValues(func() (*headerMatcher, *headerMatcher) {
+	o := &defaultOptions
+	return newHeaderMatcher(o.LogRequestHeaders, o), newHeaderMatcher(o.LogResponseHeaders, o)
+})
+
+// coreKVs returns the core request and response attributes, which are logged
+// regardless of the options.
+func coreKVs(r *http.Request, respHeader http.Header, status, bytes int, duration time.Duration, s *Schema, o *Options, reqHeaders, respHeaders *headerMatcher) []any {
+	remoteIP, remotePort := splitRemoteAddr(r.RemoteAddr)
+	logkvs := appendKVs(nil,
+		s.RequestURL, loggedURL(r, o),
+		s.RequestMethod, r.Method,
+		s.RequestPath, r.URL.Path,
+		s.RequestRemoteIP, privacyHash(PrivacyRemoteIP, remoteIP, o),
+		s.RequestHost, r.Host,
+		s.RequestScheme, scheme(r),
+		s.RequestProto, r.Proto,
+		s.RequestHeaders, nestKVs(reqHeaders.kvs(r.Header)),
+		s.RequestBytes, r.ContentLength,
+		s.RequestUserAgent, privacyHash(PrivacyUserAgent, r.UserAgent(), o),
+		s.RequestReferer, privacyHash(PrivacyReferer, referer(r.Referer(), o.RefererPolicy), o),
+		s.ResponseHeaders, nestKVs(respHeaders.kvs(respHeader)),
+		s.ResponseStatus, status,
+		s.ResponseStatusClass, statusClass(status),
+		s.ResponseDuration, float64(duration.Milliseconds()),
+		s.ResponseBytes, bytes,
+	)
+	if remotePort != 0 {
+		logkvs = appendKVs(logkvs, s.RequestRemotePort, remotePort)
+	}
+	if id := requestID(r.Context(), r); id != "" {
+		logkvs = appendKVs(logkvs, s.RequestID, id)
+	}
+	return logkvs
+}
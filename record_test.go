@@ -0,0 +1,94 @@
+package httplog_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	httplog "github.com/rickliujh/chi-httplogr/v3"
+	"github.com/rickliujh/chi-httplogr/v3/httplogtest"
+)
+
+func TestCaptureEmit(t *testing.T) {
+	logs := httplogtest.NewRecorder()
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		httplog.SetKVs(r.Context(), "user", "u1")
+		w.WriteHeader(http.StatusBadGateway)
+	})
+
+	rec := httplog.Capture(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder())
+	handler.ServeHTTP(rec.Writer, rec.Request)
+	httplog.Emit(logs.Logger(), nil, rec)
+
+	entry := logs.LastEntry()
+	if !entry.HasKV(httplog.SchemaECS.ResponseStatus, http.StatusBadGateway) {
+		t.Errorf("want status 502; entry: %v", entry.KVs)
+	}
+	if !entry.HasKV("user", "u1") {
+		t.Errorf("entry is missing user: %v", entry.KVs)
+	}
+}
+
+func TestLogFormatterClientClosedStatus(t *testing.T) {
+	logs := httplogtest.NewRecorder()
+	formatter := httplog.NewLogFormatter(logs.Logger(), &httplog.Options{
+		Levels:             &httplog.Levels{Warn: 0},
+		ClientClosedStatus: httplog.StatusClientClosedRequest,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+	formatter.NewLogEntry(req).Write(0, 0, http.Header{}, time.Millisecond, nil)
+
+	entry := logs.LastEntry()
+	if !entry.HasKV(httplog.SchemaECS.ResponseStatus, httplog.StatusClientClosedRequest) {
+		t.Errorf("want status 499; entry: %v", entry.KVs)
+	}
+}
+
+func TestRecordMarshalJSON(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		httplog.SetKVs(r.Context(), "user", "u1")
+		w.WriteHeader(http.StatusBadGateway)
+	})
+
+	rec := httplog.Capture(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder())
+	rec.Err = errors.New("upstream failed")
+	handler.ServeHTTP(rec.Writer, rec.Request)
+
+	data, err := json.Marshal(rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("invalid JSON %s: %v", data, err)
+	}
+	if got["user"] != "u1" || got["error"] != "upstream failed" || got["level"] != "error" {
+		t.Errorf("unexpected record JSON: %s", data)
+	}
+	if status := got[httplog.SchemaECS.ResponseStatus]; status != float64(http.StatusBadGateway) {
+		t.Errorf("want status 502; got %v in %s", status, data)
+	}
+}
+
+func TestEntryMarshalJSONDuplicateError(t *testing.T) {
+	e := &httplog.Entry{
+		Message:       "GET / => HTTP 500",
+		Err:           errors.New("boom"),
+		KeysAndValues: []any{httplog.ErrorKey, "handler error"},
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(data), `"error":`); n != 1 {
+		t.Errorf("want a single error key; got %s", data)
+	}
+}
@@ -0,0 +1,79 @@
+package httplog
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+type ctxKeyRecoverer struct{}
+
+// recovererState is shared by Recoverer with the request loggers mounted after
+// it, which mark the panics they logged before re-panicking.
+type recovererState struct {
+	logged atomic.Bool
+}
+
+// Recoverer is a drop-in replacement of chi's middleware.Recoverer, which
+// recovers from panics, responds with HTTP 500 and guarantees that the panic is
+// recovered and logged exactly once, regardless of the middleware order:
+//
+//   - mounted after RequestLogger, the panic is logged by RequestLogger with
+//     the stack trace, like the panics recovered by RequestLogger itself.
+//   - mounted before RequestLogger without Options.RecoverPanics, the panic
+//     logged by RequestLogger isn't printed again.
+//
+// Other panics are printed by middleware.PrintPrettyStack, like chi does.
+// Like middleware.Recoverer, it re-panics http.ErrAbortHandler.
+func Recoverer(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		state := &recovererState{}
+		ctx := context.WithValue(r.Context(), ctxKeyRecoverer{}, state)
+
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+			if rec == http.ErrAbortHandler {
+				panic(rec)
+			}
+			// Skip 3 frames (this middleware + runtime/panic.go).
+			panicErr := newPanicError(rec, 3)
+			recordPanic(time.Now(), MetricsLabel(r, nil), panicErr)
+
+			if rl := getRequestLog(ctx); rl != nil {
+				rl.mu.Lock()
+				rl.recovered = panicErr
+				rl.mu.Unlock()
+			} else if !state.logged.Load() {
+				middleware.PrintPrettyStack(rec)
+			}
+
+			if r.Header.Get("Connection") != "Upgrade" {
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+		}()
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// markPanicLogged marks the panic as logged for the Recoverer mounted before
+// the request logger, if any.
+func markPanicLogged(ctx context.Context) {
+	if state, ok := ctx.Value(ctxKeyRecoverer{}).(*recovererState); ok {
+		state.logged.Store(true)
+	}
+}
+
+// recoveredPanic returns the panic recovered by Recoverer mounted after the
+// request logger, if any.
+func (rl *requestLog) recoveredPanic() *PanicError {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	return rl.recovered
+}
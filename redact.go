@@ -0,0 +1,137 @@
+package httplog
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// RedactMode is the strategy replacing the redacted values of headers and
+// query parameters, see Options.RedactMode.
+type RedactMode int
+
+const (
+	// RedactModePlaceholder replaces the values with Options.RedactPlaceholder.
+	RedactModePlaceholder RedactMode = iota
+
+	// RedactModeLength replaces the values with the placeholder and the value
+	// length, e.g. "[REDACTED] (len=32)".
+	RedactModeLength
+
+	// RedactModeHMAC replaces the values with their truncated HMAC-SHA256 keyed
+	// by Options.RedactHMACKey, e.g. "hmac:1f2e3d4c5b6a7988", so that equal values
+	// can be correlated across requests without exposing them.
+	RedactModeHMAC
+)
+
+// redactValue returns the replacement of the redacted value.
+func redactValue(v string, o *Options) string {
+	placeholder := o.RedactPlaceholder
+	if placeholder == "" {
+		placeholder = redactedHeaderValue
+	}
+	switch o.RedactMode {
+	case RedactModeLength:
+		return placeholder + " (len=" + strconv.Itoa(len(v)) + ")"
+	case RedactModeHMAC:
+		if len(o.RedactHMACKey) == 0 {
+			return placeholder
+		}
+		mac := hmac.New(sha256.New, o.RedactHMACKey)
+		mac.Write([]byte(v))
+		return "hmac:" + hex.EncodeToString(mac.Sum(nil)[:8])
+	}
+	return placeholder
+}
+
+// redactValues returns the replacement of the redacted multi-value header, query
+// parameter or form field, whose values are joined by ", " as in HTTP headers.
+func redactValues(vals []string, o *Options) string {
+	if len(vals) == 1 {
+		return redactValue(vals[0], o)
+	}
+	return redactValue(strings.Join(vals, ", "), o)
+}
+
+// redactedName reports whether the name is listed, matched case-insensitively.
+func redactedName(redact []string, name string) bool {
+	return slices.ContainsFunc(redact, func(r string) bool { return strings.EqualFold(r, name) })
+}
+
+// redactBody returns the JSON or form-encoded body with the values of the
+// Options.RedactBodyFields redacted at any depth, or the body itself if there is
+// nothing to redact. Bodies that can't be parsed (e.g. truncated ones) are
+// redacted as a whole, so that a secret isn't logged verbatim.
+func redactBody(body []byte, contentType string, o *Options) []byte {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	mediaType = strings.TrimSpace(strings.ToLower(mediaType))
+	switch {
+	case mediaType == "application/x-www-form-urlencoded":
+		form, err := url.ParseQuery(string(body))
+		if err != nil {
+			return []byte(redactValue(string(body), o))
+		}
+		var redacted bool
+		for name, vals := range form {
+			if redactedName(o.RedactBodyFields, name) {
+				form[name] = []string{redactValues(vals, o)}
+				redacted = true
+			}
+		}
+		if !redacted {
+			return body
+		}
+		return []byte(form.Encode())
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		dec := json.NewDecoder(bytes.NewReader(body))
+		dec.UseNumber()
+		var v any
+		if err := dec.Decode(&v); err != nil {
+			return []byte(redactValue(string(body), o))
+		}
+		if !redactJSON(v, o) {
+			return body
+		}
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(v); err != nil {
+			return []byte(redactValue(string(body), o))
+		}
+		return bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+	}
+	return body
+}
+
+// redactJSON redacts the values of the Options.RedactBodyFields of the decoded
+// JSON value in place, and reports whether any value was redacted.
+func redactJSON(v any, o *Options) bool {
+	var redacted bool
+	switch v := v.(type) {
+	case map[string]any:
+		for k, val := range v {
+			if !redactedName(o.RedactBodyFields, k) {
+				redacted = redactJSON(val, o) || redacted
+				continue
+			}
+			s, ok := val.(string)
+			if !ok {
+				b, _ := json.Marshal(val)
+				s = string(b)
+			}
+			v[k] = redactValue(s, o)
+			redacted = true
+		}
+	case []any:
+		for _, val := range v {
+			redacted = redactJSON(val, o) || redacted
+		}
+	}
+	return redacted
+}
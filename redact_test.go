@@ -0,0 +1,90 @@
+package httplog_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr/funcr"
+	httplog "github.com/rickliujh/chi-httplogr/v3"
+)
+
+func TestRedactBodyFields(t *testing.T) {
+	tests := []struct {
+		contentType string
+		body        string
+		want        string
+	}{
+		{"application/json", `{"user":"u1","auth":{"Password":"s3cr3t"}}`, `"user":"u1"`},
+		{"application/x-www-form-urlencoded", "user=u1&password=s3cr3t", "user=u1"},
+		{"application/json", `{"user":"u1","password":"s3cr3t"`, "[REDACTED]"},
+	}
+	for _, tt := range tests {
+		var logs strings.Builder
+		logger := funcr.NewJSON(func(obj string) { logs.WriteString(obj + "\n") }, funcr.Options{})
+		handler := httplog.RequestLogger(logger, &httplog.Options{
+			LogRequestBody:      func(r *http.Request) bool { return true },
+			LogBodyContentTypes: []string{tt.contentType},
+			RedactBodyFields:    []string{"password"},
+		})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			io.Copy(io.Discard, r.Body)
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(tt.body))
+		req.Header.Set("Content-Type", tt.contentType)
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+
+		if strings.Contains(logs.String(), "s3cr3t") {
+			t.Errorf("%s: redacted body field logged:\n%s", tt.body, logs.String())
+		}
+		if !strings.Contains(logs.String(), strings.ReplaceAll(tt.want, `"`, `\"`)) {
+			t.Errorf("%s: want %s logged:\n%s", tt.body, tt.want, logs.String())
+		}
+	}
+}
+
+func TestRedactCookies(t *testing.T) {
+	var logs strings.Builder
+	logger := funcr.NewJSON(func(obj string) { logs.WriteString(obj + "\n") }, funcr.Options{})
+	handler := httplog.RequestLogger(logger, &httplog.Options{
+		LogCookies: []string{"locale", "session"},
+		RedactMode: httplog.RedactModeLength,
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(&http.Cookie{Name: "locale", Value: "en"})
+	req.AddCookie(&http.Cookie{Name: "session", Value: "s3cr3t"})
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if strings.Contains(logs.String(), "s3cr3t") {
+		t.Errorf("redacted cookie logged:\n%s", logs.String())
+	}
+	if !strings.Contains(logs.String(), `"session":"[REDACTED] (len=6)"`) || !strings.Contains(logs.String(), `"locale":"en"`) {
+		t.Errorf("cookies not logged as expected:\n%s", logs.String())
+	}
+}
+
+func TestRedactMultipleValues(t *testing.T) {
+	var logs strings.Builder
+	logger := funcr.NewJSON(func(obj string) { logs.WriteString(obj + "\n") }, funcr.Options{})
+	handler := httplog.RequestLogger(logger, &httplog.Options{
+		LogRequestHeaders: []string{"X-Token"},
+		RedactHeaders:     []string{"X-Token"},
+		LogQueryParams:    []string{"token"},
+		RedactMode:        httplog.RedactModeLength,
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/?token=ab&token=cd", nil)
+	req.Header.Add("X-Token", "ab")
+	req.Header.Add("X-Token", "cd")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	// Both are redacted as "ab, cd".
+	if got := strings.Count(logs.String(), `"[REDACTED] (len=6)"`); got != 2 {
+		t.Errorf("want the header and the query parameter redacted alike:\n%s", logs.String())
+	}
+}
@@ -0,0 +1,39 @@
+package httplog
+
+import (
+	"net/url"
+)
+
+// RefererPolicy defines how much of the Referer header is logged, see
+// Options.RefererPolicy. Full referer URLs of third-party sites frequently
+// contain tokens and personal data in the query string.
+type RefererPolicy int
+
+const (
+	// RefererFull logs the full Referer header.
+	RefererFull RefererPolicy = iota
+
+	// RefererNoQuery logs the Referer without the query string and fragment,
+	// e.g. "https://example.com/path".
+	RefererNoQuery
+
+	// RefererOrigin logs the origin of the Referer only, e.g. "https://example.com".
+	RefererOrigin
+)
+
+// referer returns the Referer header value according to the policy.
+func referer(ref string, policy RefererPolicy) string {
+	if ref == "" || policy == RefererFull {
+		return ref
+	}
+	u, err := url.Parse(ref)
+	if err != nil || u.Host == "" {
+		// Don't risk logging a malformed value, which may contain anything.
+		return ""
+	}
+	u.User, u.RawQuery, u.ForceQuery, u.Fragment, u.RawFragment = nil, "", false, "", ""
+	if policy == RefererOrigin {
+		u.Path, u.RawPath = "", ""
+	}
+	return u.String()
+}
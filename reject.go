@@ -0,0 +1,158 @@
+package httplog
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"time"
+)
+
+// The reasons of requests rejected before reaching the handler, see SetRejected.
+const (
+	RejectThrottled    = "throttled"     // Over the concurrency limit and the backlog
+	RejectQueueTimeout = "queue_timeout" // Timed out waiting in the backlog
+	RejectCanceled     = "canceled"      // Canceled by the client while waiting in the backlog
+	RejectShed         = "shed"          // Shed by a load-shedding middleware
+)
+
+// reject holds the reason of the rejected request and its queue wait time.
+type reject struct {
+	reason    string
+	queueWait time.Duration
+}
+
+// SetRejected records that the request was rejected by a throttling or load
+// shedding middleware before reaching the handler, with the reason (e.g.
+// RejectShed) and the time the request waited in the queue, which are logged
+// as Schema.RejectReason and Schema.RejectQueueWait instead of an anonymous
+// HTTP 429 or 503.
+//
+// Use it from custom middlewares, or instrument throttling middlewares with
+// InstrumentThrottle.
+func SetRejected(ctx context.Context, reason string, queueWait time.Duration) {
+	if rl := getRequestLog(ctx); rl != nil {
+		rl.mu.Lock()
+		defer rl.mu.Unlock()
+		rl.reject = &reject{reason: reason, queueWait: queueWait}
+	}
+}
+
+type ctxKeyThrottle struct{}
+
+// throttleState tracks the request through the instrumented throttle.
+type throttleState struct {
+	reached bool
+}
+
+// InstrumentThrottle instruments the given throttling middleware, e.g. chi's
+// middleware.Throttle, to record the requests it rejects, see SetRejected:
+//
+//	r.Use(httplog.RequestLogger(logger, opts))
+//	r.Use(httplog.InstrumentThrottle(middleware.Throttle(100)))
+//
+// The requests responded with HTTP 429 or 503 without reaching the next handler
+// are recorded with the time spent in the middleware as queue wait. The reasons
+// of chi's middleware.Throttle are recognized from its error messages; other
+// rejections are recorded as RejectThrottled (HTTP 429) or RejectShed (HTTP 503).
+func InstrumentThrottle(throttle func(http.Handler) http.Handler) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		throttled := throttle(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if st, ok := r.Context().Value(ctxKeyThrottle{}).(*throttleState); ok {
+				st.reached = true
+			}
+			if rw, ok := w.(*rejectWriter); ok {
+				w = rw.ResponseWriter
+			}
+			next.ServeHTTP(w, r)
+		}))
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rl := getRequestLog(r.Context())
+			if rl == nil {
+				throttled.ServeHTTP(w, r)
+				return
+			}
+
+			st := &throttleState{}
+			rw := &rejectWriter{ResponseWriter: w}
+			start := rl.clock.Now()
+			throttled.ServeHTTP(rw, r.WithContext(context.WithValue(r.Context(), ctxKeyThrottle{}, st)))
+			if st.reached {
+				return
+			}
+			if reason := rejectReason(rw.status, rw.body.Bytes()); reason != "" {
+				SetRejected(r.Context(), reason, rl.clock.Since(start))
+			}
+		})
+	}
+}
+
+// rejectReason returns the reason of the rejection by the response status and
+// the error message of chi's middleware.Throttle, if any, or an empty string if
+// the request wasn't rejected.
+func rejectReason(status int, body []byte) string {
+	switch {
+	case bytes.HasPrefix(body, []byte("Timed out while waiting")):
+		return RejectQueueTimeout
+	case bytes.HasPrefix(body, []byte("Context was canceled")):
+		return RejectCanceled
+	case status == http.StatusTooManyRequests:
+		return RejectThrottled
+	case status == http.StatusServiceUnavailable:
+		return RejectShed
+	}
+	return ""
+}
+
+// rejectWriter records the status and the beginning of the body of the
+// rejection responses.
+type rejectWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+// maxRejectBody is the number of bytes of the rejection responses recorded to
+// recognize their reasons.
+const maxRejectBody = 64
+
+func (rw *rejectWriter) WriteHeader(status int) {
+	if rw.status == 0 {
+		rw.status = status
+	}
+	rw.ResponseWriter.WriteHeader(status)
+}
+
+func (rw *rejectWriter) Write(p []byte) (int, error) {
+	if rw.status == 0 {
+		rw.status = http.StatusOK
+	}
+	if n := maxRejectBody - rw.body.Len(); n > 0 {
+		rw.body.Write(p[:min(n, len(p))])
+	}
+	return rw.ResponseWriter.Write(p)
+}
+
+// Unwrap returns the underlying response writer, see http.ResponseController.
+func (rw *rejectWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// rejectKVs returns the reason and the queue wait time of the rejected request,
+// see SetRejected.
+func rejectKVs(ctx context.Context, s *Schema) []any {
+	rl := getRequestLog(ctx)
+	if rl == nil {
+		return nil
+	}
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	if rl.reject == nil {
+		return nil
+	}
+	return []any{
+		s.RejectReason, rl.reject.reason,
+		s.RejectQueueWait, float64(rl.reject.queueWait.Milliseconds()),
+	}
+}
@@ -0,0 +1,16 @@
+package httplog
+
+import (
+	"net/netip"
+)
+
+// splitRemoteAddr splits the remote address of the request into the IP address
+// and the port, e.g. "[::1]:8080" into "::1" and 8080. Addresses without a port,
+// e.g. the client IPs of CDN headers, and unix socket addresses (e.g. "@") are
+// returned as is, with port 0.
+func splitRemoteAddr(addr string) (string, int) {
+	if ap, err := netip.ParseAddrPort(addr); err == nil {
+		return ap.Addr().String(), int(ap.Port())
+	}
+	return addr, 0
+}
@@ -0,0 +1,37 @@
+package httplog
+
+import (
+	"net/http"
+)
+
+// ReplayStore stores the failed requests, so that on-call engineers can re-issue
+// the exact failing requests, see Options.ReplayStore.
+type ReplayStore interface {
+	// Store stores the request with its full body and returns a reference to
+	// the stored request, e.g. an object key or a HAR file path, which is logged
+	// as Schema.RequestReplayRef. The request body was already read; the
+	// headers aren't redacted.
+	Store(r *http.Request, body []byte) (ref string, err error)
+}
+
+// ReplayStoreFunc is an adapter to use an ordinary function as a ReplayStore.
+type ReplayStoreFunc func(r *http.Request, body []byte) (string, error)
+
+// Store implements ReplayStore.
+func (f ReplayStoreFunc) Store(r *http.Request, body []byte) (string, error) {
+	return f(r, body)
+}
+
+// replayable reports whether the request is safe to be re-issued, i.e. whether
+// its method is idempotent or it carries an idempotency key, and whether its
+// body fits into Options.MaxCaptureBytes.
+func replayable(r *http.Request, o *Options) bool {
+	if r.ContentLength > int64(maxCaptureBytes(o)) {
+		return false
+	}
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return idempotencyKey(r, o) != ""
+}
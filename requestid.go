@@ -0,0 +1,44 @@
+package httplog
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// requestID returns the ID of the request set by chi's middleware.RequestID, or
+// the X-Request-Id request header value, or an empty string.
+func requestID(ctx context.Context, r *http.Request) string {
+	if id := middleware.GetReqID(ctx); id != "" {
+		return id
+	}
+	return r.Header.Get(middleware.RequestIDHeader)
+}
+
+// parentRequest holds the state propagating the request ID to child requests,
+// see Options.ParentRequestIDHeader.
+type parentRequest struct {
+	header    string
+	requestID string // X-Request-Id header of the request, if chi's middleware.RequestID isn't used
+}
+
+// propagateRequestID sets the ID of the request in ctx as the parent request ID
+// of the outgoing child request, see Options.ParentRequestIDHeader. It returns
+// the child request, cloned if modified.
+func propagateRequestID(ctx context.Context, req *http.Request) *http.Request {
+	rl := getRequestLog(ctx)
+	if rl == nil || rl.parent.header == "" {
+		return req
+	}
+	id := middleware.GetReqID(ctx)
+	if id == "" {
+		id = rl.parent.requestID
+	}
+	if id == "" {
+		return req
+	}
+	clone := req.Clone(ctx)
+	clone.Header.Set(rl.parent.header, id)
+	return clone
+}
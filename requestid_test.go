@@ -0,0 +1,147 @@
+package httplog_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"regexp"
+	"testing"
+
+	"github.com/go-chi/chi/v5/middleware"
+	httplog "github.com/rickliujh/chi-httplogr/v3"
+	"github.com/rickliujh/chi-httplogr/v3/httplogtest"
+)
+
+func TestEchoRequestIDHeader(t *testing.T) {
+	rec := httplogtest.NewRecorder()
+	logger := httplog.RequestLogger(rec.Logger(), &httplog.Options{
+		Levels:              &httplog.Levels{Warn: 1, Info: 1, Debug: 1},
+		LogRequestID:        true,
+		EchoRequestIDHeader: "X-Request-Id",
+	})
+	var handlerID string
+	handler := logger(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handlerID = middleware.GetReqID(r.Context())
+	}))
+
+	// Without chi's middleware.RequestID or Options.IDGenerator, a token is generated.
+	resp, entry := rec.RoundTrip(handler, httptest.NewRequest(http.MethodGet, "/", nil))
+	id := resp.Header.Get("X-Request-Id")
+	if !regexp.MustCompile(`^[0-9a-f]{16}$`).MatchString(id) {
+		t.Fatalf("got echoed request ID %q, want a 16 hex character token", id)
+	}
+	if handlerID != id {
+		t.Errorf("got request ID %q in the handler, want %q", handlerID, id)
+	}
+	if !entry.HasKV(httplog.SchemaECS.RequestID, id) {
+		t.Errorf("entry is missing the echoed request ID %q: %v", id, entry.KVs)
+	}
+
+	// The ID set by chi's middleware.RequestID is echoed as is.
+	resp, entry = rec.RoundTrip(middleware.RequestID(handler), httptest.NewRequest(http.MethodGet, "/", nil))
+	id = resp.Header.Get("X-Request-Id")
+	if id == "" || id != handlerID {
+		t.Errorf("got echoed request ID %q, want %q set by middleware.RequestID", id, handlerID)
+	}
+	if !entry.HasKV(httplog.SchemaECS.RequestID, id) {
+		t.Errorf("entry is missing the echoed request ID %q: %v", id, entry.KVs)
+	}
+}
+
+func TestEchoRequestIDHeaderIDGenerator(t *testing.T) {
+	rec := httplogtest.NewRecorder()
+	handler := httplog.RequestLogger(rec.Logger(), &httplog.Options{
+		Levels:              &httplog.Levels{Warn: 1, Info: 1, Debug: 1},
+		LogRequestID:        true,
+		IDGenerator:         func() string { return "generated-id" },
+		EchoRequestIDHeader: "X-Request-Id",
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	resp, entry := rec.RoundTrip(handler, httptest.NewRequest(http.MethodGet, "/", nil))
+	if got := resp.Header.Get("X-Request-Id"); got != "generated-id" {
+		t.Errorf("got echoed request ID %q, want the generated one", got)
+	}
+	if !entry.HasKV(httplog.SchemaECS.RequestID, "generated-id") {
+		t.Errorf("entry is missing the generated request ID: %v", entry.KVs)
+	}
+
+	// An incoming X-Request-Id header takes precedence over the generator.
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Request-Id", "incoming-id")
+	resp, _ = rec.RoundTrip(handler, req)
+	if got := resp.Header.Get("X-Request-Id"); got != "incoming-id" {
+		t.Errorf("got echoed request ID %q, want the incoming one", got)
+	}
+}
+
+func TestParentRequestIDHeader(t *testing.T) {
+	opts := &httplog.Options{
+		Levels:                &httplog.Levels{Warn: 1, Info: 1, Debug: 1},
+		LogRequestID:          true,
+		ParentRequestIDHeader: "X-Parent-Request-Id",
+	}
+
+	upstreamRec := httplogtest.NewRecorder()
+	upstream := httptest.NewServer(httplog.RequestLogger(upstreamRec.Logger(), opts)(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+	))
+	defer upstream.Close()
+	target, _ := url.Parse(upstream.URL)
+
+	rec := httplogtest.NewRecorder()
+	var requestID string
+	proxy := httplog.InstrumentProxy(httputil.NewSingleHostReverseProxy(target))
+	handler := middleware.RequestID(httplog.RequestLogger(rec.Logger(), opts)(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestID = middleware.GetReqID(r.Context())
+			proxy.ServeHTTP(w, r)
+		}),
+	))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	resp, _ := rec.RoundTrip(handler, req)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("got status %d, want 200", resp.StatusCode)
+	}
+	if req.Header.Get("X-Parent-Request-Id") != "" {
+		t.Errorf("the incoming request header was modified: %v", req.Header)
+	}
+	entry := upstreamRec.LastEntry()
+	if requestID == "" || !entry.HasKV(httplog.SchemaECS.RequestParentID, requestID) {
+		t.Errorf("upstream entry is missing the parent request ID %q: %v", requestID, entry.KVs)
+	}
+}
+
+func TestParentRequestIDHeaderClient(t *testing.T) {
+	var parentID string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		parentID = r.Header.Get("X-Parent-Request-Id")
+	}))
+	defer upstream.Close()
+
+	rec := httplogtest.NewRecorder()
+	client := &http.Client{Transport: httplog.UpstreamTransport(nil)}
+	handler := httplog.RequestLogger(rec.Logger(), &httplog.Options{
+		ParentRequestIDHeader: "X-Parent-Request-Id",
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		out, _ := http.NewRequestWithContext(r.Context(), http.MethodGet, upstream.URL, nil)
+		resp, err := client.Do(out)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		resp.Body.Close()
+		if out.Header.Get("X-Parent-Request-Id") != "" {
+			t.Errorf("the outgoing request header was modified: %v", out.Header)
+		}
+	}))
+
+	// Without chi's middleware.RequestID, the incoming X-Request-Id header is propagated.
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Request-Id", "incoming-id")
+	rec.RoundTrip(handler, req)
+	if parentID != "incoming-id" {
+		t.Errorf("got parent request ID %q upstream, want the incoming request ID", parentID)
+	}
+}
@@ -0,0 +1,239 @@
+// Package rfc5424 provides a logr.LogSink rendering each log entry as an
+// RFC 5424 syslog message, with the key-value pairs of the entry rendered as
+// SD-PARAMs, for environments that require syslog delivery of access logs:
+//
+//	conn, _ := net.Dial("udp", "localhost:514")
+//	logger := rfc5424.New(conn, rfc5424.Options{AppName: "example-app"})
+//
+//	r.Use(httplog.RequestLogger(logger, &httplog.Options{
+//		Schema: httplog.SchemaECS,
+//	}))
+package rfc5424
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+// Syslog severities, see RFC 5424 section 6.2.1.
+const (
+	severityError = 3
+	severityInfo  = 6
+	severityDebug = 7
+)
+
+// Options configures the syslog sink.
+type Options struct {
+	// Facility is the syslog facility code.
+	//
+	// If not provided, the default is 16 (local0).
+	Facility int
+
+	// Hostname is the HOSTNAME header field.
+	//
+	// If not provided, os.Hostname() is used.
+	Hostname string
+
+	// AppName is the APP-NAME header field.
+	//
+	// If not provided, the NILVALUE "-" is used.
+	AppName string
+
+	// MsgID is the MSGID header field.
+	//
+	// If not provided, the default is "access".
+	MsgID string
+
+	// SDID is the SD-ID of the structured data element holding the key-value pairs.
+	//
+	// If not provided, the default is "httplog@32473".
+	SDID string
+
+	// Verbosity is the maximum V-level of the written messages, like the
+	// verbosity of funcr: entries logged with logger.V(n) for n > Verbosity are
+	// discarded.
+	//
+	// If not provided, only V(0) entries and errors are written.
+	Verbosity int
+}
+
+// New returns a logger writing RFC 5424 syslog messages to w, one per Write call.
+func New(w io.Writer, opts Options) logr.Logger {
+	if opts.Facility == 0 {
+		opts.Facility = 16
+	}
+	if opts.Hostname == "" {
+		opts.Hostname, _ = os.Hostname()
+	}
+	if opts.MsgID == "" {
+		opts.MsgID = "access"
+	}
+	if opts.SDID == "" {
+		opts.SDID = "httplog@32473"
+	}
+
+	return logr.New(&sink{
+		w:    &lockedWriter{w: w},
+		opts: opts,
+		pid:  os.Getpid(),
+	})
+}
+
+type lockedWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (lw *lockedWriter) Write(p []byte) (int, error) {
+	lw.mu.Lock()
+	defer lw.mu.Unlock()
+	return lw.w.Write(p)
+}
+
+type sink struct {
+	w      *lockedWriter
+	opts   Options
+	pid    int
+	name   string
+	values []any
+}
+
+var _ logr.LogSink = &sink{}
+
+func (s *sink) Init(info logr.RuntimeInfo) {}
+
+func (s *sink) Enabled(level int) bool {
+	return level <= s.opts.Verbosity
+}
+
+func (s *sink) Info(level int, msg string, keysAndValues ...any) {
+	severity := severityInfo
+	if level > 0 {
+		severity = severityDebug
+	}
+	s.write(severity, msg, keysAndValues)
+}
+
+func (s *sink) Error(err error, msg string, keysAndValues ...any) {
+	if err != nil {
+		keysAndValues = append([]any{"error", err.Error()}, keysAndValues...)
+	}
+	s.write(severityError, msg, keysAndValues)
+}
+
+func (s *sink) WithValues(keysAndValues ...any) logr.LogSink {
+	values := append(append([]any(nil), s.values...), keysAndValues...)
+	return &sink{w: s.w, opts: s.opts, pid: s.pid, name: s.name, values: values}
+}
+
+func (s *sink) WithName(name string) logr.LogSink {
+	if s.name != "" {
+		name = s.name + "." + name
+	}
+	return &sink{w: s.w, opts: s.opts, pid: s.pid, name: name, values: s.values}
+}
+
+func (s *sink) write(severity int, msg string, keysAndValues []any) {
+	var b strings.Builder
+
+	appName := s.opts.AppName
+	if s.name != "" {
+		appName = strings.TrimPrefix(appName+"."+s.name, ".")
+	}
+
+	fmt.Fprintf(&b, "<%d>1 %s %s %s %d %s ",
+		s.opts.Facility*8+severity,
+		time.Now().UTC().Format(time.RFC3339Nano),
+		headerField(s.opts.Hostname, 255),
+		headerField(appName, 48),
+		s.pid,
+		headerField(s.opts.MsgID, 32),
+	)
+
+	params := map[string]string{}
+	for _, kvs := range [][]any{s.values, keysAndValues} {
+		for i := 0; i+1 < len(kvs); i += 2 {
+			if key, ok := kvs[i].(string); ok && key != "" {
+				flatten(params, key, kvs[i+1])
+			}
+		}
+	}
+
+	if len(params) == 0 {
+		b.WriteString("-")
+	} else {
+		names := make([]string, 0, len(params))
+		for name := range params {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		fmt.Fprintf(&b, "[%s", s.opts.SDID)
+		for _, name := range names {
+			fmt.Fprintf(&b, " %s=\"%s\"", name, escapeParamValue(params[name]))
+		}
+		b.WriteString("]")
+	}
+
+	b.WriteString(" ")
+	b.WriteString(msg)
+	b.WriteString("\n")
+
+	s.w.Write([]byte(b.String()))
+}
+
+// flatten adds the value to the params, flattening nested objects with dots.
+func flatten(params map[string]string, key string, v any) {
+	if m, ok := v.(map[string]any); ok {
+		for k, v := range m {
+			flatten(params, key+"."+k, v)
+		}
+		return
+	}
+	params[paramName(key)] = fmt.Sprint(v)
+}
+
+// paramName returns a valid PARAM-NAME, i.e. up to 32 printable US-ASCII
+// characters except '=', ' ', ']' and '"'.
+func paramName(key string) string {
+	name := strings.Map(func(r rune) rune {
+		if r <= ' ' || r > '~' || r == '=' || r == ']' || r == '"' {
+			return '_'
+		}
+		return r
+	}, key)
+	if len(name) > 32 {
+		name = name[:32]
+	}
+	return name
+}
+
+// escapeParamValue escapes '"', '\' and ']' in the PARAM-VALUE.
+func escapeParamValue(v string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`).Replace(v)
+}
+
+// headerField returns a valid header field of up to maxLen printable US-ASCII
+// characters, or the NILVALUE "-" if empty.
+func headerField(v string, maxLen int) string {
+	v = strings.Map(func(r rune) rune {
+		if r <= ' ' || r > '~' {
+			return -1
+		}
+		return r
+	}, v)
+	if v == "" {
+		return "-"
+	}
+	if len(v) > maxLen {
+		v = v[:maxLen]
+	}
+	return v
+}
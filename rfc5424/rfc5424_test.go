@@ -0,0 +1,79 @@
+package rfc5424_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/rickliujh/chi-httplogr/v3/rfc5424"
+)
+
+func TestVerbosity(t *testing.T) {
+	tests := []struct {
+		verbosity int
+		want      []string
+	}{
+		{verbosity: 0, want: []string{"error", "access"}},
+		{verbosity: 1, want: []string{"error", "access", "body"}},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		logger := rfc5424.New(&buf, rfc5424.Options{Hostname: "host", Verbosity: tt.verbosity})
+		logger.Error(errors.New("boom"), "error")
+		logger.Info("access")
+		logger.V(1).Info("body")
+		logger.V(2).Info("debug")
+
+		var got []string
+		for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+			got = append(got, line[strings.LastIndex(line, " ")+1:])
+		}
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("verbosity %d: got messages %v, want %v", tt.verbosity, got, tt.want)
+		}
+	}
+}
+
+func TestStructuredData(t *testing.T) {
+	tests := []struct {
+		name string
+		kvs  []any
+		want string
+	}{
+		{name: "Empty", kvs: nil, want: " - msg"},
+		{name: "Sorted", kvs: []any{"b", 2, "a", 1}, want: ` [httplog@32473 a="1" b="2"] msg`},
+		{name: "Escaped", kvs: []any{"v", `a"b\c]d`}, want: ` [httplog@32473 v="a\"b\\c\]d"] msg`},
+		{name: "InvalidName", kvs: []any{`a b=c]"d`, 1}, want: ` [httplog@32473 a_b_c__d="1"] msg`},
+		{name: "LongName", kvs: []any{strings.Repeat("k", 40), 1}, want: ` [httplog@32473 ` + strings.Repeat("k", 32) + `="1"] msg`},
+		{name: "Flattened", kvs: []any{"http", map[string]any{"request": map[string]any{"method": "GET"}}}, want: ` [httplog@32473 http.request.method="GET"] msg`},
+		{name: "EmptyKey", kvs: []any{"", 1, 2, 3}, want: " - msg"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			rfc5424.New(&buf, rfc5424.Options{Hostname: "host"}).Info("msg", tt.kvs...)
+			if got := strings.TrimSuffix(buf.String(), "\n"); !strings.HasSuffix(got, " access"+tt.want) {
+				t.Errorf("got %q, want suffix %q", got, " access"+tt.want)
+			}
+		})
+	}
+}
+
+func TestHeader(t *testing.T) {
+	var buf bytes.Buffer
+	logger := rfc5424.New(&buf, rfc5424.Options{Facility: 1, Hostname: "my host", AppName: "app"}).WithName("http")
+	logger.Error(nil, "msg")
+	logger.V(0).Info("msg")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	for i, prefix := range []string{"<11>1 ", "<14>1 "} { // facility 1 * 8 + severity
+		if !strings.HasPrefix(lines[i], prefix) {
+			t.Errorf("got %q, want prefix %q", lines[i], prefix)
+		}
+		fields := strings.Fields(lines[i])
+		if fields[2] != "myhost" || fields[3] != "app.http" || fields[5] != "access" {
+			t.Errorf("unexpected header fields: %q", lines[i])
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package httplog
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// OtherRoute is the label of routes bucketed together by MetricsLabel.
+const OtherRoute = "other"
+
+// RoutePatternFunc returns the route pattern matched by the request, e.g.
+// "/users/{id}", or an empty string if the request wasn't routed (yet).
+type RoutePatternFunc func(r *http.Request) string
+
+// ChiRoutePattern returns the chi route pattern of the request.
+func ChiRoutePattern(r *http.Request) string {
+	if rctx := chi.RouteContext(r.Context()); rctx != nil {
+		return rctx.RoutePattern()
+	}
+	return ""
+}
+
+// ServeMuxRoutePattern returns the http.ServeMux pattern of the request (Go 1.23+),
+// e.g. "GET /users/{id}". It's always empty when built with older Go versions.
+func ServeMuxRoutePattern(r *http.Request) string {
+	return serveMuxPattern(r)
+}
+
+// DefaultRoutePattern returns the chi route pattern of the request, falling back
+// to the http.ServeMux pattern for non-chi routers.
+func DefaultRoutePattern(r *http.Request) string {
+	if pattern := ChiRoutePattern(r); pattern != "" {
+		return pattern
+	}
+	return ServeMuxRoutePattern(r)
+}
+
+// chiMountedRoute returns the mount prefix of the top-level chi sub-router that
+// routed the request, e.g. "/api/v1" for r.Mount("/api/v1", apiRouter), and the
+// route pattern within the sub-router, e.g. "/users/{id}". Both are empty if
+// the request wasn't routed by a mounted sub-router.
+func chiMountedRoute(r *http.Request) (prefix, inner string) {
+	rctx := chi.RouteContext(r.Context())
+	if rctx == nil || len(rctx.RoutePatterns) < 2 {
+		return "", ""
+	}
+	prefix = strings.TrimSuffix(strings.TrimSuffix(rctx.RoutePatterns[0], "/*"), "/")
+	inner = strings.TrimPrefix(rctx.RoutePattern(), prefix)
+	if inner == "" {
+		inner = "/"
+	}
+	return prefix, inner
+}
+
+// MetricsLabel returns a bounded-cardinality route label of the request, which
+// is safe to be used as a metrics (e.g. Prometheus) label. It must be called
+// after the request was routed, i.e. after the underlying HTTP handler returns.
+//
+// The label is resolved by the first matching strategy:
+//   - Options.MetricsLabelFunc, if set
+//   - the route pattern, if allowed by Options.MetricsRoutes
+//   - OtherRoute otherwise, e.g. for requests that didn't match any route
+func MetricsLabel(r *http.Request, o *Options) string {
+	if o != nil && o.MetricsLabelFunc != nil {
+		var label string
+		callHook(r.Context(), "MetricsLabelFunc", func() { label = o.MetricsLabelFunc(r) })
+		return label
+	}
+
+	pattern := routePattern(r, o)
+	if pattern == "" {
+		return OtherRoute
+	}
+	if o != nil && len(o.MetricsRoutes) > 0 && !slices.Contains(o.MetricsRoutes, pattern) {
+		return OtherRoute
+	}
+	return pattern
+}
+
+// routePattern returns the route pattern of the request using the provider
+// configured by Options.RoutePattern, or DefaultRoutePattern.
+func routePattern(r *http.Request, o *Options) string {
+	if o != nil && o.RoutePattern != nil {
+		var pattern string
+		callHook(r.Context(), "RoutePattern", func() { pattern = o.RoutePattern(r) })
+		return pattern
+	}
+	return DefaultRoutePattern(r)
+}
@@ -0,0 +1,12 @@
+//go:build !go1.23
+
+package httplog
+
+import (
+	"net/http"
+)
+
+// serveMuxPattern is not supported, since http.Request.Pattern was added in Go 1.23.
+func serveMuxPattern(r *http.Request) string {
+	return ""
+}
@@ -0,0 +1,11 @@
+//go:build go1.23
+
+package httplog
+
+import (
+	"net/http"
+)
+
+func serveMuxPattern(r *http.Request) string {
+	return r.Pattern
+}
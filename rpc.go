@@ -0,0 +1,55 @@
+package httplog
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// JSONRPCInspector returns a preset for Options.InspectRequestBody, which logs
+// the JSON-RPC method and request ID as Schema.RPCMethod and Schema.RPCRequestID,
+// so that RPC-over-POST traffic can be grouped by the logical operation.
+//
+// For batch requests, the method and ID of the first call are logged.
+func JSONRPCInspector(s *Schema) func(req *http.Request, body []byte) []any {
+	return func(req *http.Request, body []byte) []any {
+		var call struct {
+			JSONRPC string          `json:"jsonrpc"`
+			Method  string          `json:"method"`
+			ID      json.RawMessage `json:"id"`
+		}
+		if err := json.Unmarshal(body, &call); err != nil {
+			var batch []json.RawMessage
+			if json.Unmarshal(body, &batch) != nil || len(batch) == 0 || json.Unmarshal(batch[0], &call) != nil {
+				return nil
+			}
+		}
+		if call.Method == "" {
+			return nil
+		}
+
+		kvs := []any{s.RPCMethod, call.Method}
+		if len(call.ID) > 0 && string(call.ID) != "null" {
+			var id any
+			if json.Unmarshal(call.ID, &id) == nil {
+				kvs = append(kvs, s.RPCRequestID, id)
+			}
+		}
+		return kvs
+	}
+}
+
+// RouteRPCInspector returns a preset for Options.InspectRequestBody, which logs
+// the RPC method mapped from the HTTP method and chi route pattern (e.g. routes
+// generated by gRPC-gateway) as Schema.RPCMethod, e.g.:
+//
+//	httplog.RouteRPCInspector(httplog.SchemaOTEL, map[string]string{
+//		"POST /v1/users": "users.v1.UserService/CreateUser",
+//	})
+func RouteRPCInspector(s *Schema, methods map[string]string) func(req *http.Request, body []byte) []any {
+	return func(req *http.Request, body []byte) []any {
+		if method, ok := methods[req.Method+" "+DefaultRoutePattern(req)]; ok {
+			return []any{s.RPCMethod, method}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,196 @@
+package httplog
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Sampler decides whether to record the logs of successful requests, see
+// Options.Sampler. Logs of failed requests are always recorded.
+type Sampler interface {
+	// Sample reports whether to record the log of a successful request to the
+	// given route (see MetricsLabel), and the sampling rate used for the decision.
+	Sample(route string) (sampled bool, rate float64)
+}
+
+// TenantSampler is a Sampler that also keys its decisions by the tenant ID of
+// the request (see Options.TenantFunc), so that a noisy tenant can be
+// down-sampled without affecting the others. The tenant ID is resolved before
+// the sampling decision.
+type TenantSampler interface {
+	Sampler
+
+	// SampleTenant is like Sample for a request of the tenant, or of no tenant if
+	// the tenant ID is empty.
+	SampleTenant(route, tenant string) (sampled bool, rate float64)
+}
+
+// AdaptiveSampler is a TenantSampler that targets a maximum number of recorded
+// logs of successful requests per second. Every second, it adjusts the sampling
+// rate of each route and tenant to its fair share of the budget, so that the
+// logging cost stays flat as the traffic grows, while rarely requested routes
+// and quiet tenants are still recorded.
+type AdaptiveSampler struct {
+	maxPerSecond float64
+	clock        Clock
+
+	mu     sync.Mutex
+	window time.Time
+	counts map[samplerKey]int
+	rates  map[samplerKey]float64
+}
+
+// samplerKey is the route and tenant the AdaptiveSampler shares the budget by.
+type samplerKey struct {
+	route, tenant string
+}
+
+// NewAdaptiveSampler returns an AdaptiveSampler targeting at most maxPerSecond
+// recorded logs of successful requests per second.
+func NewAdaptiveSampler(maxPerSecond float64) *AdaptiveSampler {
+	return &AdaptiveSampler{
+		maxPerSecond: maxPerSecond,
+		clock:        systemClock{},
+		counts:       map[samplerKey]int{},
+		rates:        map[samplerKey]float64{},
+	}
+}
+
+// Sample implements Sampler.
+func (s *AdaptiveSampler) Sample(route string) (bool, float64) {
+	return s.SampleTenant(route, "")
+}
+
+// SampleTenant implements TenantSampler.
+func (s *AdaptiveSampler) SampleTenant(route, tenant string) (bool, float64) {
+	key := samplerKey{route, tenant}
+	s.mu.Lock()
+	now := s.clock.Now()
+	if now.Sub(s.window) >= time.Second {
+		s.adjust(now)
+	}
+	s.counts[key]++
+	rate, ok := s.rates[key]
+	if !ok {
+		rate = 1
+	}
+	s.mu.Unlock()
+
+	return rate >= 1 || rand.Float64() < rate, rate
+}
+
+// adjust computes the sampling rates of the routes and tenants from the request
+// counts of the past window and starts a new window. s.mu must be held.
+func (s *AdaptiveSampler) adjust(now time.Time) {
+	elapsed := now.Sub(s.window).Seconds()
+	if s.window.IsZero() || elapsed > 2 {
+		// No (recent) traffic to adjust to.
+		elapsed = 0
+	}
+
+	rates := make(map[samplerKey]float64, len(s.counts))
+	if elapsed > 0 && len(s.counts) > 0 {
+		share := s.maxPerSecond / float64(len(s.counts))
+		for key, count := range s.counts {
+			rates[key] = min(1, share/(float64(count)/elapsed))
+		}
+	}
+
+	s.window = now
+	s.rates = rates
+	s.counts = make(map[samplerKey]int, len(rates))
+}
+
+// samplingDecision is the sampling decision of the request log, made once, when
+// it's needed first.
+type samplingDecision struct {
+	once    sync.Once
+	decide  func() (sampled bool, rate float64)
+	sampled bool
+	rate    float64
+	header  string
+}
+
+func (d *samplingDecision) get() (sampled bool, rate float64) {
+	d.once.Do(func() {
+		d.sampled, d.rate = d.decide()
+	})
+	return d.sampled, d.rate
+}
+
+// newSamplingDecision returns the sampling decision of the request, honoring the
+// decision of a trusted upstream service propagated by Options.SamplingHeader.
+// The tenant ID is resolved for a TenantSampler.
+func newSamplingDecision(ctx context.Context, rl *requestLog, r *http.Request, o *Options) *samplingDecision {
+	return &samplingDecision{
+		header: o.SamplingHeader,
+		decide: func() (bool, float64) {
+			if o.SamplingHeader != "" && trustedUpstream(ctx, r, o) {
+				if v := r.Header.Get(o.SamplingHeader); v != "" {
+					if rate, err := strconv.ParseFloat(v, 64); err == nil && rate >= 0 && rate <= 1 {
+						return rate > 0, rate
+					}
+				}
+			}
+			// Record the request if the sampler fails.
+			sampled, rate := true, 1.0
+			route := MetricsLabel(r.WithContext(ctx), o)
+			if ts, ok := o.Sampler.(TenantSampler); ok {
+				tenant := rl.resolveTenant(ctx, r, o)
+				callHook(ctx, "Sampler", func() { sampled, rate = ts.SampleTenant(route, tenant) })
+				return sampled, rate
+			}
+			callHook(ctx, "Sampler", func() { sampled, rate = o.Sampler.Sample(route) })
+			return sampled, rate
+		},
+	}
+}
+
+// combineSamplingRates returns the sampling rate of two independent sampling
+// decisions, where a zero rate means no sampling.
+func combineSamplingRates(a, b float64) float64 {
+	switch {
+	case a == 0:
+		return b
+	case b == 0:
+		return a
+	}
+	return a * b
+}
+
+// trustedUpstream reports whether the request comes from an upstream service
+// trusted by Options.TrustUpstream.
+func trustedUpstream(ctx context.Context, r *http.Request, o *Options) bool {
+	if o.TrustUpstream == nil {
+		return false
+	}
+	var trusted bool
+	callHook(ctx, "TrustUpstream", func() { trusted = o.TrustUpstream(r) })
+	return trusted
+}
+
+// Sampled returns the sampling decision of the request log, i.e. whether the
+// log of the successful request will be recorded, and the sampling rate used.
+// It reports true if no Options.Sampler is configured.
+//
+// The decision is made on the first call, so call it after the request was
+// routed, e.g. from the HTTP handler, to get the per-route sampling rate.
+func Sampled(ctx context.Context) (sampled bool, rate float64) {
+	if rl := getRequestLog(ctx); rl != nil && rl.sampling != nil {
+		return rl.sampling.get()
+	}
+	return true, 1
+}
+
+// samplingHeaderValue returns the value of Options.SamplingHeader propagating
+// the sampling decision downstream, i.e. the sampling rate if sampled or "0".
+func samplingHeaderValue(sampled bool, rate float64) string {
+	if !sampled {
+		return "0"
+	}
+	return strconv.FormatFloat(rate, 'g', -1, 64)
+}
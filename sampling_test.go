@@ -0,0 +1,149 @@
+package httplog_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	httplog "github.com/rickliujh/chi-httplogr/v3"
+	"github.com/rickliujh/chi-httplogr/v3/httplogtest"
+)
+
+// sampleAll is a Sampler recording all requests.
+type sampleAll struct{}
+
+func (sampleAll) Sample(string) (bool, float64) { return true, 1 }
+
+func TestSamplingHeaderTrust(t *testing.T) {
+	tests := []struct {
+		name        string
+		trust       func(r *http.Request) bool
+		wantEntries int
+	}{
+		{name: "Untrusted", trust: nil, wantEntries: 1},
+		{name: "Trusted", trust: func(r *http.Request) bool { return true }, wantEntries: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httplogtest.NewRecorder()
+			handler := httplog.RequestLogger(rec.Logger(), &httplog.Options{
+				Levels:         &httplog.Levels{Warn: 1, Info: 1, Debug: 1},
+				Sampler:        sampleAll{},
+				SamplingHeader: "X-Log-Sampling",
+				TrustUpstream:  tt.trust,
+			})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("X-Log-Sampling", "0")
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			if got := len(rec.Entries()); got != tt.wantEntries {
+				t.Errorf("got %d entries, want %d", got, tt.wantEntries)
+			}
+		})
+	}
+}
+
+func TestCombinedSamplingRate(t *testing.T) {
+	tests := []struct {
+		name     string
+		trust    func(r *http.Request) bool
+		wantRate float64
+	}{
+		{name: "Untrusted", trust: nil, wantRate: 0.5},
+		{name: "Trusted", trust: func(r *http.Request) bool { return true }, wantRate: 0.25},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httplogtest.NewRecorder()
+			handler := httplog.RequestLogger(rec.Logger(), &httplog.Options{
+				Schema:                httplog.SchemaECS,
+				Levels:                &httplog.Levels{Warn: 1, Info: 1, Debug: 1},
+				HeadPolicy:            httplog.MethodPolicySample,
+				MethodSampleRate:      0.5,
+				LowPrioritySampleRate: 0.5,
+				TrustUpstream:         tt.trust,
+			})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+			for i := 0; i < 200; i++ {
+				req := httptest.NewRequest(http.MethodHead, "/", nil)
+				req.Header.Set("Priority", "u=7")
+				handler.ServeHTTP(httptest.NewRecorder(), req)
+			}
+
+			entries := rec.Entries()
+			if len(entries) == 0 {
+				t.Fatal("no entries recorded")
+			}
+			for _, entry := range entries {
+				if !entry.HasKV(httplog.SchemaECS.SamplingRate, tt.wantRate) {
+					t.Fatalf("want sampling rate %v; entry: %v", tt.wantRate, entry.KVs)
+				}
+			}
+		})
+	}
+}
+
+func TestSamplingLargeHeaders(t *testing.T) {
+	rec := httplogtest.NewRecorder()
+	handler := httplog.RequestLogger(rec.Logger(), &httplog.Options{
+		Schema:           httplog.SchemaECS,
+		Levels:           &httplog.Levels{Warn: 1, Info: 1, Debug: 1},
+		OnlyErrors:       true,
+		LargeHeaderBytes: 100,
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Cookie", strings.Repeat("a", 200))
+	if _, entry := rec.RoundTrip(handler, req); !entry.HasKV(httplog.SchemaECS.RequestHeadersLarge, true) {
+		t.Errorf("want the request with large headers logged; entry: %v", entry.KVs)
+	}
+}
+
+// dropTenant is a TenantSampler dropping the logs of a tenant.
+type dropTenant string
+
+func (d dropTenant) Sample(route string) (bool, float64) { return d.SampleTenant(route, "") }
+
+func (d dropTenant) SampleTenant(route, tenant string) (bool, float64) {
+	if tenant == string(d) {
+		return false, 0.5
+	}
+	return true, 1
+}
+
+func TestTenantSampler(t *testing.T) {
+	var calls int
+	rec := httplogtest.NewRecorder()
+	handler := httplog.RequestLogger(rec.Logger(), &httplog.Options{
+		Levels:  &httplog.Levels{Warn: 1, Info: 1, Debug: 1},
+		Sampler: dropTenant("noisy"),
+		TenantFunc: func(r *http.Request) string {
+			calls++
+			return r.Header.Get("X-Tenant")
+		},
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The decision is made in the handler, so before TenantFunc would run
+		// after the handler.
+		if sampled, _ := httplog.Sampled(r.Context()); sampled != (r.Header.Get("X-Tenant") != "noisy") {
+			t.Errorf("tenant %q: sampled = %v", r.Header.Get("X-Tenant"), sampled)
+		}
+	}))
+
+	for _, tenant := range []string{"noisy", "quiet"} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-Tenant", tenant)
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	entries := rec.Entries()
+	if len(entries) != 1 || !entries[0].HasKV(httplog.SchemaECS.TenantID, "quiet") {
+		t.Errorf("want the quiet tenant logged only; got %v", entries)
+	}
+	if calls != 2 {
+		t.Errorf("got %d TenantFunc calls, want 2", calls)
+	}
+}
@@ -2,6 +2,7 @@ package httplog
 
 import (
 	"log/slog"
+	"slices"
 	"strings"
 	"time"
 )
@@ -13,12 +14,24 @@ import (
 // platforms and standards (ECS, OTEL, GCP, etc.) by providing the schema.
 type Schema struct {
 	// Base attributes for core logging information.
-	Timestamp       string // Timestamp of the log entry
-	Level           string // Log level (e.g. INFO, WARNING, ERROR)
-	Message         string // Primary log message
-	ErrorMessage    string // Error message when an error occurs
-	ErrorType       string // Low-cardinality error type (e.g. "ClientAborted", "ValidationError")
-	ErrorStackTrace string // Stack trace for panic or error
+	Timestamp        string // Timestamp of the log entry
+	Level            string // Log level (e.g. INFO, WARNING, ERROR)
+	Message          string // Primary log message
+	ErrorMessage     string // Error message when an error occurs
+	ErrorType        string // Low-cardinality error type (e.g. "ClientAborted", "ValidationError")
+	EventOutcome     string // Outcome of the request: success, failure or unknown
+	AbortHeadersSent string // Whether the response headers were sent before the client aborted
+	AbortElapsed     string // Time from the request start to the client abort in milliseconds
+	AbortNoticed     string // Time from the request start to the client abort noticed by ClientGone in milliseconds
+	AbortProgress    string // Percentage of the response Content-Length written before the client abort
+	HookErrors       string // Failures of the user-provided hooks, see HookError
+	ErrorTitle       string // Short human-readable summary of the error, e.g. from RFC 7807 problem details
+	ErrorDetail      string // Human-readable explanation of the error, e.g. from RFC 7807 problem details
+	ErrorStackTrace  string // Stack trace for panic or error
+	TraceID          string // Trace ID of the distributed trace, see Options.TracePropagators
+	TransactionID    string // Transaction ID of the tracing agent (e.g. Elastic APM), see Options.TraceContext
+	SpanID           string // Span ID of the caller within the distributed trace
+	Baggage          string // Selected W3C Baggage entries propagated by the caller, see Options.LogBaggage
 
 	// Source code location attributes for tracking origin of log statements.
 	SourceFile     string // Source file name where the log originated
@@ -26,31 +39,168 @@ type Schema struct {
 	SourceFunction string // Function name where the log originated
 
 	// Request attributes for the incoming HTTP request.
-	// NOTE: RequestQuery is intentionally not supported as it would likely leak sensitive data.
-	RequestURL         string // Full request URL
-	RequestMethod      string // HTTP method (e.g. GET, POST)
-	RequestPath        string // URL path component
-	RequestRemoteIP    string // Client IP address
-	RequestHost        string // Host header value
-	RequestScheme      string // URL scheme (http, https)
-	RequestProto       string // HTTP protocol version (e.g. HTTP/1.1, HTTP/2)
-	RequestHeaders     string // Selected request headers
-	RequestBody        string // Request body content, if logged.
-	RequestBytes       string // Size of request body in bytes
-	RequestBytesUnread string // Unread bytes in request body
-	RequestUserAgent   string // User-Agent header value
-	RequestReferer     string // Referer header value
+	// NOTE: RequestQuery is logged only with Options.LogURLParts, as it would likely leak sensitive data.
+	RequestURL               string // Full request URL
+	RequestID                string // Request ID set by chi's middleware.RequestID or X-Request-Id header
+	RequestParentID          string // Request ID of the parent request, see Options.ParentRequestIDHeader
+	RequestMethod            string // HTTP method (e.g. GET, POST)
+	RequestPath              string // URL path component
+	RequestRoute             string // Matched route pattern (e.g. /users/{id}), see Options.RoutePattern
+	RequestRouteMount        string // Mount prefix of the chi sub-router (e.g. /api/v1), see Options.LogRouteMount
+	RequestRouteInner        string // Route pattern within the chi sub-router (e.g. /users/{id}), see Options.LogRouteMount
+	HandlerName              string // Name of the Go handler serving the request, see Named
+	RequestFingerprint       string // Stable fingerprint of the request client, see Options.LogFingerprint
+	SLOViolated              string // Whether the request violated the SLO of the route, see Options.SLOs
+	SLOReason                string // Reason of the SLO violation, i.e. status or latency
+	RequestRemoteIP          string // Client IP address (without the port)
+	RequestRemotePort        string // Client port, if the remote address has one
+	RequestHost              string // Host header value
+	RequestPort              string // Port of the server (from the Host header or the scheme), see Options.LogURLParts
+	RequestQuery             string // Raw query string, see Options.LogURLParts
+	RequestQueryParams       string // Selected query parameters, see Options.LogQueryParams
+	RequestFragment          string // URL fragment, see Options.LogURLParts
+	RequestScheme            string // URL scheme (http, https)
+	RequestProto             string // HTTP protocol version (e.g. HTTP/1.1, HTTP/2)
+	RequestHeaders           string // Selected request headers
+	RequestTrailers          string // Request trailers, see Options.LogRequestTrailers
+	RequestCookies           string // Selected request cookies, see Options.LogCookies
+	RequestBody              string // Request body content, if logged.
+	RequestBodyRef           string // Path of the file with the full request body, see Options.SpillBodyThreshold
+	RequestBodyWindows       string // Byte ranges of the request body, see Options.LogBodyWindows
+	RequestOversized         string // Whether the request Content-Length exceeds Options.OversizedRequestBytes
+	RequestHeaderBytes       string // Estimated size of the request headers on the wire, see Options.LogHeaderSize
+	RequestHeaderCount       string // Number of request header lines, see Options.LogHeaderSize
+	RequestHeadersLarge      string // Whether the request headers exceed Options.LargeHeaderBytes
+	BodyCaptureSkipped       string // Whether the body capture was skipped, see Options.MaxGlobalConcurrentCaptures
+	RequestBytes             string // Size of request body in bytes
+	RequestBytesPerSec       string // Request body throughput in bytes per second, see Options.LogThroughput
+	RequestMessages          string // Number of gRPC messages or WebSocket frames received
+	RequestBytesUnread       string // Unread bytes in request body
+	RequestBodyValid         string // Whether the JSON request body is valid, see Options.ValidateRequestBody
+	RequestBodyError         string // Validation error of the JSON request body
+	RequestUserAgent         string // User-Agent header value
+	RequestUserAgentDetails  string // Parsed User-Agent details, see Options.UserAgentParser
+	ClientIsBot              string // Whether the client is a bot, as flagged by Options.UserAgentParser
+	ClientLocale             string // Most preferred locale of the Accept-Language header
+	RequestCharset           string // Charset parameter of the Content-Type header, see Options.LogRequestEncoding
+	RequestContentLanguage   string // Content-Language header value
+	ClientBrands             string // Browser brands and major versions from Sec-CH-UA, see Options.LogClientHints
+	ClientPlatform           string // Platform (OS) from Sec-CH-UA-Platform
+	ClientMobile             string // Mobile device flag from Sec-CH-UA-Mobile
+	TrafficClass             string // Traffic class (e.g. user, probe, monitor, bot), see Options.TrafficClassFunc
+	RequestReferer           string // Referer header value
+	RequestSequence          string // Per-middleware sequence number of the logged request
+	RepeatCount              string // Number of identical error logs collapsed after the first one, see Options.ErrorDedupWindow
+	ErrorBurst               string // Whether the entry is a summary of an HTTP 5xx burst, see Options.ErrorBurstThreshold
+	ErrorBurstCount          string // Number of HTTP 5xx responses of the route within the burst window
+	ErrorBurstWindow         string // Burst window in milliseconds
+	SamplingRate             string // Sampling rate of the request log, see Options.Sampler
+	SamplingSampled          string // Whether the request log was sampled (failed requests are always logged)
+	CDNRayID                 string // CDN request ID (e.g. CF-Ray), see Options.LogCDNHeaders
+	CDNEdge                  string // CDN edge location or cache node that served the request
+	Labels                   string // Low-cardinality labels of the request, see Options.LogLabels
+	RequestIdempotencyKey    string // Idempotency key of the request, see Options.IdempotencyHeaders
+	RequestPriority          string // Priority class of the request (high, normal or low), see Options.LogPriority
+	RequestReplayRef         string // Reference of the request stored by Options.ReplayStore
+	RequestDuplicate         string // Whether the idempotency key was seen recently, see Options.DuplicateCacheSize
+	RequestConditional       string // Presence of conditional request headers (If-None-Match, If-Modified-Since)
+	RequestRange             string // Range header value of range requests
+	RequestDeadline          string // Timeout of the request context set before RequestLogger (e.g. by middleware.Timeout mounted before it), relative to the start of the request
+	RequestDeadlineRemaining string // Time remaining until the deadline of the request context at completion
+	RequestQueueTime         string // Time the request spent queued before reaching the app, see Options.LogQueueTime
+	RequestContinueSent      string // Whether the interim 100 Continue response was sent, see Options.LogExpectContinue
+	RequestContinueWait      string // Time from the interim 100 Continue response to the first byte of the request body in milliseconds
+	RequestReadDeadline      string // Read deadline set by the handler relative to the request start in milliseconds, see Options.LogResponseController
+	RequestFullDuplex        string // Whether the handler enabled full duplex
+	GraphQLOperationName     string // GraphQL operation name, see Options.GraphQLPaths
+	GraphQLOperationType     string // GraphQL operation type (query, mutation, subscription)
+	GraphQLDocumentHash      string // Truncated SHA-256 hash of the GraphQL query document
+	RPCMethod                string // RPC method name, see Options.InspectRequestBody
+	RPCRequestID             string // RPC request ID, e.g. JSON-RPC id
+	RequestCORSType          string // CORS request class (preflight, actual)
+	RequestOrigin            string // Origin header value of CORS requests
+
+	// User attributes for the authenticated identity of the client.
+	UserID          string // Unique identifier of the user, see Options.IdentityFunc
+	UserName        string // Short name or login of the user
+	UserClaims      string // Selected claims of the JWT bearer token, see Options.LogJWTClaims
+	UserIssuer      string // Issuer of the JWT bearer token ("iss" claim), see Options.LogJWTClaims
+	UserAudience    string // Audience of the JWT bearer token ("aud" claim), see Options.LogJWTClaims
+	ClientScope     string // Scopes granted to the client ("scope" claim), see Options.LogJWTClaims
+	APIKeyHash      string // Salted hash fingerprint of the API key, see Options.LogAPIKeyHeaders
+	TenantID        string // Tenant (organization) the request belongs to, see Options.TenantFunc
+	AuthScheme      string // Authentication scheme (e.g. bearer, basic), see SetAuthResult
+	AuthOutcome     string // Authentication outcome (success, failure, anonymous)
+	AuthReason      string // Reason of the authentication failure
+	RejectReason    string // Reason of the rejection by a throttling or load-shedding middleware, see SetRejected
+	RejectQueueWait string // Time the rejected request waited in the queue in milliseconds
 
 	// Response attributes for the HTTP response.
-	ResponseHeaders  string // Selected response headers
-	ResponseBody     string // Response body content, if logged.
-	ResponseStatus   string // HTTP status code
-	ResponseDuration string // Request processing duration
-	ResponseBytes    string // Size of response body in bytes
+	ResponseHeaders             string // Selected response headers
+	ResponseBody                string // Response body content, if logged.
+	ResponseBodyRef             string // Path of the file with the full response body, see Options.SpillBodyThreshold
+	ResponseBodyWindows         string // Byte ranges of the response body
+	ResponseStatus              string // HTTP status code
+	ResponseStatusClass         string // HTTP status code class, e.g. 2xx
+	ResponseDuration            string // Request processing duration
+	ResponseStalled             string // Time to the first byte of a stalled response in milliseconds, see Options.StallThreshold
+	Timings                     string // Named durations recorded by Mark and Span
+	MiddlewareTimings           string // Durations recorded by MiddlewareTiming, and the remaining time as handler
+	Counters                    string // Named counters aggregated by Count and Add
+	Experiments                 string // Experiment and feature flag variants set by SetExperiment
+	ResponseBytes               string // Size of response body in bytes
+	ResponseHeadContentLength   string // Content-Length of HEAD responses, as of the GET response
+	ResponseBytesPerSec         string // Response body throughput in bytes per second, see Options.LogThroughput
+	Partial                     string // Intermediate log entry of a long-lived request, see EmitPartial
+	ContextKVsTruncated         string // Whether key-value pairs set by SetKVs were dropped, see Options.MaxContextKVs
+	ResponseMessages            string // Number of gRPC messages or WebSocket frames sent
+	WebSocketCloseCode          string // Status code of the WebSocket close frame, or 1006 if the connection was closed without one
+	WebSocketCloseReason        string // Reason of the WebSocket close frame
+	WebSocketCloseInitiator     string // Side sending the first WebSocket close frame (client or server)
+	ResponseHeaderBytes         string // Estimated size of the response status line and headers, see Options.LogWireBytes
+	ResponseWireBytes           string // Estimated total size of the response on the wire (headers and body)
+	ResponseWriteDeadline       string // Write deadline set by the handler relative to the request start in milliseconds
+	ResponseWriterCapabilities  string // Flush, Hijack and Push support of the response writer
+	ResponseFilename            string // File name of file downloads (Content-Disposition: attachment)
+	ResponseAllowedMethods      string // Methods allowed by the Allow header of HTTP 405 responses
+	ResponseErrorMessage        string // Error message extracted from JSON error responses, see Options.ErrorMessageFields
+	ResponseContentTypeMismatch string // Detected Content-Type of the response body, if it doesn't match the declared one
+	ResponseCompressionRatio    string // Ratio of uncompressed to compressed response body size
+	CacheStatus                 string // Normalized cache status (e.g. hit, miss, stale, bypass)
+	ResponseNotModified         string // Whether a conditional request was answered with HTTP 304
+	ResponseETag                string // ETag header value of HTTP 304 responses
+	ResponseContentRange        string // Content-Range header value of range responses
+	ResponseRangeSatisfiable    string // Whether the requested range was satisfiable (not HTTP 416)
+	ResponseRedirectLocation    string // Location header value of HTTP 3xx responses
+	ResponseRateLimit           string // Rate limiting response headers of HTTP 429 responses
+	SecurityMissingHeaders      string // Security response headers missing in the response, see Options.AuditSecurityHeaders
+
+	// Upstream attributes for requests proxied by a reverse proxy, see InstrumentProxy.
+	UpstreamAddress    string // Address of the upstream target
+	UpstreamStatus     string // HTTP status code of the upstream response
+	UpstreamDuration   string // Duration of the last upstream round trip
+	UpstreamRetries    string // Number of retried upstream round trips
+	UpstreamHeaderDiff string // Names of the request headers added, removed and modified before proxying
+
+	// Extra maps names of custom fields to their field names in the schema, so that
+	// hooks (e.g. Options.LogExtraAttrs) can stay consistent with the chosen naming
+	// convention and group delimiter, e.g. s.Field("order_id"). See ExtendSchema.
+	Extra map[string]string
 
 	// GroupDelimiter is an optional delimiter for nested objects in some formats.
 	// For example, GCP uses nested JSON objects like "httpRequest": {}.
 	GroupDelimiter string
+
+	// RootFields are the field names pinned to the root of the log entry, which
+	// are logged as is even if they contain the GroupDelimiter, e.g. special
+	// fields like "logging.googleapis.com/trace" in a schema grouped by ".".
+	RootFields []string
+
+	// FieldDelimiters overrides the GroupDelimiter of individual fields by field
+	// name, e.g. to group the ECS fields by "." in a schema otherwise grouped by
+	// ":", or to group some fields of an ungrouped schema. An empty delimiter
+	// keeps the field ungrouped, like RootFields, which take precedence.
+	FieldDelimiters map[string]string
 }
 
 var (
@@ -59,33 +209,162 @@ var (
 	//
 	// Reference: https://www.elastic.co/guide/en/ecs/current/ecs-http.html
 	SchemaECS = &Schema{
-		Timestamp:          "@timestamp",
-		Level:              "log.level",
-		Message:            "message",
-		ErrorMessage:       "error.message",
-		ErrorType:          "error.type",
-		ErrorStackTrace:    "error.stack_trace",
-		SourceFile:         "log.origin.file.name",
-		SourceLine:         "log.origin.file.line",
-		SourceFunction:     "log.origin.function",
-		RequestURL:         "url.full",
-		RequestMethod:      "http.request.method",
-		RequestPath:        "url.path",
-		RequestRemoteIP:    "client.ip",
-		RequestHost:        "url.domain",
-		RequestScheme:      "url.scheme",
-		RequestProto:       "http.version",
-		RequestHeaders:     "http.request.headers",
-		RequestBody:        "http.request.body.content",
-		RequestBytes:       "http.request.body.bytes",
-		RequestBytesUnread: "http.request.body.unread.bytes",
-		RequestUserAgent:   "user_agent.original",
-		RequestReferer:     "http.request.referrer",
-		ResponseHeaders:    "http.response.headers",
-		ResponseBody:       "http.response.body.content",
-		ResponseStatus:     "http.response.status_code",
-		ResponseDuration:   "event.duration",
-		ResponseBytes:      "http.response.body.bytes",
+		Timestamp:                   "@timestamp",
+		Level:                       "log.level",
+		Message:                     "message",
+		ErrorMessage:                "error.message",
+		ErrorType:                   "error.type",
+		EventOutcome:                "event.outcome",
+		AbortHeadersSent:            "http.response.abort.headers_sent",
+		AbortElapsed:                "http.response.abort.elapsed_ms",
+		AbortNoticed:                "http.response.abort.noticed_ms",
+		AbortProgress:               "http.response.abort.progress_pct",
+		HookErrors:                  "httplog.hook_errors",
+		ErrorTitle:                  "error.title",
+		ErrorDetail:                 "error.detail",
+		ErrorStackTrace:             "error.stack_trace",
+		TraceID:                     "trace.id",
+		TransactionID:               "transaction.id",
+		SpanID:                      "span.id",
+		Baggage:                     "baggage",
+		SourceFile:                  "log.origin.file.name",
+		SourceLine:                  "log.origin.file.line",
+		SourceFunction:              "log.origin.function",
+		RequestURL:                  "url.full",
+		RequestID:                   "http.request.id",
+		RequestParentID:             "http.request.parent_id",
+		RequestMethod:               "http.request.method",
+		RequestPath:                 "url.path",
+		RequestRoute:                "http.route",
+		RequestRouteMount:           "http.route_mount",
+		RequestRouteInner:           "http.route_inner",
+		HandlerName:                 "code.function",
+		RequestFingerprint:          "http.request.fingerprint",
+		SLOViolated:                 "slo.violated",
+		SLOReason:                   "slo.reason",
+		RequestRemoteIP:             "client.ip",
+		RequestRemotePort:           "client.port",
+		RequestHost:                 "url.domain",
+		RequestPort:                 "url.port",
+		RequestQuery:                "url.query",
+		RequestQueryParams:          "url.query_params",
+		RequestFragment:             "url.fragment",
+		RequestScheme:               "url.scheme",
+		RequestProto:                "http.version",
+		RequestHeaders:              "http.request.headers",
+		RequestTrailers:             "http.request.trailers",
+		RequestCookies:              "http.request.cookies",
+		RequestBody:                 "http.request.body.content",
+		RequestBodyRef:              "http.request.body.ref",
+		RequestBodyWindows:          "http.request.body.windows",
+		RequestOversized:            "http.request.oversized",
+		RequestHeaderBytes:          "http.request.header_bytes",
+		RequestHeaderCount:          "http.request.header_count",
+		RequestHeadersLarge:         "http.request.headers_large",
+		BodyCaptureSkipped:          "http.request.body.capture_skipped",
+		RequestBytes:                "http.request.body.bytes",
+		RequestBytesPerSec:          "http.request.bytes_per_sec",
+		RequestMessages:             "http.request.messages",
+		RequestBytesUnread:          "http.request.body.unread.bytes",
+		RequestBodyValid:            "http.request.body.valid",
+		RequestBodyError:            "http.request.body.error",
+		RequestUserAgent:            "user_agent.original",
+		RequestUserAgentDetails:     "user_agent.details",
+		ClientIsBot:                 "client.is_bot",
+		ClientLocale:                "client.locale",
+		RequestCharset:              "http.request.charset",
+		RequestContentLanguage:      "http.request.content_language",
+		ClientBrands:                "client.brands",
+		ClientPlatform:              "client.platform",
+		ClientMobile:                "client.mobile",
+		TrafficClass:                "traffic.class",
+		RequestReferer:              "http.request.referrer",
+		RequestSequence:             "event.sequence",
+		RepeatCount:                 "event.repeat_count",
+		ErrorBurst:                  "error.burst",
+		ErrorBurstCount:             "error.burst_count",
+		ErrorBurstWindow:            "error.burst_window_ms",
+		SamplingRate:                "sampling.rate",
+		SamplingSampled:             "sampling.sampled",
+		CDNRayID:                    "cdn.ray_id",
+		CDNEdge:                     "cdn.edge",
+		Labels:                      "labels",
+		RequestIdempotencyKey:       "http.request.idempotency_key",
+		RequestPriority:             "http.request.priority",
+		RequestReplayRef:            "http.request.replay_ref",
+		RequestDuplicate:            "http.request.duplicate",
+		RequestConditional:          "http.request.conditional",
+		RequestRange:                "http.request.range",
+		RequestDeadline:             "http.request.deadline_ms",
+		RequestDeadlineRemaining:    "http.request.deadline_remaining_ms",
+		RequestQueueTime:            "http.request.queue_time_ms",
+		RequestContinueSent:         "http.request.continue.sent",
+		RequestContinueWait:         "http.request.continue.wait_ms",
+		RequestReadDeadline:         "http.request.read_deadline_ms",
+		RequestFullDuplex:           "http.request.full_duplex",
+		GraphQLOperationName:        "graphql.operation.name",
+		GraphQLOperationType:        "graphql.operation.type",
+		GraphQLDocumentHash:         "graphql.document.hash",
+		RPCMethod:                   "rpc.method",
+		RPCRequestID:                "rpc.jsonrpc.request_id",
+		RequestCORSType:             "cors.type",
+		RequestOrigin:               "http.request.origin",
+		UserID:                      "user.id",
+		UserName:                    "user.name",
+		UserClaims:                  "user.claims",
+		UserIssuer:                  "user.issuer",
+		UserAudience:                "user.audience",
+		ClientScope:                 "client.scope",
+		APIKeyHash:                  "client.api_key_hash",
+		TenantID:                    "organization.id",
+		AuthScheme:                  "auth.scheme",
+		AuthOutcome:                 "auth.outcome",
+		AuthReason:                  "auth.reason",
+		RejectReason:                "reject.reason",
+		RejectQueueWait:             "reject.queue_wait_ms",
+		ResponseHeaders:             "http.response.headers",
+		ResponseBody:                "http.response.body.content",
+		ResponseBodyRef:             "http.response.body.ref",
+		ResponseBodyWindows:         "http.response.body.windows",
+		ResponseStatus:              "http.response.status_code",
+		ResponseStatusClass:         "http.response.status_class",
+		ResponseDuration:            "event.duration",
+		ResponseStalled:             "http.response.stalled_ms",
+		Timings:                     "timings",
+		MiddlewareTimings:           "middleware_timings",
+		Counters:                    "counters",
+		Experiments:                 "experiments",
+		ResponseBytes:               "http.response.body.bytes",
+		ResponseHeadContentLength:   "http.response.head_content_length",
+		ResponseBytesPerSec:         "http.response.bytes_per_sec",
+		Partial:                     "http.response.partial",
+		ContextKVsTruncated:         "context_kvs_truncated",
+		ResponseMessages:            "http.response.messages",
+		WebSocketCloseCode:          "http.websocket.close_code",
+		WebSocketCloseReason:        "http.websocket.close_reason",
+		WebSocketCloseInitiator:     "http.websocket.close_initiator",
+		ResponseHeaderBytes:         "http.response.header_bytes",
+		ResponseWireBytes:           "http.response.bytes",
+		ResponseWriteDeadline:       "http.response.write_deadline_ms",
+		ResponseWriterCapabilities:  "http.response.writer_capabilities",
+		ResponseFilename:            "file.name",
+		ResponseAllowedMethods:      "http.response.allowed_methods",
+		ResponseErrorMessage:        "http.response.error_message",
+		ResponseContentTypeMismatch: "http.response.content_type_mismatch",
+		ResponseCompressionRatio:    "http.response.compression_ratio",
+		CacheStatus:                 "cache.status",
+		ResponseNotModified:         "http.response.not_modified",
+		ResponseETag:                "http.response.etag",
+		ResponseContentRange:        "http.response.content_range",
+		ResponseRangeSatisfiable:    "http.response.range_satisfiable",
+		ResponseRedirectLocation:    "http.response.redirect_location",
+		ResponseRateLimit:           "http.response.rate_limit",
+		SecurityMissingHeaders:      "security.missing_headers",
+		UpstreamAddress:             "upstream.address",
+		UpstreamStatus:              "upstream.status_code",
+		UpstreamDuration:            "upstream.duration",
+		UpstreamRetries:             "upstream.retries",
+		UpstreamHeaderDiff:          "upstream.header_diff",
 	}
 
 	// SchemaOTEL represents OpenTelemetry (OTEL) semantic conventions version 1.34.0.
@@ -93,70 +372,381 @@ var (
 	//
 	// Reference: https://opentelemetry.io/docs/specs/semconv/http/http-metrics
 	SchemaOTEL = &Schema{
-		Timestamp:          "timestamp",
-		Level:              "severity_text",
-		Message:            "body",
-		ErrorMessage:       "error.message",
-		ErrorType:          "error.type",
-		ErrorStackTrace:    "exception.stacktrace",
-		SourceFile:         "code.filepath",
-		SourceLine:         "code.lineno",
-		SourceFunction:     "code.function",
-		RequestURL:         "url.full",
-		RequestMethod:      "http.request.method",
-		RequestPath:        "url.path",
-		RequestRemoteIP:    "client.address",
-		RequestHost:        "server.address",
-		RequestScheme:      "url.scheme",
-		RequestProto:       "network.protocol.version",
-		RequestHeaders:     "http.request.header",
-		RequestBody:        "http.request.body.content",
-		RequestBytes:       "http.request.body.size",
-		RequestBytesUnread: "http.request.body.unread.size",
-		RequestUserAgent:   "user_agent.original",
-		RequestReferer:     "http.request.header.referer",
-		ResponseHeaders:    "http.response.header",
-		ResponseBody:       "http.response.body.content",
-		ResponseStatus:     "http.response.status_code",
-		ResponseDuration:   "http.server.request.duration",
-		ResponseBytes:      "http.response.body.size",
+		Timestamp:                   "timestamp",
+		Level:                       "severity_text",
+		Message:                     "body",
+		ErrorMessage:                "error.message",
+		ErrorType:                   "error.type",
+		EventOutcome:                "event.outcome",
+		AbortHeadersSent:            "http.response.abort.headers_sent",
+		AbortElapsed:                "http.response.abort.elapsed_ms",
+		AbortNoticed:                "http.response.abort.noticed_ms",
+		AbortProgress:               "http.response.abort.progress_pct",
+		HookErrors:                  "httplog.hook_errors",
+		ErrorTitle:                  "error.title",
+		ErrorDetail:                 "error.detail",
+		ErrorStackTrace:             "exception.stacktrace",
+		TraceID:                     "trace_id",
+		SpanID:                      "span_id",
+		Baggage:                     "baggage",
+		SourceFile:                  "code.filepath",
+		SourceLine:                  "code.lineno",
+		SourceFunction:              "code.function",
+		RequestURL:                  "url.full",
+		RequestID:                   "http.request.id",
+		RequestParentID:             "http.request.parent_id",
+		RequestMethod:               "http.request.method",
+		RequestPath:                 "url.path",
+		RequestRoute:                "http.route",
+		RequestRouteMount:           "http.route_mount",
+		RequestRouteInner:           "http.route_inner",
+		HandlerName:                 "code.function.name",
+		RequestFingerprint:          "http.request.fingerprint",
+		SLOViolated:                 "slo.violated",
+		SLOReason:                   "slo.reason",
+		RequestRemoteIP:             "client.address",
+		RequestRemotePort:           "client.port",
+		RequestHost:                 "server.address",
+		RequestPort:                 "server.port",
+		RequestQuery:                "url.query",
+		RequestQueryParams:          "url.query_params",
+		RequestFragment:             "url.fragment",
+		RequestScheme:               "url.scheme",
+		RequestProto:                "network.protocol.version",
+		RequestHeaders:              "http.request.header",
+		RequestTrailers:             "http.request.trailers",
+		RequestCookies:              "http.request.cookies",
+		RequestBody:                 "http.request.body.content",
+		RequestBodyRef:              "http.request.body.ref",
+		RequestBodyWindows:          "http.request.body.windows",
+		RequestOversized:            "http.request.oversized",
+		RequestHeaderBytes:          "http.request.header_bytes",
+		RequestHeaderCount:          "http.request.header_count",
+		RequestHeadersLarge:         "http.request.headers_large",
+		BodyCaptureSkipped:          "http.request.body.capture_skipped",
+		RequestBytes:                "http.request.body.size",
+		RequestBytesPerSec:          "http.request.body.bytes_per_sec",
+		RequestMessages:             "http.request.messages",
+		RequestBytesUnread:          "http.request.body.unread.size",
+		RequestBodyValid:            "http.request.body.valid",
+		RequestBodyError:            "http.request.body.error",
+		RequestUserAgent:            "user_agent.original",
+		RequestUserAgentDetails:     "user_agent.details",
+		ClientIsBot:                 "client.is_bot",
+		ClientLocale:                "client.locale",
+		RequestCharset:              "http.request.charset",
+		RequestContentLanguage:      "http.request.content_language",
+		ClientBrands:                "client.brands",
+		ClientPlatform:              "client.platform",
+		ClientMobile:                "client.mobile",
+		TrafficClass:                "traffic.class",
+		RequestReferer:              "http.request.header.referer",
+		RequestSequence:             "http.request.sequence",
+		RepeatCount:                 "log.repeat_count",
+		ErrorBurst:                  "error.burst",
+		ErrorBurstCount:             "error.burst_count",
+		ErrorBurstWindow:            "error.burst_window_ms",
+		SamplingRate:                "sampling.rate",
+		SamplingSampled:             "sampling.sampled",
+		CDNRayID:                    "cdn.ray_id",
+		CDNEdge:                     "cdn.edge",
+		Labels:                      "labels",
+		RequestIdempotencyKey:       "http.request.idempotency_key",
+		RequestPriority:             "http.request.priority",
+		RequestReplayRef:            "http.request.replay_ref",
+		RequestDuplicate:            "http.request.duplicate",
+		RequestConditional:          "http.request.conditional",
+		RequestRange:                "http.request.header.range",
+		RequestDeadline:             "http.request.deadline_ms",
+		RequestDeadlineRemaining:    "http.request.deadline_remaining_ms",
+		RequestQueueTime:            "http.request.queue_time_ms",
+		RequestContinueSent:         "http.request.continue.sent",
+		RequestContinueWait:         "http.request.continue.wait_ms",
+		RequestReadDeadline:         "http.request.read_deadline_ms",
+		RequestFullDuplex:           "http.request.full_duplex",
+		GraphQLOperationName:        "graphql.operation.name",
+		GraphQLOperationType:        "graphql.operation.type",
+		GraphQLDocumentHash:         "graphql.document.hash",
+		RPCMethod:                   "rpc.method",
+		RPCRequestID:                "rpc.jsonrpc.request_id",
+		RequestCORSType:             "cors.type",
+		RequestOrigin:               "http.request.header.origin",
+		UserID:                      "user.id",
+		UserName:                    "user.name",
+		UserClaims:                  "user.claims",
+		UserIssuer:                  "user.issuer",
+		UserAudience:                "user.audience",
+		ClientScope:                 "client.scope",
+		APIKeyHash:                  "client.api_key_hash",
+		TenantID:                    "tenant.id",
+		AuthScheme:                  "auth.scheme",
+		AuthOutcome:                 "auth.outcome",
+		AuthReason:                  "auth.reason",
+		RejectReason:                "reject.reason",
+		RejectQueueWait:             "reject.queue_wait_ms",
+		ResponseHeaders:             "http.response.header",
+		ResponseBody:                "http.response.body.content",
+		ResponseBodyRef:             "http.response.body.ref",
+		ResponseBodyWindows:         "http.response.body.windows",
+		ResponseStatus:              "http.response.status_code",
+		ResponseStatusClass:         "http.response.status_class",
+		ResponseDuration:            "http.server.request.duration",
+		ResponseStalled:             "http.response.stalled_ms",
+		Timings:                     "timings",
+		MiddlewareTimings:           "middleware_timings",
+		Counters:                    "counters",
+		Experiments:                 "experiments",
+		ResponseBytes:               "http.response.body.size",
+		ResponseHeadContentLength:   "http.response.head_content_length",
+		ResponseBytesPerSec:         "http.response.body.bytes_per_sec",
+		Partial:                     "http.response.partial",
+		ContextKVsTruncated:         "context_kvs_truncated",
+		ResponseMessages:            "http.response.messages",
+		WebSocketCloseCode:          "http.websocket.close_code",
+		WebSocketCloseReason:        "http.websocket.close_reason",
+		WebSocketCloseInitiator:     "http.websocket.close_initiator",
+		ResponseHeaderBytes:         "http.response.header_bytes",
+		ResponseWireBytes:           "http.response.size",
+		ResponseWriteDeadline:       "http.response.write_deadline_ms",
+		ResponseWriterCapabilities:  "http.response.writer_capabilities",
+		ResponseFilename:            "file.name",
+		ResponseAllowedMethods:      "http.response.allowed_methods",
+		ResponseErrorMessage:        "http.response.error_message",
+		ResponseContentTypeMismatch: "http.response.content_type_mismatch",
+		ResponseCompressionRatio:    "http.response.compression_ratio",
+		CacheStatus:                 "cache.status",
+		ResponseNotModified:         "http.response.not_modified",
+		ResponseETag:                "http.response.header.etag",
+		ResponseContentRange:        "http.response.header.content-range",
+		ResponseRangeSatisfiable:    "http.response.range_satisfiable",
+		ResponseRedirectLocation:    "http.response.header.location",
+		ResponseRateLimit:           "http.response.rate_limit",
+		SecurityMissingHeaders:      "security.missing_headers",
+		UpstreamAddress:             "upstream.address",
+		UpstreamStatus:              "upstream.status_code",
+		UpstreamDuration:            "upstream.duration",
+		UpstreamRetries:             "upstream.retry_count",
+		UpstreamHeaderDiff:          "upstream.header_diff",
 	}
 
 	// SchemaGCP represents Google Cloud Platform's structured logging format.
-	// This schema is optimized for Google Cloud Logging service.
+	// This schema is optimized for Google Cloud Logging service. Set
+	// Options.GCPProjectID to correlate the logs with Cloud Trace.
+	//
+	// The URL parts and the remote port aren't fields of the LogEntry
+	// HttpRequest type, so they're logged under "request" rather than
+	// "httpRequest".
 	//
 	// References:
 	//   - https://cloud.google.com/logging/docs/structured-logging
 	//   - https://cloud.google.com/logging/docs/reference/v2/rest/v2/LogEntry#HttpRequest
 	SchemaGCP = &Schema{
-		Timestamp:          "timestamp",
-		Level:              "severity",
-		Message:            "message",
-		ErrorMessage:       "error:message",
-		ErrorType:          "error:type",
-		ErrorStackTrace:    "error:stack_trace",
-		SourceFile:         "logging.googleapis.com/sourceLocation:file",
-		SourceLine:         "logging.googleapis.com/sourceLocation:line",
-		SourceFunction:     "logging.googleapis.com/sourceLocation:function",
-		RequestURL:         "httpRequest:requestUrl",
-		RequestMethod:      "httpRequest:requestMethod",
-		RequestPath:        "httpRequest:requestPath",
-		RequestRemoteIP:    "httpRequest:remoteIp",
-		RequestHost:        "httpRequest:host",
-		RequestScheme:      "httpRequest:scheme",
-		RequestProto:       "httpRequest:protocol",
-		RequestHeaders:     "httpRequest:requestHeaders",
-		RequestBody:        "httpRequest:requestBody",
-		RequestBytes:       "httpRequest:requestSize",
-		RequestBytesUnread: "httpRequest:requestUnreadSize",
-		RequestUserAgent:   "httpRequest:userAgent",
-		RequestReferer:     "httpRequest:referer",
-		ResponseHeaders:    "httpRequest:responseHeaders",
-		ResponseBody:       "httpRequest:responseBody",
-		ResponseStatus:     "httpRequest:status",
-		ResponseDuration:   "httpRequest:latency",
-		ResponseBytes:      "httpRequest:responseSize",
-		GroupDelimiter:     ":",
+		Timestamp:                   "timestamp",
+		Level:                       "severity",
+		Message:                     "message",
+		ErrorMessage:                "error:message",
+		ErrorType:                   "error:type",
+		EventOutcome:                "event:outcome",
+		AbortHeadersSent:            "error:abortHeadersSent",
+		AbortElapsed:                "error:abortElapsedMs",
+		AbortNoticed:                "error:abortNoticedMs",
+		AbortProgress:               "error:abortProgressPct",
+		HookErrors:                  "httplog:hookErrors",
+		ErrorTitle:                  "error:title",
+		ErrorDetail:                 "error:detail",
+		ErrorStackTrace:             "error:stack_trace",
+		TraceID:                     "logging.googleapis.com/trace",
+		SpanID:                      "logging.googleapis.com/spanId",
+		Baggage:                     "baggage",
+		SourceFile:                  "logging.googleapis.com/sourceLocation:file",
+		SourceLine:                  "logging.googleapis.com/sourceLocation:line",
+		SourceFunction:              "logging.googleapis.com/sourceLocation:function",
+		RequestURL:                  "httpRequest:requestUrl",
+		RequestID:                   "httpRequest:requestId",
+		RequestParentID:             "httpRequest:parentRequestId",
+		RequestMethod:               "httpRequest:requestMethod",
+		RequestPath:                 "httpRequest:requestPath",
+		RequestRoute:                "httpRequest:route",
+		RequestRouteMount:           "httpRequest:routeMount",
+		RequestRouteInner:           "httpRequest:routeInner",
+		HandlerName:                 "logging.googleapis.com/sourceLocation:function",
+		RequestFingerprint:          "httpRequest:fingerprint",
+		SLOViolated:                 "slo:violated",
+		SLOReason:                   "slo:reason",
+		RequestRemoteIP:             "httpRequest:remoteIp",
+		RequestRemotePort:           "request:remotePort",
+		RequestHost:                 "httpRequest:host",
+		RequestPort:                 "request:port",
+		RequestQuery:                "request:query",
+		RequestQueryParams:          "httpRequest:queryParams",
+		RequestFragment:             "request:fragment",
+		RequestScheme:               "httpRequest:scheme",
+		RequestProto:                "httpRequest:protocol",
+		RequestHeaders:              "httpRequest:requestHeaders",
+		RequestTrailers:             "httpRequest:requestTrailers",
+		RequestCookies:              "httpRequest:cookies",
+		RequestBody:                 "httpRequest:requestBody",
+		RequestBodyRef:              "httpRequest:requestBodyRef",
+		RequestBodyWindows:          "httpRequest:requestBodyWindows",
+		RequestOversized:            "httpRequest:requestOversized",
+		RequestHeaderBytes:          "httpRequest:requestHeaderSize",
+		RequestHeaderCount:          "httpRequest:requestHeaderCount",
+		RequestHeadersLarge:         "httpRequest:requestHeadersLarge",
+		BodyCaptureSkipped:          "httpRequest:bodyCaptureSkipped",
+		RequestBytes:                "httpRequest:requestSize",
+		RequestBytesPerSec:          "httpRequest:requestBytesPerSec",
+		RequestMessages:             "httpRequest:requestMessages",
+		RequestBytesUnread:          "httpRequest:requestUnreadSize",
+		RequestBodyValid:            "httpRequest:requestBodyValid",
+		RequestBodyError:            "httpRequest:requestBodyError",
+		RequestUserAgent:            "httpRequest:userAgent",
+		RequestUserAgentDetails:     "httpRequest:userAgentDetails",
+		ClientIsBot:                 "client:isBot",
+		ClientLocale:                "client:locale",
+		RequestCharset:              "httpRequest:charset",
+		RequestContentLanguage:      "httpRequest:contentLanguage",
+		ClientBrands:                "client:brands",
+		ClientPlatform:              "client:platform",
+		ClientMobile:                "client:mobile",
+		TrafficClass:                "traffic:class",
+		RequestReferer:              "httpRequest:referer",
+		RequestSequence:             "httpRequest:sequence",
+		RepeatCount:                 "repeatCount",
+		ErrorBurst:                  "error:burst",
+		ErrorBurstCount:             "error:burstCount",
+		ErrorBurstWindow:            "error:burstWindowMs",
+		SamplingRate:                "sampling:rate",
+		SamplingSampled:             "sampling:sampled",
+		CDNRayID:                    "httpRequest:cdnRayId",
+		CDNEdge:                     "httpRequest:cdnEdge",
+		Labels:                      "logging.googleapis.com/labels",
+		RequestIdempotencyKey:       "httpRequest:idempotencyKey",
+		RequestPriority:             "httpRequest:priority",
+		RequestReplayRef:            "httpRequest:replayRef",
+		RequestDuplicate:            "httpRequest:duplicate",
+		RequestConditional:          "httpRequest:conditional",
+		RequestRange:                "httpRequest:range",
+		RequestDeadline:             "request:deadlineMs",
+		RequestDeadlineRemaining:    "request:deadlineRemainingMs",
+		RequestQueueTime:            "request:queueTimeMs",
+		RequestContinueSent:         "httpRequest:continueSent",
+		RequestContinueWait:         "httpRequest:continueWaitMs",
+		RequestReadDeadline:         "httpRequest:readDeadlineMs",
+		RequestFullDuplex:           "httpRequest:fullDuplex",
+		GraphQLOperationName:        "graphql:operationName",
+		GraphQLOperationType:        "graphql:operationType",
+		GraphQLDocumentHash:         "graphql:documentHash",
+		RPCMethod:                   "rpc:method",
+		RPCRequestID:                "rpc:requestId",
+		RequestCORSType:             "httpRequest:corsType",
+		RequestOrigin:               "httpRequest:origin",
+		UserID:                      "user:id",
+		UserName:                    "user:name",
+		UserClaims:                  "user:claims",
+		UserIssuer:                  "user:issuer",
+		UserAudience:                "user:audience",
+		ClientScope:                 "client:scope",
+		APIKeyHash:                  "client:api_key_hash",
+		TenantID:                    "tenant:id",
+		AuthScheme:                  "auth:scheme",
+		AuthOutcome:                 "auth:outcome",
+		AuthReason:                  "auth:reason",
+		RejectReason:                "httpRequest:rejectReason",
+		RejectQueueWait:             "httpRequest:rejectQueueWaitMs",
+		ResponseHeaders:             "httpRequest:responseHeaders",
+		ResponseBody:                "httpRequest:responseBody",
+		ResponseBodyRef:             "httpRequest:responseBodyRef",
+		ResponseBodyWindows:         "httpRequest:responseBodyWindows",
+		ResponseStatus:              "httpRequest:status",
+		ResponseStatusClass:         "httpRequest:statusClass",
+		ResponseDuration:            "httpRequest:latency",
+		ResponseStalled:             "httpRequest:stalledMs",
+		Timings:                     "timings",
+		MiddlewareTimings:           "middlewareTimings",
+		Counters:                    "counters",
+		Experiments:                 "experiments",
+		ResponseBytes:               "httpRequest:responseSize",
+		ResponseHeadContentLength:   "httpRequest:headContentLength",
+		ResponseBytesPerSec:         "httpRequest:responseBytesPerSec",
+		Partial:                     "httpRequest:partial",
+		ContextKVsTruncated:         "contextKvsTruncated",
+		ResponseMessages:            "httpRequest:responseMessages",
+		WebSocketCloseCode:          "httpRequest:websocketCloseCode",
+		WebSocketCloseReason:        "httpRequest:websocketCloseReason",
+		WebSocketCloseInitiator:     "httpRequest:websocketCloseInitiator",
+		ResponseHeaderBytes:         "httpRequest:responseHeaderSize",
+		ResponseWireBytes:           "httpRequest:responseWireSize",
+		ResponseWriteDeadline:       "httpRequest:writeDeadlineMs",
+		ResponseWriterCapabilities:  "httpRequest:writerCapabilities",
+		ResponseFilename:            "file:name",
+		ResponseAllowedMethods:      "httpRequest:allowedMethods",
+		ResponseErrorMessage:        "response:errorMessage",
+		ResponseContentTypeMismatch: "response:contentTypeMismatch",
+		ResponseCompressionRatio:    "httpRequest:compressionRatio",
+		CacheStatus:                 "httpRequest:cacheStatus",
+		ResponseNotModified:         "httpRequest:notModified",
+		ResponseETag:                "httpRequest:etag",
+		ResponseContentRange:        "httpRequest:contentRange",
+		ResponseRangeSatisfiable:    "httpRequest:rangeSatisfiable",
+		ResponseRedirectLocation:    "httpRequest:redirectLocation",
+		ResponseRateLimit:           "httpRequest:rateLimit",
+		SecurityMissingHeaders:      "security:missingHeaders",
+		UpstreamAddress:             "upstream:address",
+		UpstreamStatus:              "upstream:statusCode",
+		UpstreamDuration:            "upstream:latency",
+		UpstreamRetries:             "upstream:retryCount",
+		UpstreamHeaderDiff:          "upstream:headerDiff",
+		GroupDelimiter:              ":",
+	}
+
+	// SchemaCEF represents the ArcSight Common Event Format (CEF) extension keys,
+	// which are also largely compatible with QRadar LEEF. It's intended for security
+	// teams ingesting web access logs into SIEMs. Fields without a CEF equivalent
+	// are omitted; the CEF header must be rendered by the log handler.
+	//
+	// Reference: https://www.microfocus.com/documentation/arcsight/arcsight-smartconnectors/pdfdoc/common-event-format-v25/common-event-format-v25.pdf
+	SchemaCEF = &Schema{
+		Timestamp:         "rt",
+		Level:             "severity",
+		Message:           "msg",
+		ErrorMessage:      "reason",
+		ErrorType:         "cat",
+		RequestID:         "externalId",
+		RequestURL:        "request",
+		RequestMethod:     "requestMethod",
+		RequestRemoteIP:   "src",
+		RequestRemotePort: "spt",
+		RequestHost:       "dhost",
+		RequestScheme:     "app",
+		RequestBytes:      "in",
+		RequestUserAgent:  "requestClientApplication",
+		RequestReferer:    "requestContext",
+		UserID:            "suid",
+		UserName:          "suser",
+		ResponseStatus:    "outcome",
+		ResponseBytes:     "out",
+	}
+
+	// SchemaSplunkCIM represents the Splunk Common Information Model (CIM) Web data
+	// model fields, for SOC dashboards and searches built on CIM. Fields without
+	// a CIM equivalent are omitted.
+	//
+	// Reference: https://docs.splunk.com/Documentation/CIM/latest/User/Web
+	SchemaSplunkCIM = &Schema{
+		ErrorMessage:     "error",
+		RequestID:        "request_id",
+		RequestURL:       "url",
+		RequestMethod:    "http_method",
+		RequestPath:      "uri_path",
+		RequestRemoteIP:  "src",
+		RequestHost:      "dest",
+		RequestBytes:     "bytes_in",
+		RequestUserAgent: "http_user_agent",
+		RequestReferer:   "http_referrer",
+		UserName:         "user",
+		ResponseStatus:   "status",
+		ResponseDuration: "duration",
+		ResponseBytes:    "bytes_out",
 	}
 )
 
@@ -212,6 +802,58 @@ func (s *Schema) ReplaceAttr(groups []string, a slog.Attr) slog.Attr {
 	return a
 }
 
+// ExtendSchema returns a copy of the base schema with the given custom fields
+// added to Schema.Extra, overriding the base schema's custom fields, e.g.:
+//
+//	schema := httplog.ExtendSchema(httplog.SchemaECS, map[string]string{
+//		"order_id": "order.id",
+//	})
+func ExtendSchema(base *Schema, extra map[string]string) *Schema {
+	s := *base
+	s.Extra = make(map[string]string, len(base.Extra)+len(extra))
+	for name, field := range base.Extra {
+		s.Extra[name] = field
+	}
+	for name, field := range extra {
+		s.Extra[name] = field
+	}
+	return &s
+}
+
+// FieldDelimiter returns the delimiter grouping the field name into nested
+// objects, i.e. the one of Schema.FieldDelimiters or the GroupDelimiter, or an
+// empty string if the field isn't grouped, e.g. one of Schema.RootFields.
+func (s *Schema) FieldDelimiter(name string) string {
+	if slices.Contains(s.RootFields, name) {
+		return ""
+	}
+	if delimiter, ok := s.FieldDelimiters[name]; ok {
+		return delimiter
+	}
+	return s.GroupDelimiter
+}
+
+// grouped reports whether any field of the schema is grouped into nested
+// objects, see Schema.GroupDelimiter and Schema.FieldDelimiters.
+func (s *Schema) grouped() bool {
+	if s.GroupDelimiter != "" {
+		return true
+	}
+	for _, delimiter := range s.FieldDelimiters {
+		if delimiter != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// Field returns the field name of the custom field in the schema, see Schema.Extra.
+// It returns an empty string, which omits the field from the logs, if the custom
+// field isn't defined.
+func (s *Schema) Field(name string) string {
+	return s.Extra[name]
+}
+
 // Concise returns a simplified schema with essential fields only.
 // If concise is true, it reduces log verbosity.
 //
@@ -222,13 +864,46 @@ func (s *Schema) Concise(concise bool) *Schema {
 	}
 
 	return &Schema{
-		ErrorMessage:       s.ErrorMessage,
-		ErrorStackTrace:    s.ErrorStackTrace,
-		RequestHeaders:     s.RequestHeaders,
-		RequestBody:        s.RequestBody,
-		RequestBytesUnread: s.RequestBytesUnread,
-		ResponseHeaders:    s.ResponseHeaders,
-		ResponseBody:       s.ResponseBody,
-		GroupDelimiter:     s.GroupDelimiter,
+		ErrorMessage:                s.ErrorMessage,
+		ErrorStackTrace:             s.ErrorStackTrace,
+		TraceID:                     s.TraceID,
+		SpanID:                      s.SpanID,
+		RequestHeaders:              s.RequestHeaders,
+		RequestBody:                 s.RequestBody,
+		RequestBytesUnread:          s.RequestBytesUnread,
+		UserID:                      s.UserID,
+		UserName:                    s.UserName,
+		UserClaims:                  s.UserClaims,
+		UserIssuer:                  s.UserIssuer,
+		UserAudience:                s.UserAudience,
+		ClientScope:                 s.ClientScope,
+		APIKeyHash:                  s.APIKeyHash,
+		TenantID:                    s.TenantID,
+		ResponseHeaders:             s.ResponseHeaders,
+		ResponseBody:                s.ResponseBody,
+		RequestUserAgentDetails:     s.RequestUserAgentDetails,
+		ClientIsBot:                 s.ClientIsBot,
+		ClientLocale:                s.ClientLocale,
+		RequestID:                   s.RequestID,
+		ErrorTitle:                  s.ErrorTitle,
+		ErrorDetail:                 s.ErrorDetail,
+		GraphQLOperationName:        s.GraphQLOperationName,
+		GraphQLOperationType:        s.GraphQLOperationType,
+		GraphQLDocumentHash:         s.GraphQLDocumentHash,
+		RPCMethod:                   s.RPCMethod,
+		RPCRequestID:                s.RPCRequestID,
+		RequestIdempotencyKey:       s.RequestIdempotencyKey,
+		RequestDuplicate:            s.RequestDuplicate,
+		ResponseRedirectLocation:    s.ResponseRedirectLocation,
+		Labels:                      s.Labels,
+		CDNRayID:                    s.CDNRayID,
+		CDNEdge:                     s.CDNEdge,
+		Extra:                       s.Extra,
+		ResponseErrorMessage:        s.ResponseErrorMessage,
+		ResponseContentTypeMismatch: s.ResponseContentTypeMismatch,
+		RepeatCount:                 s.RepeatCount,
+		GroupDelimiter:              s.GroupDelimiter,
+		RootFields:                  s.RootFields,
+		FieldDelimiters:             s.FieldDelimiters,
 	}
 }
@@ -0,0 +1,75 @@
+package httplog_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	httplog "github.com/rickliujh/chi-httplogr/v3"
+	"github.com/rickliujh/chi-httplogr/v3/httplogtest"
+)
+
+func TestSchemaErrorMessageKeys(t *testing.T) {
+	schemas := map[string]*httplog.Schema{
+		"ECS":  httplog.SchemaECS,
+		"OTEL": httplog.SchemaOTEL,
+		"GCP":  httplog.SchemaGCP,
+	}
+	for name, s := range schemas {
+		if s.ErrorMessage == s.ResponseErrorMessage {
+			t.Errorf("%s: ErrorMessage and ResponseErrorMessage share the key %q", name, s.ErrorMessage)
+		}
+	}
+}
+
+func TestIndexTemplateLeafObjectConflict(t *testing.T) {
+	b, err := httplog.IndexTemplate(httplog.SchemaOTEL, "logs-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var template struct {
+		Template struct {
+			Mappings struct {
+				Subobjects *bool                     `json:"subobjects"`
+				Properties map[string]map[string]any `json:"properties"`
+			} `json:"mappings"`
+		} `json:"template"`
+	}
+	if err := json.Unmarshal(b, &template); err != nil {
+		t.Fatal(err)
+	}
+	mappings := template.Template.Mappings
+	if mappings.Subobjects == nil || *mappings.Subobjects {
+		t.Errorf("want subobjects: false")
+	}
+	for _, field := range []string{httplog.SchemaOTEL.SourceFunction, httplog.SchemaOTEL.HandlerName} {
+		if mappings.Properties[field]["type"] != "keyword" {
+			t.Errorf("%s mapped as %v, want keyword", field, mappings.Properties[field])
+		}
+	}
+}
+
+func TestSchemaCheckValueTypes(t *testing.T) {
+	rec := httplogtest.NewRecorder()
+	httplog.EmitSchemaCheck(rec.Logger(), httplog.SchemaECS)
+
+	entry := rec.LastEntry()
+	if v, _ := entry.Value(httplog.SchemaECS.ResponseStatus); v != 0 {
+		t.Errorf("got status %#v, want 0", v)
+	}
+	if v, _ := entry.Value(httplog.SchemaECS.ResponseDuration); v != 0.0 {
+		t.Errorf("got duration %#v, want 0.0", v)
+	}
+	if v, _ := entry.Value(httplog.SchemaECS.RequestMethod); v != "RequestMethod" {
+		t.Errorf("got method %#v, want RequestMethod", v)
+	}
+}
+
+func TestSchemaGCPHttpRequestFields(t *testing.T) {
+	s := httplog.SchemaGCP
+	for _, field := range []string{s.TenantID, s.RequestPort, s.RequestQuery, s.RequestFragment, s.RequestRemotePort} {
+		if strings.HasPrefix(field, "httpRequest:") {
+			t.Errorf("non-standard field %q in httpRequest", field)
+		}
+	}
+}
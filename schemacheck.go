@@ -0,0 +1,85 @@
+package httplog
+
+import (
+	"reflect"
+	"slices"
+
+	"github.com/go-logr/logr"
+)
+
+// SchemaCheckKey marks the synthetic entry emitted by EmitSchemaCheck.
+const SchemaCheckKey = "httplog.schema_check"
+
+// backendFields are the schema fields written by the log backend itself, e.g.
+// by slog with Schema.ReplaceAttr, which are omitted from the schema check.
+var backendFields = []string{"Timestamp", "Level", "Message", "SourceFile", "SourceLine", "SourceFunction"}
+
+// Fields returns the distinct field names of the schema in the declaration
+// order, followed by the custom fields of Schema.Extra in alphabetical order.
+// Fields omitted from the schema (empty names) are not returned.
+func (s *Schema) Fields() []string {
+	var fields []string
+	v := reflect.ValueOf(s).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).Type.Kind() != reflect.String || t.Field(i).Name == "GroupDelimiter" {
+			continue
+		}
+		if name := v.Field(i).String(); name != "" && !slices.Contains(fields, name) {
+			fields = append(fields, name)
+		}
+	}
+
+	extra := make([]string, 0, len(s.Extra))
+	for _, name := range s.Extra {
+		if name != "" && !slices.Contains(fields, name) && !slices.Contains(extra, name) {
+			extra = append(extra, name)
+		}
+	}
+	slices.Sort(extra)
+	return append(fields, extra...)
+}
+
+// EmitSchemaCheck emits one synthetic entry with all fields of the schema, so
+// that deployments can catch mapping or index template mismatches of the log
+// sink before real traffic arrives, see Options.EmitSchemaCheck. The entry is
+// marked by SchemaCheckKey and each string field holds the name of the semantic
+// field it's mapped from, e.g. "http.request.method": "RequestMethod". The
+// numeric, boolean and object fields hold sample values of their types, as
+// mapped by IndexTemplate, e.g. "http.response.status_code": 0. The fields
+// written by the log backend (timestamp, level, message and source) are omitted.
+func EmitSchemaCheck(logger logr.Logger, s *Schema) {
+	v := reflect.ValueOf(s).Elem()
+	t := v.Type()
+	kvs := []any{SchemaCheckKey, true}
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).Type.Kind() != reflect.String || t.Field(i).Name == "GroupDelimiter" || slices.Contains(backendFields, t.Field(i).Name) {
+			continue
+		}
+		kvs = appendKVs(kvs, v.Field(i).String(), sampleValue(t.Field(i).Name))
+	}
+	for name, field := range s.Extra {
+		kvs = appendKVs(kvs, field, name)
+	}
+	kvs = dedupeKVs(kvs)
+	if s.grouped() {
+		kvs = groupKVs(kvs, s)
+	}
+	logger.Info("httplog schema check", kvs...)
+}
+
+// sampleValue returns the sample value of the schema check for the semantic
+// field, of the type the field is logged as, see fieldTypes.
+func sampleValue(name string) any {
+	switch fieldTypes[name] {
+	case "long":
+		return 0
+	case "float":
+		return 0.0
+	case "boolean":
+		return false
+	case "object":
+		return map[string]any{"field": name}
+	}
+	return name
+}
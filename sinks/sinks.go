@@ -0,0 +1,134 @@
+// Package sinks publishes the request log entries serialized as JSON directly
+// to writers, channels or message queue (e.g. Kafka) producers, so that access
+// logs can bypass the logging pipeline, e.g. for analytics ingestion:
+//
+//	sink := sinks.NewWriterSink(file)
+//
+//	r.Use(httplog.RequestLogger(logger, &httplog.Options{
+//		Processors: []httplog.EntryProcessor{
+//			sinks.NewProcessor(sink, sinks.Options{}),
+//		},
+//	}))
+//
+// The entries are published synchronously from the request path, so producers
+// of remote queues should buffer the messages and send them in the background.
+package sinks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	httplog "github.com/rickliujh/chi-httplogr/v3"
+)
+
+// ErrDropped is returned by ChanSink, if the channel is full.
+var ErrDropped = errors.New("sinks: entry dropped")
+
+// Sink publishes the serialized entries. The key is the request ID of the entry,
+// if logged, e.g. to be used as the Kafka message key.
+type Sink interface {
+	Publish(ctx context.Context, key, value []byte) error
+}
+
+// SinkFunc is an adapter to use an ordinary function as a Sink, e.g. to publish
+// the entries by a Kafka producer:
+//
+//	sinks.SinkFunc(func(ctx context.Context, key, value []byte) error {
+//		return writer.WriteMessages(ctx, kafka.Message{Key: key, Value: value})
+//	})
+type SinkFunc func(ctx context.Context, key, value []byte) error
+
+// Publish implements Sink.
+func (f SinkFunc) Publish(ctx context.Context, key, value []byte) error {
+	return f(ctx, key, value)
+}
+
+// WriterSink writes the entries to an io.Writer as JSON Lines.
+type WriterSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewWriterSink returns a new WriterSink writing to w, e.g. a file.
+func NewWriterSink(w io.Writer) *WriterSink {
+	return &WriterSink{w: w}
+}
+
+// Publish implements Sink.
+func (s *WriterSink) Publish(ctx context.Context, key, value []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err := s.w.Write(append(value, '\n'))
+	return err
+}
+
+// ChanSink sends the entries to a channel without blocking. Entries are dropped
+// with ErrDropped, if the channel is full.
+type ChanSink chan<- []byte
+
+// Publish implements Sink.
+func (s ChanSink) Publish(ctx context.Context, key, value []byte) error {
+	select {
+	case s <- value:
+		return nil
+	default:
+		return ErrDropped
+	}
+}
+
+// Options configures the processor returned by NewProcessor.
+type Options struct {
+	// Bypass drops the published entries, so that they are not written by the
+	// logger. Entries failed to be published are still written by the logger.
+	Bypass bool
+
+	// OnError is an optional function called when an entry fails to be published.
+	OnError func(err error)
+}
+
+// NewProcessor returns an httplog.EntryProcessor publishing the entries to the sink.
+func NewProcessor(sink Sink, opts Options) httplog.EntryProcessor {
+	return httplog.EntryProcessorFunc(func(e *httplog.Entry) *httplog.Entry {
+		err := publish(sink, e)
+		if err != nil {
+			if opts.OnError != nil {
+				opts.OnError(err)
+			}
+			return e
+		}
+		if opts.Bypass {
+			return nil
+		}
+		return e
+	})
+}
+
+func publish(sink Sink, e *httplog.Entry) error {
+	value, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("sinks: encoding entry: %w", err)
+	}
+	ctx := context.Background()
+	if e.Request != nil {
+		// Publish the entries of requests aborted by the client too.
+		ctx = context.WithoutCancel(e.Request.Context())
+	}
+	return sink.Publish(ctx, requestID(e), value)
+}
+
+// requestID returns the request ID of the entry, if logged.
+func requestID(e *httplog.Entry) []byte {
+	if e.Schema == nil || e.Schema.RequestID == "" {
+		return nil
+	}
+	for i := 0; i+1 < len(e.KeysAndValues); i += 2 {
+		if e.KeysAndValues[i] == e.Schema.RequestID {
+			return []byte(fmt.Sprint(e.KeysAndValues[i+1]))
+		}
+	}
+	return nil
+}
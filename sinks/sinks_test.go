@@ -0,0 +1,108 @@
+package sinks_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5/middleware"
+	httplog "github.com/rickliujh/chi-httplogr/v3"
+	"github.com/rickliujh/chi-httplogr/v3/httplogtest"
+	"github.com/rickliujh/chi-httplogr/v3/sinks"
+)
+
+// serve serves a request through the request logger publishing to the sink, and
+// returns the number of entries written by the logger.
+func serve(t *testing.T, sink sinks.Sink, opts sinks.Options) int {
+	t.Helper()
+	rec := httplogtest.NewRecorder()
+	handler := middleware.RequestID(httplog.RequestLogger(rec.Logger(), &httplog.Options{
+		LogRequestID: true,
+		Processors:   []httplog.EntryProcessor{sinks.NewProcessor(sink, opts)},
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	return len(rec.Entries())
+}
+
+func TestProcessor(t *testing.T) {
+	errPublish := errors.New("publish failed")
+	tests := []struct {
+		name        string
+		bypass      bool
+		err         error
+		wantEntries int
+	}{
+		{name: "Published", wantEntries: 1},
+		{name: "Bypassed", bypass: true, wantEntries: 0},
+		{name: "FailedBypass", bypass: true, err: errPublish, wantEntries: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var key, value []byte
+			var onError error
+			sink := sinks.SinkFunc(func(ctx context.Context, k, v []byte) error {
+				key, value = k, v
+				return tt.err
+			})
+			got := serve(t, sink, sinks.Options{Bypass: tt.bypass, OnError: func(err error) { onError = err }})
+
+			if got != tt.wantEntries {
+				t.Errorf("got %d logged entries, want %d", got, tt.wantEntries)
+			}
+			if onError != tt.err {
+				t.Errorf("got OnError %v, want %v", onError, tt.err)
+			}
+			var entry map[string]any
+			if err := json.Unmarshal(value, &entry); err != nil {
+				t.Fatalf("invalid JSON %s: %v", value, err)
+			}
+			if len(key) == 0 || entry[httplog.SchemaECS.RequestID] != string(key) {
+				t.Errorf("got key %q, want the request ID of %s", key, value)
+			}
+		})
+	}
+}
+
+func TestWriterSink(t *testing.T) {
+	var buf bytes.Buffer
+	sink := sinks.NewWriterSink(&buf)
+	serve(t, sink, sinks.Options{})
+	serve(t, sink, sinks.Options{})
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2:\n%s", len(lines), buf.String())
+	}
+	for _, line := range lines {
+		if !json.Valid([]byte(line)) {
+			t.Errorf("invalid JSON line: %s", line)
+		}
+	}
+}
+
+func TestChanSink(t *testing.T) {
+	ch := make(chan []byte, 1)
+	var errs []error
+	opts := sinks.Options{Bypass: true, OnError: func(err error) { errs = append(errs, err) }}
+
+	if got := serve(t, sinks.ChanSink(ch), opts); got != 0 {
+		t.Errorf("got %d logged entries, want 0", got)
+	}
+	// The channel is full, so the entry is dropped and written by the logger.
+	if got := serve(t, sinks.ChanSink(ch), opts); got != 1 {
+		t.Errorf("got %d logged entries, want 1", got)
+	}
+	if len(errs) != 1 || !errors.Is(errs[0], sinks.ErrDropped) {
+		t.Errorf("got errors %v, want ErrDropped", errs)
+	}
+	if len(ch) != 1 {
+		t.Errorf("got %d published entries, want 1", len(ch))
+	}
+}
@@ -0,0 +1,33 @@
+package httplog
+
+import (
+	"time"
+)
+
+// SLO is the service level objective of a route, see Options.SLOs.
+type SLO struct {
+	// Latency is the maximum duration of a good request. Zero disables the
+	// latency objective.
+	Latency time.Duration
+
+	// MinErrorStatus is the minimum status of a bad request.
+	//
+	// If not provided, the default is 500, i.e. HTTP 5xx responses are bad.
+	MinErrorStatus int
+}
+
+// sloKVs returns whether the request violated the SLO and the reason, i.e.
+// "status" or "latency".
+func sloKVs(slo SLO, status int, duration time.Duration, s *Schema) []any {
+	minErrorStatus := slo.MinErrorStatus
+	if minErrorStatus == 0 {
+		minErrorStatus = 500
+	}
+	switch {
+	case status >= minErrorStatus:
+		return []any{s.SLOViolated, true, s.SLOReason, "status"}
+	case slo.Latency > 0 && duration > slo.Latency:
+		return []any{s.SLOViolated, true, s.SLOReason, "latency"}
+	}
+	return []any{s.SLOViolated, false}
+}
@@ -0,0 +1,120 @@
+package httplog
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"time"
+)
+
+// headerSnapshot is a copy of the response headers taken when the headers were
+// sent, see Options.SnapshotResponseHeaders.
+type headerSnapshot struct {
+	header http.Header
+	taken  bool
+
+	// capture copies the headers, see Options.SnapshotResponseHeaders.
+	capture bool
+
+	// beforeSend is called once right before the headers are sent, e.g. to add
+	// the Server-Timing header, see Options.ServerTiming.
+	beforeSend func(header http.Header)
+
+	// clock, if set, records the time the headers were sent as sentAt, see
+	// Options.StallThreshold.
+	clock  Clock
+	sentAt time.Time
+}
+
+func (hs *headerSnapshot) take(header http.Header) {
+	if hs.taken {
+		return
+	}
+	hs.taken = true
+	if hs.clock != nil {
+		hs.sentAt = hs.clock.Now()
+	}
+	if hs.beforeSend != nil {
+		hs.beforeSend(header)
+	}
+	if hs.capture {
+		hs.header = header.Clone()
+	}
+}
+
+// wrapSnapshot returns w wrapped to take the snapshot of the response headers
+// on the first WriteHeader, Write, Flush, ReadFrom or Hijack. It's wrapped by
+// chi's middleware.WrapResponseWriter, so it preserves the optional interfaces
+// chi looks for, i.e. http.Flusher, http.Hijacker and io.ReaderFrom of HTTP/1.x
+// writers, and http.Flusher and http.Pusher of HTTP/2 writers.
+func wrapSnapshot(w http.ResponseWriter, snap *headerSnapshot) http.ResponseWriter {
+	sw := snapshotWriter{ResponseWriter: w, snap: snap}
+	_, fl := w.(http.Flusher)
+	_, hj := w.(http.Hijacker)
+	_, rf := w.(io.ReaderFrom)
+	_, ps := w.(http.Pusher)
+	switch {
+	case fl && hj && rf:
+		return &snapshotHTTP1Writer{snapshotFlushWriter{sw}}
+	case fl && ps:
+		return &snapshotHTTP2Writer{snapshotFlushWriter{sw}}
+	case fl:
+		return &snapshotFlushWriter{sw}
+	default:
+		return &sw
+	}
+}
+
+type snapshotWriter struct {
+	http.ResponseWriter
+	snap *headerSnapshot
+}
+
+func (w *snapshotWriter) WriteHeader(code int) {
+	// Informational responses (e.g. 103 Early Hints) are followed by the final headers.
+	if code >= 200 || code == http.StatusSwitchingProtocols {
+		w.snap.take(w.Header())
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *snapshotWriter) Write(p []byte) (int, error) {
+	w.snap.take(w.Header())
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *snapshotWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+type snapshotFlushWriter struct {
+	snapshotWriter
+}
+
+func (w *snapshotFlushWriter) Flush() {
+	w.snap.take(w.Header())
+	w.ResponseWriter.(http.Flusher).Flush()
+}
+
+type snapshotHTTP1Writer struct {
+	snapshotFlushWriter
+}
+
+func (w *snapshotHTTP1Writer) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.snap.take(w.Header())
+	return w.ResponseWriter.(http.Hijacker).Hijack()
+}
+
+func (w *snapshotHTTP1Writer) ReadFrom(r io.Reader) (int64, error) {
+	w.snap.take(w.Header())
+	return w.ResponseWriter.(io.ReaderFrom).ReadFrom(r)
+}
+
+type snapshotHTTP2Writer struct {
+	snapshotFlushWriter
+}
+
+func (w *snapshotHTTP2Writer) Push(target string, opts *http.PushOptions) error {
+	return w.ResponseWriter.(http.Pusher).Push(target, opts)
+}
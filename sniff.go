@@ -0,0 +1,78 @@
+package httplog
+
+import (
+	"bytes"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// sniffLen is the number of bytes considered by http.DetectContentType.
+const sniffLen = 512
+
+// sniffWriter captures the first bytes of textual responses (e.g. JSON) to detect
+// a mismatch of the declared Content-Type and the actual content, e.g. an HTML
+// error page served as application/json. Binary responses and encoded (e.g.
+// gzip-compressed) responses, whose bytes can't be sniffed, are not captured.
+type sniffWriter struct {
+	header  http.Header
+	buf     bytes.Buffer
+	decided bool
+	capture bool
+}
+
+func (sw *sniffWriter) capturing() bool {
+	if !sw.decided {
+		encoding := sw.header.Get("Content-Encoding")
+		sw.capture = (encoding == "" || strings.EqualFold(encoding, "identity")) &&
+			isTextualContentType(mediaType(sw.header.Get("Content-Type")))
+		sw.decided = true
+	}
+	return sw.capture
+}
+
+func (sw *sniffWriter) Write(p []byte) (int, error) {
+	if sw.capturing() && sw.buf.Len() < sniffLen {
+		sw.buf.Write(p[:min(len(p), sniffLen-sw.buf.Len())])
+	}
+	return len(p), nil
+}
+
+// mismatch returns the sniffed Content-Type of the response body, if it doesn't
+// match the declared Content-Type, or an empty string.
+func (sw *sniffWriter) mismatch() string {
+	if sw == nil || sw.buf.Len() == 0 {
+		return ""
+	}
+	declared := mediaType(sw.header.Get("Content-Type"))
+	sniffed := http.DetectContentType(sw.buf.Bytes())
+
+	switch mt := mediaType(sniffed); {
+	case mt == declared, mt == "text/plain", mt == "application/octet-stream":
+		// Plain text is compatible with any textual type, and the unknown content
+		// can't be told apart.
+		return ""
+	case mt == "text/xml":
+		if strings.Contains(declared, "xml") {
+			return ""
+		}
+	case mt == "text/html":
+		if declared == "application/xhtml+xml" {
+			return ""
+		}
+	}
+	return sniffed
+}
+
+func mediaType(contentType string) string {
+	mt, _, _ := mime.ParseMediaType(contentType)
+	return mt
+}
+
+func isTextualContentType(mt string) bool {
+	return strings.HasPrefix(mt, "text/") ||
+		strings.Contains(mt, "json") ||
+		strings.Contains(mt, "xml") ||
+		strings.Contains(mt, "javascript") ||
+		mt == "application/x-www-form-urlencoded"
+}
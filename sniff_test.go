@@ -0,0 +1,52 @@
+package httplog_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	httplog "github.com/rickliujh/chi-httplogr/v3"
+	"github.com/rickliujh/chi-httplogr/v3/httplogtest"
+)
+
+func TestContentTypeMismatch(t *testing.T) {
+	var gzipped bytes.Buffer
+	zw := gzip.NewWriter(&gzipped)
+	zw.Write([]byte(`{"error":"boom"}`))
+	zw.Close()
+
+	tests := []struct {
+		name         string
+		encoding     string
+		body         []byte
+		wantMismatch bool
+	}{
+		{name: "HTMLAsJSON", body: []byte("<html><body>Bad Gateway</body></html>"), wantMismatch: true},
+		{name: "JSON", body: []byte(`{"error":"boom"}`)},
+		{name: "GzipJSON", encoding: "gzip", body: gzipped.Bytes()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httplogtest.NewRecorder()
+			handler := httplog.RequestLogger(rec.Logger(), &httplog.Options{
+				Schema:                    httplog.SchemaECS,
+				DetectContentTypeMismatch: true,
+			})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				if tt.encoding != "" {
+					w.Header().Set("Content-Encoding", tt.encoding)
+				}
+				w.WriteHeader(http.StatusInternalServerError)
+				w.Write(tt.body)
+			}))
+
+			_, entry := rec.RoundTrip(handler, httptest.NewRequest(http.MethodGet, "/", nil))
+			if _, got := entry.Value(httplog.SchemaECS.ResponseContentTypeMismatch); got != tt.wantMismatch {
+				t.Errorf("mismatch logged: %v, want %v; entry: %v", got, tt.wantMismatch, entry.KVs)
+			}
+		})
+	}
+}
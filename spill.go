@@ -0,0 +1,89 @@
+package httplog
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"time"
+)
+
+// bodySpill captures a body in memory up to Options.SpillBodyThreshold bytes,
+// and spills the whole body to a temporary file once it grows larger, so that
+// large bodies are preserved while the memory stays bounded. The in-memory
+// buffer keeps the first bytes of the body, e.g. to be logged.
+type bodySpill struct {
+	mem       *bytes.Buffer
+	threshold int
+	dir       string
+	key       []byte
+	ttl       time.Duration
+	file      *os.File
+	w         io.Writer // file, or sealer encrypting to file
+	sealer    *sealWriter
+	err       error
+	closed    bool
+}
+
+func newBodySpill(mem *bytes.Buffer, o *Options) *bodySpill {
+	return &bodySpill{mem: mem, threshold: o.SpillBodyThreshold, dir: o.SpillBodyDir, key: o.SpillBodyKey, ttl: o.SpillBodyTTL}
+}
+
+func (bs *bodySpill) Write(p []byte) (int, error) {
+	if bs.err != nil {
+		return len(p), nil
+	}
+	if bs.file == nil {
+		if bs.mem.Len()+len(p) <= bs.threshold {
+			return bs.mem.Write(p)
+		}
+		bs.file, bs.err = os.CreateTemp(bs.dir, spillFilePattern)
+		if bs.err == nil {
+			bs.w = bs.file
+			if bs.key != nil {
+				// Never write the body unencrypted, if the key is invalid.
+				bs.sealer, bs.err = newSealWriter(bs.file, bs.key)
+				bs.w = bs.sealer
+			}
+		}
+		if bs.err == nil {
+			_, bs.err = bs.w.Write(bs.mem.Bytes())
+		}
+		// Keep the first bytes of the body in memory.
+		bs.mem.Write(p[:min(len(p), bs.threshold-bs.mem.Len())])
+	}
+	if bs.err == nil {
+		_, bs.err = bs.w.Write(p)
+	}
+	return len(p), nil
+}
+
+// close closes the spill file and returns its path, or "" if the body wasn't
+// spilled or spilling failed.
+func (bs *bodySpill) close() string {
+	if bs == nil || bs.file == nil || bs.closed {
+		return ""
+	}
+	bs.closed = true
+	if bs.sealer != nil && bs.err == nil {
+		bs.err = bs.sealer.close()
+	}
+	if err := bs.file.Close(); err != nil && bs.err == nil {
+		bs.err = err
+	}
+	if bs.err != nil {
+		os.Remove(bs.file.Name())
+		return ""
+	}
+	if bs.ttl > 0 {
+		removeSpillFile(bs.file.Name(), bs.ttl)
+	}
+	return bs.file.Name()
+}
+
+// discard removes the spill file, unless it was closed to be referenced by the
+// request log, e.g. if the request isn't logged.
+func (bs *bodySpill) discard() {
+	if name := bs.close(); name != "" {
+		os.Remove(name)
+	}
+}
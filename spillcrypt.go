@@ -0,0 +1,179 @@
+package httplog
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// spillChunkSize is the number of body bytes sealed per record of the encrypted
+// spill files, see Options.SpillBodyKey.
+const spillChunkSize = 64 << 10
+
+// ErrSpillCorrupt is returned by DecryptSpillBody if the spill file is corrupted,
+// truncated or encrypted with another key.
+var ErrSpillCorrupt = errors.New("httplog: corrupted or truncated spill file")
+
+// sealWriter encrypts the body written to it with AES-GCM, in records of up to
+// spillChunkSize bytes:
+//
+//	length (4 bytes, big-endian) | nonce (12 bytes) | ciphertext and tag
+//
+// The additional data of each record is its index (8 bytes, big-endian) and a
+// final flag (1 byte), so that reordered, dropped and truncated records are
+// detected on decryption. The last record, written by close, is final.
+type sealWriter struct {
+	w     io.Writer
+	aead  cipher.AEAD
+	buf   []byte
+	index uint64
+}
+
+func newSealWriter(w io.Writer, key []byte) (*sealWriter, error) {
+	aead, err := newSpillAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	return &sealWriter{w: w, aead: aead}, nil
+}
+
+func newSpillAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func (sw *sealWriter) Write(p []byte) (int, error) {
+	sw.buf = append(sw.buf, p...)
+	// Keep the last chunk buffered, as it's sealed as final by close.
+	for len(sw.buf) > spillChunkSize {
+		if err := sw.seal(sw.buf[:spillChunkSize], false); err != nil {
+			return 0, err
+		}
+		sw.buf = sw.buf[spillChunkSize:]
+	}
+	return len(p), nil
+}
+
+// close seals the buffered bytes as the final record.
+func (sw *sealWriter) close() error {
+	return sw.seal(sw.buf, true)
+}
+
+func (sw *sealWriter) seal(chunk []byte, final bool) error {
+	record := make([]byte, 4+sw.aead.NonceSize(), 4+sw.aead.NonceSize()+len(chunk)+sw.aead.Overhead())
+	nonce := record[4:]
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	record = sw.aead.Seal(record, nonce, chunk, spillAD(sw.index, final))
+	binary.BigEndian.PutUint32(record, uint32(len(record)-4))
+	sw.index++
+	_, err := sw.w.Write(record)
+	return err
+}
+
+// spillAD returns the additional data of the record with the given index.
+func spillAD(index uint64, final bool) []byte {
+	ad := binary.BigEndian.AppendUint64(nil, index)
+	if final {
+		return append(ad, 1)
+	}
+	return append(ad, 0)
+}
+
+// DecryptSpillBody decrypts the body spill file encrypted with the key of
+// Options.SpillBodyKey from src to dst, e.g.:
+//
+//	f, err := os.Open(ref) // Schema.RequestBodyRef or Schema.ResponseBodyRef
+//	...
+//	err = httplog.DecryptSpillBody(os.Stdout, f, key)
+//
+// It returns ErrSpillCorrupt if the file was tampered with, truncated or
+// encrypted with another key. The bytes written to dst before the error are
+// authenticated, but the body may be incomplete.
+func DecryptSpillBody(dst io.Writer, src io.Reader, key []byte) error {
+	aead, err := newSpillAEAD(key)
+	if err != nil {
+		return err
+	}
+
+	var lenBuf [4]byte
+	for index := uint64(0); ; index++ {
+		if _, err := io.ReadFull(src, lenBuf[:]); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return ErrSpillCorrupt // no final record
+			}
+			return err
+		}
+		n := binary.BigEndian.Uint32(lenBuf[:])
+		if n < uint32(aead.NonceSize()+aead.Overhead()) || n > uint32(aead.NonceSize()+spillChunkSize+aead.Overhead()) {
+			return ErrSpillCorrupt
+		}
+		record := make([]byte, n)
+		if _, err := io.ReadFull(src, record); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return ErrSpillCorrupt
+			}
+			return err
+		}
+		nonce, ciphertext := record[:aead.NonceSize()], record[aead.NonceSize():]
+
+		final := true
+		plaintext, err := aead.Open(nil, nonce, ciphertext, spillAD(index, final))
+		if err != nil {
+			final = false
+			if plaintext, err = aead.Open(nil, nonce, ciphertext, spillAD(index, final)); err != nil {
+				return ErrSpillCorrupt
+			}
+		}
+		if _, err := dst.Write(plaintext); err != nil {
+			return err
+		}
+		if final {
+			// The final record must be the last one.
+			if n, _ := src.Read(lenBuf[:1]); n > 0 {
+				return ErrSpillCorrupt
+			}
+			return nil
+		}
+	}
+}
+
+// spillFilePattern is the pattern of the spill file names, see os.CreateTemp.
+const spillFilePattern = "httplog-body-*"
+
+// removeSpillFile removes the spill file after the TTL, see Options.SpillBodyTTL.
+func removeSpillFile(name string, ttl time.Duration) {
+	time.AfterFunc(ttl, func() { os.Remove(name) })
+}
+
+// sweepSpillFiles removes the spill files in the directory older than the TTL,
+// e.g. left behind by a previous process, see Options.SpillBodyTTL.
+func sweepSpillFiles(dir string, ttl time.Duration) {
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	prefix := strings.TrimSuffix(spillFilePattern, "*")
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasPrefix(entry.Name(), prefix) {
+			continue
+		}
+		if info, err := entry.Info(); err == nil && time.Since(info.ModTime()) > ttl {
+			os.Remove(filepath.Join(dir, entry.Name()))
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package httplog
+
+import (
+	"sync/atomic"
+)
+
+// Stats holds the counters of all request logger middlewares in the process,
+// so that operators can monitor the request logger itself.
+type Stats struct {
+	RequestsLogged     uint64 `json:"requestsLogged"`     // Requests recorded in the logs
+	RequestsSuppressed uint64 `json:"requestsSuppressed"` // Requests filtered out by Skip or log level
+	PanicsRecovered    uint64 `json:"panicsRecovered"`    // Panics recovered from the HTTP handlers
+	BodyBytesCaptured  uint64 `json:"bodyBytesCaptured"`  // Request and response body bytes captured for logging
+	HookPanics         uint64 `json:"hookPanics"`         // Panics recovered from the user-provided hooks
+	EmitPanics         uint64 `json:"emitPanics"`         // Panics recovered from the log sink writing the request logs
+	EmitTimeouts       uint64 `json:"emitTimeouts"`       // Request logs not written within Options.EmitTimeout
+	EmitsDropped       uint64 `json:"emitsDropped"`       // Log entries dropped over Options.MaxPendingEmits
+}
+
+var stats struct {
+	requestsLogged     atomic.Uint64
+	requestsSuppressed atomic.Uint64
+	panicsRecovered    atomic.Uint64
+	bodyBytesCaptured  atomic.Uint64
+	hookPanics         atomic.Uint64
+	emitPanics         atomic.Uint64
+	emitTimeouts       atomic.Uint64
+	emitsDropped       atomic.Uint64
+}
+
+// ReadStats returns a snapshot of the current request logger counters.
+func ReadStats() Stats {
+	return Stats{
+		RequestsLogged:     stats.requestsLogged.Load(),
+		RequestsSuppressed: stats.requestsSuppressed.Load(),
+		PanicsRecovered:    stats.panicsRecovered.Load(),
+		BodyBytesCaptured:  stats.bodyBytesCaptured.Load(),
+		HookPanics:         stats.hookPanics.Load(),
+		EmitPanics:         stats.emitPanics.Load(),
+		EmitTimeouts:       stats.emitTimeouts.Load(),
+		EmitsDropped:       stats.emitsDropped.Load(),
+	}
+}
@@ -0,0 +1,289 @@
+package httplog
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// streamType returns the type of the streaming request, i.e. "grpc" for gRPC
+// and gRPC-Web calls and "websocket" for WebSocket upgrades, or "" otherwise.
+// Their bodies are streams of messages rather than documents, so they are never
+// captured.
+func streamType(r *http.Request) string {
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+		return "grpc"
+	}
+	if strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+		return "websocket"
+	}
+	return ""
+}
+
+// frameCounter counts the frames of a stream, i.e. the length-prefixed gRPC
+// messages or the WebSocket frames, see Options.LogStreamMessages.
+type frameCounter struct {
+	mu      sync.Mutex
+	frames  int
+	hdr     [14]byte
+	hdrLen  int
+	payload uint64 // remaining payload bytes of the current frame
+
+	// handshake skips the HTTP response of the WebSocket handshake written to the
+	// hijacked connection, up to the empty line; crlf is the length matched so far.
+	handshake bool
+	crlf      int
+
+	// headerLen returns the length of the frame header, given its first bytes,
+	// and payloadLen the length of the payload, given the full header.
+	headerLen  func(hdr []byte) int
+	payloadLen func(hdr []byte) uint64
+
+	// webSocket captures the payload of the first WebSocket close frame, i.e. the
+	// status code and the reason, unmasked by mask; closeSeq orders the close
+	// frames of both directions.
+	webSocket bool
+	closed    bool
+	closing   bool
+	masked    bool
+	mask      [4]byte
+	closeData []byte
+	closeSeq  uint64
+}
+
+// closeSeq orders the WebSocket close frames, see frameCounter.closeSeq.
+var closeSeq atomic.Uint64
+
+// maxCloseFrame is the maximum payload length of WebSocket close frames, as of
+// all control frames.
+const maxCloseFrame = 125
+
+func newGRPCCounter() *frameCounter {
+	return &frameCounter{
+		headerLen:  func([]byte) int { return 5 }, // compressed flag, length
+		payloadLen: func(hdr []byte) uint64 { return uint64(binary.BigEndian.Uint32(hdr[1:5])) },
+	}
+}
+
+func newWebSocketCounter() *frameCounter {
+	return &frameCounter{
+		webSocket: true,
+		headerLen: func(hdr []byte) int {
+			if len(hdr) < 2 {
+				return 2
+			}
+			n := 2
+			switch hdr[1] & 0x7f {
+			case 126:
+				n += 2
+			case 127:
+				n += 8
+			}
+			if hdr[1]&0x80 != 0 { // masking key
+				n += 4
+			}
+			return n
+		},
+		payloadLen: func(hdr []byte) uint64 {
+			switch l := hdr[1] & 0x7f; l {
+			case 126:
+				return uint64(binary.BigEndian.Uint16(hdr[2:4]))
+			case 127:
+				return binary.BigEndian.Uint64(hdr[2:10])
+			default:
+				return uint64(l)
+			}
+		},
+	}
+}
+
+func (c *frameCounter) count(p []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for len(p) > 0 && c.handshake {
+		switch {
+		case p[0] == "\r\n\r\n"[c.crlf]:
+			c.crlf++
+		case p[0] == '\r':
+			c.crlf = 1
+		default:
+			c.crlf = 0
+		}
+		c.handshake = c.crlf < 4
+		p = p[1:]
+	}
+	for len(p) > 0 {
+		if c.payload > 0 {
+			n := min(uint64(len(p)), c.payload)
+			if c.closing {
+				c.captureClose(p[:n])
+			}
+			c.payload -= n
+			p = p[n:]
+			c.closing = c.closing && c.payload > 0
+			continue
+		}
+		c.hdr[c.hdrLen] = p[0]
+		c.hdrLen++
+		p = p[1:]
+		if c.hdrLen == c.headerLen(c.hdr[:c.hdrLen]) {
+			c.payload = c.payloadLen(c.hdr[:c.hdrLen])
+			c.frames++
+			if c.webSocket && !c.closed && c.hdr[0]&0x0f == 0x8 { // close frame
+				c.closed, c.closing = true, c.payload > 0
+				c.closeSeq = closeSeq.Add(1)
+				if c.masked = c.hdr[1]&0x80 != 0; c.masked {
+					copy(c.mask[:], c.hdr[c.hdrLen-4:c.hdrLen])
+				}
+			}
+			c.hdrLen = 0
+		}
+	}
+}
+
+// captureClose captures the bytes of the close frame payload, unmasked.
+func (c *frameCounter) captureClose(p []byte) {
+	for _, b := range p[:min(len(p), maxCloseFrame-len(c.closeData))] {
+		if c.masked {
+			b ^= c.mask[len(c.closeData)%4]
+		}
+		c.closeData = append(c.closeData, b)
+	}
+}
+
+// closeFrame returns the status code and the reason of the first close frame,
+// 1005 (no status received) if the frame has no status code, and its sequence,
+// or a zero sequence if no close frame was seen.
+func (c *frameCounter) closeFrame() (code int, reason string, seq uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.closed {
+		return 0, "", 0
+	}
+	if len(c.closeData) < 2 {
+		return 1005, "", c.closeSeq
+	}
+	return int(binary.BigEndian.Uint16(c.closeData)), string(c.closeData[2:]), c.closeSeq
+}
+
+func (c *frameCounter) get() int {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.frames
+}
+
+// Write implements bodyCapturer, counting the frames of the response body.
+func (c *frameCounter) Write(p []byte) (int, error) {
+	c.count(p)
+	return len(p), nil
+}
+
+func (c *frameCounter) capturing() bool {
+	return true
+}
+
+// frameReader counts the frames of the stream read from the underlying reader.
+type frameReader struct {
+	io.ReadCloser
+	counter *frameCounter
+}
+
+func (fr *frameReader) Read(p []byte) (int, error) {
+	n, err := fr.ReadCloser.Read(p)
+	fr.counter.count(p[:n])
+	return n, err
+}
+
+// webSocketWriter counts the WebSocket frames on the hijacked connection. It's
+// wrapped by chi's middleware.WrapResponseWriter, which only looks for
+// http.Flusher and http.Hijacker of WebSocket (i.e. HTTP/1.1) writers.
+type webSocketWriter struct {
+	http.ResponseWriter
+	in, out     *frameCounter
+	wroteHeader bool
+	hijacked    atomic.Bool
+	connClosed  atomic.Bool // the hijacked connection was closed
+}
+
+func (w *webSocketWriter) WriteHeader(code int) {
+	w.wroteHeader = true
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *webSocketWriter) Flush() {
+	if fl, ok := w.ResponseWriter.(http.Flusher); ok {
+		fl.Flush()
+	}
+}
+
+func (w *webSocketWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, brw, err := w.ResponseWriter.(http.Hijacker).Hijack()
+	if err != nil {
+		return conn, brw, err
+	}
+	w.hijacked.Store(true)
+	// The handshake is written to the connection, unless it was sent by WriteHeader.
+	w.out.handshake = !w.wroteHeader
+	fc := &frameConn{Conn: conn, in: w.in, out: w.out, closed: &w.connClosed}
+	// The buffered reader may already hold frames sent right after the handshake.
+	reader := bufio.NewReader(&frameReader{ReadCloser: io.NopCloser(brw.Reader), counter: w.in})
+	return fc, bufio.NewReadWriter(reader, bufio.NewWriter(fc)), nil
+}
+
+func (w *webSocketWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+type frameConn struct {
+	net.Conn
+	in, out *frameCounter
+	closed  *atomic.Bool
+}
+
+func (c *frameConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	c.in.count(p[:n])
+	return n, err
+}
+
+func (c *frameConn) Write(p []byte) (int, error) {
+	n, err := c.Conn.Write(p)
+	c.out.count(p[:n])
+	return n, err
+}
+
+func (c *frameConn) Close() error {
+	c.closed.Store(true)
+	return c.Conn.Close()
+}
+
+// closeKVs returns the status code, the reason and the initiator of the first
+// close frame of the hijacked WebSocket connection, or the status code 1006
+// (abnormal closure) if the connection was closed without a close frame from
+// either side. It returns nil while the connection is still open, e.g. when
+// the handler hands the connection over to another goroutine.
+func (w *webSocketWriter) closeKVs(s *Schema) []any {
+	if !w.hijacked.Load() {
+		return nil
+	}
+	inCode, inReason, inSeq := w.in.closeFrame()
+	outCode, outReason, outSeq := w.out.closeFrame()
+	switch {
+	case inSeq != 0 && (outSeq == 0 || inSeq < outSeq):
+		return appendKVs(nil, s.WebSocketCloseCode, inCode, s.WebSocketCloseReason, inReason, s.WebSocketCloseInitiator, "client")
+	case outSeq != 0:
+		return appendKVs(nil, s.WebSocketCloseCode, outCode, s.WebSocketCloseReason, outReason, s.WebSocketCloseInitiator, "server")
+	}
+	if !w.connClosed.Load() {
+		return nil
+	}
+	return []any{s.WebSocketCloseCode, 1006}
+}
@@ -0,0 +1,70 @@
+package httplog_test
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	httplog "github.com/rickliujh/chi-httplogr/v3"
+	"github.com/rickliujh/chi-httplogr/v3/httplogtest"
+)
+
+// hijackRecorder is a response recorder hijacked to one end of a pipe.
+type hijackRecorder struct {
+	*httptest.ResponseRecorder
+	conn net.Conn
+}
+
+func (w *hijackRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return w.conn, bufio.NewReadWriter(bufio.NewReader(w.conn), bufio.NewWriter(w.conn)), nil
+}
+
+func TestWebSocketAbnormalClosure(t *testing.T) {
+	tests := []struct {
+		name      string
+		closeConn bool
+		wantCode  bool
+	}{
+		{name: "Closed", closeConn: true, wantCode: true},
+		{name: "HandedOff", closeConn: false, wantCode: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httplogtest.NewRecorder()
+			handler := httplog.RequestLogger(rec.Logger(), &httplog.Options{
+				Schema:            httplog.SchemaECS,
+				Levels:            &httplog.Levels{Warn: 1, Info: 1, Debug: 1},
+				LogStreamMessages: true,
+			})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				conn, _, err := w.(http.Hijacker).Hijack()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if tt.closeConn {
+					conn.Close()
+				}
+			}))
+
+			server, client := net.Pipe()
+			defer server.Close()
+			defer client.Close()
+
+			req := httptest.NewRequest(http.MethodGet, "/ws", nil)
+			req.Header.Set("Connection", "Upgrade")
+			req.Header.Set("Upgrade", "websocket")
+			handler.ServeHTTP(&hijackRecorder{ResponseRecorder: httptest.NewRecorder(), conn: server}, req)
+
+			entry := rec.LastEntry()
+			_, ok := entry.Value(httplog.SchemaECS.WebSocketCloseCode)
+			if ok != tt.wantCode {
+				t.Errorf("close code logged: %v, want %v; entry: %v", ok, tt.wantCode, entry.KVs)
+			}
+			if tt.wantCode && !entry.HasKV(httplog.SchemaECS.WebSocketCloseCode, 1006) {
+				t.Errorf("want close code 1006; entry: %v", entry.KVs)
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package httplog
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+// Summarizer aggregates the requests per route over time windows, and logs one
+// summary entry per route and window, with the request count, the p50 and p95
+// latencies and the breakdown by status class. It's a lightweight alternative
+// to metrics for small deployments, e.g.:
+//
+//	summarizer := httplog.NewSummarizer()
+//	go summarizer.Run(ctx, logger, time.Minute)
+//	r.Use(httplog.RequestLogger(logger, &httplog.Options{Summarizer: summarizer}))
+//
+// The requests are aggregated regardless of whether their request logs are
+// written. The latencies of a window are kept in memory until it's logged.
+// The route is keyed by Schema.RequestRoute of the Options the summarizer is
+// used with, and grouped like the request logs.
+type Summarizer struct {
+	mu     sync.Mutex
+	routes map[string]*routeSummary
+	bounds []time.Duration
+	schema *Schema
+}
+
+type routeSummary struct {
+	latencies     []time.Duration
+	statusClasses map[string]int
+}
+
+// NewSummarizer returns a new Summarizer.
+//
+// If histogram bounds are given, the summary entries also hold the latency
+// histogram of the route as "latencyBuckets", i.e. the cumulative request
+// counts of the latencies less than or equal to each bound ("leMs"), so that
+// percentile trends can be derived from the logs across windows and instances,
+// e.g.:
+//
+//	httplog.NewSummarizer(10*time.Millisecond, 50*time.Millisecond, 250*time.Millisecond, time.Second)
+//
+// The count of all requests, i.e. of the +Inf bucket, is "requests".
+func NewSummarizer(histogramBounds ...time.Duration) *Summarizer {
+	bounds := slices.Clone(histogramBounds)
+	slices.Sort(bounds)
+	return &Summarizer{routes: map[string]*routeSummary{}, bounds: slices.Compact(bounds)}
+}
+
+// add aggregates the request to the route, see MetricsLabel.
+func (s *Summarizer) add(route string, status int, duration time.Duration, schema *Schema) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.schema = schema
+	rs, ok := s.routes[route]
+	if !ok {
+		rs = &routeSummary{statusClasses: map[string]int{}}
+		s.routes[route] = rs
+	}
+	rs.latencies = append(rs.latencies, duration)
+	rs.statusClasses[statusClass(status)]++
+}
+
+// Run logs the summary of the requests aggregated in the past window every
+// interval, until ctx is canceled. Run it in a goroutine.
+func (s *Summarizer) Run(ctx context.Context, logger logr.Logger, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		s.log(logger, interval)
+	}
+}
+
+// log logs the summary of the current window and starts a new window.
+func (s *Summarizer) log(logger logr.Logger, window time.Duration) {
+	s.mu.Lock()
+	routes, schema := s.routes, s.schema
+	s.routes = map[string]*routeSummary{}
+	s.mu.Unlock()
+
+	routeKey := "route"
+	if schema != nil && schema.RequestRoute != "" {
+		routeKey = schema.RequestRoute
+	}
+
+	names := make([]string, 0, len(routes))
+	for route := range routes {
+		names = append(names, route)
+	}
+	sort.Strings(names)
+
+	for _, route := range names {
+		rs := routes[route]
+		sort.Slice(rs.latencies, func(i, j int) bool { return rs.latencies[i] < rs.latencies[j] })
+		kvs := []any{
+			routeKey, route,
+			"window", window.String(),
+			"requests", len(rs.latencies),
+			"latencyP50Ms", float64(percentile(rs.latencies, 0.50)) / float64(time.Millisecond),
+			"latencyP95Ms", float64(percentile(rs.latencies, 0.95)) / float64(time.Millisecond),
+			"statusClasses", rs.statusClasses,
+		}
+		if len(s.bounds) > 0 {
+			kvs = append(kvs, "latencyBuckets", histogram(rs.latencies, s.bounds))
+		}
+		if schema != nil && schema.grouped() {
+			kvs = groupKVs(kvs, schema)
+		}
+		logger.Info("HTTP request summary", kvs...)
+	}
+}
+
+// histogram returns the cumulative counts of the sorted durations less than or
+// equal to each of the sorted bounds.
+func histogram(sorted []time.Duration, bounds []time.Duration) []map[string]any {
+	buckets := make([]map[string]any, len(bounds))
+	for i, bound := range bounds {
+		count, _ := slices.BinarySearch(sorted, bound+1)
+		buckets[i] = map[string]any{
+			"leMs":  float64(bound) / float64(time.Millisecond),
+			"count": count,
+		}
+	}
+	return buckets
+}
+
+// percentile returns the percentile of the sorted durations, using the
+// nearest-rank method.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(p*float64(len(sorted))+0.5) - 1
+	return sorted[min(max(rank, 0), len(sorted)-1)]
+}
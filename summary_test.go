@@ -0,0 +1,44 @@
+package httplog_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	httplog "github.com/rickliujh/chi-httplogr/v3"
+	"github.com/rickliujh/chi-httplogr/v3/httplogtest"
+)
+
+func TestSummarizerSchema(t *testing.T) {
+	summarizer := httplog.NewSummarizer()
+	handler := httplog.RequestLogger(httplogtest.NewRecorder().Logger(), &httplog.Options{
+		Schema:     httplog.SchemaOTEL,
+		Summarizer: summarizer,
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(time.Millisecond / 2)
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	rec := httplogtest.NewRecorder()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		summarizer.Run(ctx, rec.Logger(), 10*time.Millisecond)
+	}()
+	for len(rec.Entries()) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	entry := rec.Entries()[0]
+	if _, ok := entry.Value(httplog.SchemaOTEL.RequestRoute); !ok {
+		t.Errorf("entry is missing %s: %v", httplog.SchemaOTEL.RequestRoute, entry.KVs)
+	}
+	if p50, _ := entry.Value("latencyP50Ms"); p50.(float64) == float64(int(p50.(float64))) {
+		t.Errorf("got p50 %v ms, want fractional milliseconds", p50)
+	}
+}
@@ -0,0 +1,158 @@
+package httplog
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// timing holds a named duration recorded by Mark or Span.
+type timing struct {
+	name     string
+	duration time.Duration
+}
+
+// Mark records the time elapsed since the start of the request under the given
+// name, e.g. httplog.Mark(ctx, "db_done"). The marks are logged as a nested
+// Schema.Timings object in milliseconds. Marking the same name again overwrites
+// the previous mark.
+func Mark(ctx context.Context, name string) {
+	if rl := getRequestLog(ctx); rl != nil {
+		rl.mu.Lock()
+		defer rl.mu.Unlock()
+		rl.setTiming(name, rl.clock.Since(rl.start), false)
+	}
+}
+
+// Span starts measuring a named duration and returns a function that stops it,
+// e.g. defer httplog.Span(ctx, "render")(). The duration is logged as part of
+// the nested Schema.Timings object in milliseconds. Spans of the same name are
+// summed up, e.g. for repeated calls in a loop.
+func Span(ctx context.Context, name string) func() {
+	rl := getRequestLog(ctx)
+	if rl == nil {
+		return func() {}
+	}
+	start := rl.clock.Now()
+	return func() {
+		rl.mu.Lock()
+		defer rl.mu.Unlock()
+		rl.setTiming(name, rl.clock.Since(start), true)
+	}
+}
+
+// MiddlewareTiming records the time d spent by the named middleware itself,
+// excluding the next handlers, e.g.:
+//
+//	start := time.Now()
+//	// authenticate the request
+//	httplog.MiddlewareTiming(r.Context(), "auth", time.Since(start))
+//	next.ServeHTTP(w, r)
+//
+// The durations are logged as a nested Schema.MiddlewareTimings object in
+// milliseconds, along with the remaining time of the request as "handler", so
+// that it's clear where the latency accrues in deep middleware chains. The
+// durations of the same name are summed up. Only the middlewares mounted after
+// the request logger can record their durations.
+func MiddlewareTiming(ctx context.Context, name string, d time.Duration) {
+	if rl := getRequestLog(ctx); rl != nil {
+		rl.mu.Lock()
+		defer rl.mu.Unlock()
+		for i := range rl.middlewareTimings {
+			if rl.middlewareTimings[i].name == name {
+				rl.middlewareTimings[i].duration += d
+				return
+			}
+		}
+		rl.middlewareTimings = append(rl.middlewareTimings, timing{name: name, duration: d})
+	}
+}
+
+// setTiming sets or adds the named duration. rl.mu must be held.
+func (rl *requestLog) setTiming(name string, d time.Duration, add bool) {
+	for i := range rl.timings {
+		if rl.timings[i].name == name {
+			if add {
+				d += rl.timings[i].duration
+			}
+			rl.timings[i].duration = d
+			return
+		}
+	}
+	rl.timings = append(rl.timings, timing{name: name, duration: d})
+}
+
+// timingsKVs returns the recorded timings as a nested object, or nil.
+func timingsKVs(ctx context.Context) map[string]any {
+	rl := getRequestLog(ctx)
+	if rl == nil {
+		return nil
+	}
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	if len(rl.timings) == 0 {
+		return nil
+	}
+	m := make(map[string]any, len(rl.timings))
+	for _, t := range rl.timings {
+		m[t.name] = float64(t.duration.Milliseconds())
+	}
+	return m
+}
+
+// middlewareTimingsKVs returns the durations recorded by MiddlewareTiming and
+// the remaining time of the request duration as "handler", or nil.
+func middlewareTimingsKVs(ctx context.Context, duration time.Duration) map[string]any {
+	rl := getRequestLog(ctx)
+	if rl == nil {
+		return nil
+	}
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	if len(rl.middlewareTimings) == 0 {
+		return nil
+	}
+	m := make(map[string]any, len(rl.middlewareTimings)+1)
+	for _, t := range rl.middlewareTimings {
+		m[t.name] = float64(t.duration.Milliseconds())
+		duration -= t.duration
+	}
+	m["handler"] = float64(max(duration, 0).Milliseconds())
+	return m
+}
+
+// serverTiming returns the Server-Timing header value of the timings recorded
+// so far and the elapsed time of the request as "total", see Options.ServerTiming.
+func (rl *requestLog) serverTiming() string {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	var b strings.Builder
+	for _, t := range rl.timings {
+		b.WriteString(serverTimingName(t.name))
+		b.WriteString(";dur=")
+		b.WriteString(serverTimingDuration(t.duration))
+		b.WriteString(", ")
+	}
+	b.WriteString("total;dur=")
+	b.WriteString(serverTimingDuration(rl.clock.Since(rl.start)))
+	return b.String()
+}
+
+// serverTimingName replaces the characters not allowed in the Server-Timing
+// metric names (HTTP tokens) with underscores.
+func serverTimingName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r > ' ' && r < 0x7f && !strings.ContainsRune(`"(),/:;<=>?@[\]{}`, r) {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+func serverTimingDuration(d time.Duration) string {
+	return strconv.FormatFloat(float64(d.Microseconds())/1000, 'f', -1, 64)
+}
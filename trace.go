@@ -0,0 +1,119 @@
+package httplog
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// TracePropagator extracts the trace context from the request headers, so that
+// the request log can be correlated with distributed traces. It returns empty
+// strings if the request carries no trace context of its kind.
+type TracePropagator func(r *http.Request) (traceID, spanID string)
+
+// TraceW3C extracts the W3C Trace Context "traceparent" header, e.g.
+// "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", which is also
+// used by Azure and OpenTelemetry.
+func TraceW3C(r *http.Request) (traceID, spanID string) {
+	parts := strings.Split(r.Header.Get("traceparent"), "-")
+	if len(parts) < 4 || len(parts[1]) != 32 || len(parts[2]) != 16 {
+		return "", ""
+	}
+	return parts[1], parts[2]
+}
+
+// TraceElasticAPM extracts the trace context propagated by Elastic APM agents,
+// i.e. the "elastic-apm-traceparent" header of older agents or the W3C
+// "traceparent" header. Use Options.TraceContext to log the transaction ID of
+// the Elastic APM agent instrumenting the service.
+func TraceElasticAPM(r *http.Request) (traceID, spanID string) {
+	if v := r.Header.Get("elastic-apm-traceparent"); v != "" && r.Header.Get("traceparent") == "" {
+		r = &http.Request{Header: http.Header{"Traceparent": {v}}}
+	}
+	return TraceW3C(r)
+}
+
+// TraceAWS extracts the AWS X-Ray "X-Amzn-Trace-Id" header set by ALB, e.g.
+// "Root=1-5759e988-bd862e3fe1be46a994272793;Parent=53995c3f42cd8ad8;Sampled=1".
+func TraceAWS(r *http.Request) (traceID, spanID string) {
+	for _, field := range strings.Split(r.Header.Get("X-Amzn-Trace-Id"), ";") {
+		key, value, _ := strings.Cut(strings.TrimSpace(field), "=")
+		switch key {
+		case "Root":
+			traceID = value
+		case "Parent":
+			spanID = value
+		}
+	}
+	return traceID, spanID
+}
+
+// TraceGCP extracts the Google Cloud "X-Cloud-Trace-Context" header, e.g.
+// "105445aa7843bc8bf206b12000100000/1;o=1". The decimal span ID of the header
+// is returned as 16 hex digits, as expected by Cloud Logging, e.g.
+// "0000000000000001". See Options.GCPProjectID for the trace ID format.
+func TraceGCP(r *http.Request) (traceID, spanID string) {
+	v, _, _ := strings.Cut(r.Header.Get("X-Cloud-Trace-Context"), ";")
+	traceID, spanID, _ = strings.Cut(v, "/")
+	if id, err := strconv.ParseUint(spanID, 10, 64); err == nil {
+		spanID = fmt.Sprintf("%016x", id)
+	} else {
+		spanID = ""
+	}
+	return traceID, spanID
+}
+
+// TraceAzure extracts the legacy Azure Application Insights "Request-Id" header,
+// e.g. "|4bf92f3577b34da6a3ce929d0e0e4736.00f067aa0ba902b7.". Newer Azure SDKs
+// propagate the W3C "traceparent" header instead, see TraceW3C.
+func TraceAzure(r *http.Request) (traceID, spanID string) {
+	v, ok := strings.CutPrefix(r.Header.Get("Request-Id"), "|")
+	if !ok {
+		return "", ""
+	}
+	traceID, rest, _ := strings.Cut(v, ".")
+	spanID, _, _ = strings.Cut(rest, ".")
+	return traceID, spanID
+}
+
+// traceKVs returns the trace context of the request as returned by
+// Options.TraceContext, or found by the first matching propagator.
+func traceKVs(r *http.Request, o *Options, s *Schema) []any {
+	if o.TraceContext != nil {
+		var traceID, transactionID, spanID string
+		callHook(r.Context(), "TraceContext", func() { traceID, transactionID, spanID = o.TraceContext(r.Context()) })
+		if traceID != "" {
+			kvs := []any{s.TraceID, qualifiedTraceID(traceID, o)}
+			if transactionID != "" {
+				kvs = append(kvs, s.TransactionID, transactionID)
+			}
+			if spanID != "" {
+				kvs = append(kvs, s.SpanID, spanID)
+			}
+			return kvs
+		}
+	}
+
+	for _, propagate := range o.TracePropagators {
+		traceID, spanID := propagate(r)
+		if traceID == "" {
+			continue
+		}
+		kvs := []any{s.TraceID, qualifiedTraceID(traceID, o)}
+		if spanID != "" {
+			kvs = append(kvs, s.SpanID, spanID)
+		}
+		return kvs
+	}
+	return nil
+}
+
+// qualifiedTraceID returns the trace ID in the projects/PROJECT/traces/ID
+// format of Cloud Logging, if Options.GCPProjectID is set.
+func qualifiedTraceID(traceID string, o *Options) string {
+	if o.GCPProjectID == "" {
+		return traceID
+	}
+	return "projects/" + o.GCPProjectID + "/traces/" + traceID
+}
@@ -0,0 +1,31 @@
+package httplog_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	httplog "github.com/rickliujh/chi-httplogr/v3"
+	"github.com/rickliujh/chi-httplogr/v3/httplogtest"
+)
+
+func TestTraceGCP(t *testing.T) {
+	rec := httplogtest.NewRecorder()
+	handler := httplog.RequestLogger(rec.Logger(), &httplog.Options{
+		Schema:           httplog.SchemaGCP,
+		Levels:           &httplog.Levels{Warn: 1, Info: 1, Debug: 1},
+		TracePropagators: []httplog.TracePropagator{httplog.TraceGCP},
+		GCPProjectID:     "my-project",
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Cloud-Trace-Context", "105445aa7843bc8bf206b12000100000/255;o=1")
+	_, entry := rec.RoundTrip(handler, req)
+
+	if !entry.HasKV(httplog.SchemaGCP.TraceID, "projects/my-project/traces/105445aa7843bc8bf206b12000100000") {
+		t.Errorf("entry is missing the qualified trace ID: %v", entry.KVs)
+	}
+	if !entry.HasKV(httplog.SchemaGCP.SpanID, "00000000000000ff") {
+		t.Errorf("entry is missing the hex span ID: %v", entry.KVs)
+	}
+}
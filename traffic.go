@@ -0,0 +1,63 @@
+package httplog
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Traffic classes returned by ClassifyTraffic, see Options.TrafficClassFunc.
+const (
+	TrafficUser    = "user"
+	TrafficProbe   = "probe"
+	TrafficMonitor = "monitor"
+	TrafficBot     = "bot"
+)
+
+// probeUserAgents are the User-Agents of load balancer and orchestrator health checks.
+var probeUserAgents = []string{
+	"kube-probe/",
+	"elb-healthchecker/",
+	"googlehc/",
+	"consul health check",
+	"envoy/hc",
+}
+
+// monitorUserAgents are the User-Agents of uptime checkers and synthetic monitors.
+var monitorUserAgents = []string{
+	"uptimerobot/",
+	"pingdom.com_bot",
+	"statuscake",
+	"datadogsynthetics",
+	"newrelicsynthetics/",
+	"googlestackdrivermonitoring-uptimechecks",
+	"site24x7",
+	"betteruptime",
+	"checkly/",
+	"amazon-route53-health-check-service",
+}
+
+// ClassifyTraffic is a built-in traffic classifier, which can be used as
+// Options.TrafficClassFunc. It classifies the request by its User-Agent as
+// TrafficProbe (e.g. Kubernetes probes, load balancer health checks),
+// TrafficMonitor (e.g. uptime checkers), TrafficBot (e.g. crawlers) or TrafficUser.
+func ClassifyTraffic(r *http.Request) string {
+	lower := strings.ToLower(r.UserAgent())
+	switch {
+	case containsAny(lower, probeUserAgents):
+		return TrafficProbe
+	case containsAny(lower, monitorUserAgents):
+		return TrafficMonitor
+	case userAgentIsBot(lower):
+		return TrafficBot
+	}
+	return TrafficUser
+}
+
+func containsAny(s string, substrs []string) bool {
+	for _, substr := range substrs {
+		if strings.Contains(s, substr) {
+			return true
+		}
+	}
+	return false
+}
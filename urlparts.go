@@ -0,0 +1,36 @@
+package httplog
+
+import (
+	"net"
+	"net/http"
+)
+
+// urlPartsKVs returns the structured parts of the request URL, i.e. the port,
+// raw query, with the Options.RedactQueryParams redacted, and fragment, see
+// Options.LogURLParts.
+func urlPartsKVs(r *http.Request, reqScheme string, s *Schema, o *Options) []any {
+	_, port, err := net.SplitHostPort(r.Host)
+	if err != nil || port == "" {
+		port = "80"
+		if reqScheme == "https" {
+			port = "443"
+		}
+	}
+
+	kvs := []any{s.RequestPort, port}
+	if r.URL.RawQuery != "" {
+		kvs = append(kvs, s.RequestQuery, redactedURL(r.URL, o).RawQuery)
+	}
+	if r.URL.Fragment != "" {
+		kvs = append(kvs, s.RequestFragment, r.URL.Fragment)
+	}
+	return kvs
+}
+
+// hostname returns the host of the request without the port.
+func hostname(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.Host); err == nil {
+		return host
+	}
+	return r.Host
+}
@@ -0,0 +1,107 @@
+package httplog
+
+import (
+	"strings"
+)
+
+// ParseUserAgent is a lightweight built-in User-Agent parser, which can be used
+// as Options.UserAgentParser. It returns the browser, OS and device family of
+// the client along with the is_bot flag (logged as Schema.ClientIsBot) as
+// key-value pairs, e.g.:
+//
+//	"browser", "Chrome", "os", "Android", "device", "mobile", "is_bot", false
+//
+// It recognizes major browsers and platforms only; plug in a full-fledged parser
+// for anything more detailed.
+func ParseUserAgent(ua string) []any {
+	if ua == "" {
+		return nil
+	}
+	lower := strings.ToLower(ua)
+	isBot := userAgentIsBot(lower)
+
+	device := "desktop"
+	switch {
+	case isBot:
+		device = "other"
+	case strings.Contains(lower, "ipad"), strings.Contains(lower, "tablet"),
+		strings.Contains(lower, "android") && !strings.Contains(lower, "mobile"):
+		device = "tablet"
+	case strings.Contains(lower, "mobi"), strings.Contains(lower, "iphone"):
+		device = "mobile"
+	}
+
+	return []any{
+		"browser", userAgentBrowser(ua, lower),
+		"os", userAgentOS(lower),
+		"device", device,
+		"is_bot", isBot,
+	}
+}
+
+var botUserAgents = []string{"bot", "crawler", "spider", "slurp", "curl/", "wget/", "python-requests", "go-http-client", "headlesschrome"}
+
+func userAgentIsBot(lower string) bool {
+	for _, bot := range botUserAgents {
+		if strings.Contains(lower, bot) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitIsBot removes the boolean "is_bot" flag from the key-value pairs returned
+// by Options.UserAgentParser and returns it, or nil if there's none.
+func splitIsBot(kvs []any) (details []any, isBot any) {
+	for i := 0; i+1 < len(kvs); i += 2 {
+		if k, ok := kvs[i].(string); ok && k == "is_bot" {
+			if _, ok := kvs[i+1].(bool); ok {
+				details = append(append(make([]any, 0, len(kvs)-2), kvs[:i]...), kvs[i+2:]...)
+				return details, kvs[i+1]
+			}
+		}
+	}
+	return kvs, nil
+}
+
+func userAgentBrowser(ua, lower string) string {
+	switch {
+	case strings.Contains(ua, "Edg/"), strings.Contains(ua, "Edge/"):
+		return "Edge"
+	case strings.Contains(ua, "OPR/"), strings.Contains(ua, "Opera"):
+		return "Opera"
+	case strings.Contains(ua, "Firefox/"), strings.Contains(ua, "FxiOS/"):
+		return "Firefox"
+	case strings.Contains(ua, "Chrome/"), strings.Contains(ua, "CriOS/"):
+		return "Chrome"
+	case strings.Contains(ua, "Safari/") && strings.Contains(ua, "Version/"):
+		return "Safari"
+	case strings.Contains(ua, "MSIE "), strings.Contains(ua, "Trident/"):
+		return "Internet Explorer"
+	}
+
+	// Non-browser clients, e.g. "curl/8.4.0" or "Googlebot/2.1".
+	name, _, _ := strings.Cut(ua, "/")
+	if !strings.ContainsAny(name, " ;(") && userAgentIsBot(lower) {
+		return name
+	}
+	return "Other"
+}
+
+func userAgentOS(lower string) string {
+	switch {
+	case strings.Contains(lower, "windows"):
+		return "Windows"
+	case strings.Contains(lower, "android"):
+		return "Android"
+	case strings.Contains(lower, "iphone"), strings.Contains(lower, "ipad"), strings.Contains(lower, "ios"):
+		return "iOS"
+	case strings.Contains(lower, "mac os x"), strings.Contains(lower, "macintosh"):
+		return "macOS"
+	case strings.Contains(lower, "cros"):
+		return "ChromeOS"
+	case strings.Contains(lower, "linux"):
+		return "Linux"
+	}
+	return "Other"
+}
@@ -0,0 +1,60 @@
+package httplog_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	httplog "github.com/rickliujh/chi-httplogr/v3"
+	"github.com/rickliujh/chi-httplogr/v3/httplogtest"
+)
+
+func TestParseUserAgent(t *testing.T) {
+	tests := []struct {
+		ua   string
+		want []any
+	}{
+		{
+			ua:   "Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Mobile Safari/537.36",
+			want: []any{"browser", "Chrome", "os", "Android", "device", "mobile", "is_bot", false},
+		},
+		{
+			ua:   "Googlebot/2.1 (+http://www.google.com/bot.html)",
+			want: []any{"browser", "Googlebot", "os", "Other", "device", "other", "is_bot", true},
+		},
+		{
+			ua:   "curl/8.4.0",
+			want: []any{"browser", "curl", "os", "Other", "device", "other", "is_bot", true},
+		},
+		{
+			// Ordinary clients merely mentioning checks or monitors aren't bots.
+			ua:   "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36 CheckoutApp/2.0 MonitorView/1.1",
+			want: []any{"browser", "Chrome", "os", "Windows", "device", "desktop", "is_bot", false},
+		},
+	}
+	for _, tt := range tests {
+		if got := httplog.ParseUserAgent(tt.ua); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseUserAgent(%q) = %v, want %v", tt.ua, got, tt.want)
+		}
+	}
+}
+
+func TestClientIsBot(t *testing.T) {
+	rec := httplogtest.NewRecorder()
+	handler := httplog.RequestLogger(rec.Logger(), &httplog.Options{
+		Levels:          &httplog.Levels{Warn: 1, Info: 1, Debug: 1},
+		UserAgentParser: httplog.ParseUserAgent,
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("User-Agent", "Mozilla/5.0 (compatible; bingbot/2.0; +http://www.bing.com/bingbot.htm)")
+	_, entry := rec.RoundTrip(handler, req)
+	if !entry.HasKV(httplog.SchemaECS.ClientIsBot, true) {
+		t.Errorf("entry is missing the top-level bot flag: %v", entry.KVs)
+	}
+	details, _ := entry.Value(httplog.SchemaECS.RequestUserAgentDetails)
+	if _, ok := details.(map[string]any)["is_bot"]; ok {
+		t.Errorf("the bot flag is logged in the User-Agent details too: %v", details)
+	}
+}
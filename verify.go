@@ -0,0 +1,117 @@
+package httplog
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"runtime"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+var (
+	// ErrRecovererAfterLogger is reported by Verify for routes where chi's
+	// middleware.Recoverer is mounted after RequestLogger: it recovers the panics
+	// of the handler before they reach RequestLogger, which then logs an HTTP 500
+	// without the panic and its stack trace. Mount middleware.Recoverer before
+	// RequestLogger, or use httplog.Recoverer, which passes the panics on.
+	ErrRecovererAfterLogger = errors.New("middleware.Recoverer is mounted after RequestLogger")
+
+	// Deprecated: Use ErrRecovererAfterLogger.
+	ErrLoggerAfterRecoverer = ErrRecovererAfterLogger
+
+	// ErrLoggerMountedTwice is reported by Verify for routes where RequestLogger
+	// is mounted more than once.
+	ErrLoggerMountedTwice = errors.New("RequestLogger is mounted twice")
+
+	// ErrLoggerNotMounted is reported by Verify if RequestLogger isn't mounted on
+	// the router. If it wraps the router instead, e.g. http.ListenAndServe(addr,
+	// logger(r)), the route patterns are empty, since they are resolved within the
+	// router.
+	ErrLoggerNotMounted = errors.New("RequestLogger is not mounted on the router")
+)
+
+// ChainError is a mistake in the middleware chain of the routes reported by Verify.
+type ChainError struct {
+	Err    error    // ErrRecovererAfterLogger, ErrLoggerMountedTwice or ErrLoggerNotMounted
+	Routes []string // affected routes, e.g. "GET /users/{id}"
+}
+
+func (e *ChainError) Error() string {
+	if len(e.Routes) == 0 {
+		return "httplog: " + e.Err.Error()
+	}
+	return fmt.Sprintf("httplog: %v: %s", e.Err, strings.Join(e.Routes, ", "))
+}
+
+func (e *ChainError) Unwrap() error {
+	return e.Err
+}
+
+// Verify walks the middleware chains of the router's routes and reports the
+// common mistakes in mounting RequestLogger, i.e. mounting chi's
+// middleware.Recoverer after it, mounting it twice or not mounting it on the router at
+// all. The mistakes are returned as *ChainError values joined by errors.Join,
+// so they can be checked with errors.Is, e.g. at startup:
+//
+//	if err := httplog.Verify(r); err != nil {
+//		log.Fatal(err)
+//	}
+func Verify(r chi.Routes) error {
+	var recovererAfter, twice []string
+	mounted := false
+
+	err := chi.Walk(r, func(method, route string, _ http.Handler, middlewares ...func(http.Handler) http.Handler) error {
+		loggers, flagged := 0, false
+		for _, mw := range middlewares {
+			switch name := funcName(mw); {
+			case isRequestLogger(name):
+				loggers++
+			case name == "github.com/go-chi/chi/v5/middleware.Recoverer":
+				if loggers > 0 && !flagged {
+					recovererAfter = append(recovererAfter, method+" "+route)
+					flagged = true
+				}
+			}
+		}
+		if loggers > 0 {
+			mounted = true
+		}
+		if loggers > 1 {
+			twice = append(twice, method+" "+route)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	if len(recovererAfter) > 0 {
+		errs = append(errs, &ChainError{Err: ErrRecovererAfterLogger, Routes: recovererAfter})
+	}
+	if len(twice) > 0 {
+		errs = append(errs, &ChainError{Err: ErrLoggerMountedTwice, Routes: twice})
+	}
+	if !mounted {
+		errs = append(errs, &ChainError{Err: ErrLoggerNotMounted})
+	}
+	return errors.Join(errs...)
+}
+
+// pkgPath is the import path of this package, used to recognize RequestLogger.
+var pkgPath = reflect.TypeOf(Options{}).PkgPath()
+
+func isRequestLogger(name string) bool {
+	return strings.HasPrefix(name, pkgPath+".RequestLogger.") || strings.HasPrefix(name, pkgPath+".perHostLogger.")
+}
+
+func funcName(f any) string {
+	fn := runtime.FuncForPC(reflect.ValueOf(f).Pointer())
+	if fn == nil {
+		return ""
+	}
+	return fn.Name()
+}
@@ -0,0 +1,64 @@
+package httplog_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-logr/logr/funcr"
+	httplog "github.com/rickliujh/chi-httplogr/v3"
+)
+
+func TestVerifyRecovererOrder(t *testing.T) {
+	tests := []struct {
+		name          string
+		mount         func(r chi.Router, logger func(http.Handler) http.Handler)
+		wantErr       bool
+		wantPanicLogs bool
+	}{
+		{
+			name: "RecovererBeforeLogger",
+			mount: func(r chi.Router, logger func(http.Handler) http.Handler) {
+				r.Use(middleware.Recoverer)
+				r.Use(logger)
+			},
+			wantPanicLogs: true,
+		},
+		{
+			name: "RecovererAfterLogger",
+			mount: func(r chi.Router, logger func(http.Handler) http.Handler) {
+				r.Use(logger)
+				r.Use(middleware.Recoverer)
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logs strings.Builder
+			logger := funcr.NewJSON(func(obj string) { logs.WriteString(obj + "\n") }, funcr.Options{})
+
+			r := chi.NewRouter()
+			tt.mount(r, httplog.RequestLogger(logger, &httplog.Options{}))
+			r.Get("/panic", func(w http.ResponseWriter, r *http.Request) { panic("boom") })
+
+			err := httplog.Verify(r)
+			if got := errors.Is(err, httplog.ErrRecovererAfterLogger); got != tt.wantErr {
+				t.Errorf("Verify() = %v, want ErrRecovererAfterLogger: %v", err, tt.wantErr)
+			}
+
+			func() {
+				defer func() { recover() }()
+				r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/panic", nil))
+			}()
+			if got := strings.Contains(logs.String(), "panic: boom"); got != tt.wantPanicLogs {
+				t.Errorf("panic logged: %v, want %v; logs:\n%s", got, tt.wantPanicLogs, logs.String())
+			}
+		})
+	}
+}
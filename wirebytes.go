@@ -0,0 +1,63 @@
+package httplog
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// dateHeaderLen is the length of the "Date" header line added by net/http.
+const dateHeaderLen = len("Date: Mon, 02 Jan 2006 15:04:05 GMT\r\n")
+
+// responseHeaderBytes estimates the size of the HTTP/1.1 status line and the
+// response headers on the wire, incl. the Date and Content-Length headers added
+// by net/http. HTTP/2 compresses the headers, so it's an upper bound there.
+func responseHeaderBytes(proto string, status int, header http.Header, bodyBytes int) int {
+	n := len(proto) + len(" 000 ") + len(http.StatusText(status)) + len("\r\n")
+	for key, vals := range header {
+		for _, v := range vals {
+			n += len(key) + len(": ") + len(v) + len("\r\n")
+		}
+	}
+	if header.Get("Date") == "" {
+		n += dateHeaderLen
+	}
+	if header.Get("Content-Length") == "" && header.Get("Transfer-Encoding") == "" {
+		n += len("Content-Length: \r\n") + len(strconv.Itoa(bodyBytes))
+	}
+	return n + len("\r\n")
+}
+
+// bytesPerSec returns the throughput of n bytes transferred over the duration,
+// or 0 if the duration is not positive.
+func bytesPerSec(n int64, duration time.Duration) float64 {
+	if n <= 0 || duration <= 0 {
+		return 0
+	}
+	return math.Round(float64(n) / duration.Seconds())
+}
+
+// requestHeaderBytes estimates the size of the request headers on the wire,
+// incl. the Host header, and returns it along with the number of header lines.
+func requestHeaderBytes(r *http.Request) (bytes, count int) {
+	bytes, count = len("Host: ")+len(r.Host)+len("\r\n"), 1
+	for key, vals := range r.Header {
+		for _, v := range vals {
+			bytes += len(key) + len(": ") + len(v) + len("\r\n")
+			count++
+		}
+	}
+	return bytes, count
+}
+
+// headContentLength returns the Content-Length of the response to a HEAD
+// request, i.e. the one set by the handler, or else the number of body bytes
+// written by the handler and discarded by net/http, which is the Content-Length
+// of the GET response of handlers serving both methods.
+func headContentLength(header http.Header, written int) int64 {
+	if n, err := strconv.ParseInt(header.Get("Content-Length"), 10, 64); err == nil && n >= 0 {
+		return n
+	}
+	return int64(written)
+}